@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"ES/internal/domain"
+	"ES/internal/ports"
+
+	"github.com/olivere/elastic/v7"
+)
+
+// backfillRepository คือส่วนของ MySQL repository ที่ backfill ใช้
+type backfillRepository interface {
+	StreamRichBranchData(ctx context.Context, dbtx ports.DBTX, filter domain.BranchFilter, afterID int64, pageSize int, fn func(page []*domain.Branch) error) error
+	CountBranches(ctx context.Context, dbtx ports.DBTX, filter domain.BranchFilter) (int64, error)
+	GetLatestOutboxEventID(ctx context.Context, dbtx ports.DBTX) (int64, error)
+	GetBranchIDsWithEventsAfter(ctx context.Context, dbtx ports.DBTX, afterEventID int64) ([]int64, error)
+	GetRichBranchDataByIDs(ctx context.Context, dbtx ports.DBTX, ids []int64) ([]*domain.Branch, error)
+}
+
+// catchUp เขียนสาขาที่มี outbox event หลัง snapshotVersion ลงใน index ใหม่อีกครั้ง
+//
+// ระหว่างที่ backfill ทำงาน alias ยังชี้ index เดิม worker จึงเขียน event เหล่านั้นลง index เดิมเท่านั้น
+// หลังย้าย alias แล้ว สาขาเหล่านี้ถูกอ่านจาก MySQL ใหม่และเขียนด้วย version เท่ากับ ID ล่าสุดของ outbox ในตอนนั้น
+// event ที่ใหม่กว่านั้นจะถูก worker เขียนผ่าน alias และชนะเสมอ ส่วน event ที่เก่ากว่าและยังค้างอยู่จะถูกปฏิเสธ
+func catchUp(ctx context.Context, db *sql.DB, repo backfillRepository, esClient *elastic.Client, index string, snapshotVersion int64) error {
+	// ต้องอ่าน version ก่อนอ่านข้อมูลสาขา ข้อมูลที่อ่านจึงใหม่อย่างน้อยเท่ากับ version นี้
+	version, err := repo.GetLatestOutboxEventID(ctx, db)
+	if err != nil {
+		return err
+	}
+	ids, err := repo.GetBranchIDsWithEventsAfter(ctx, db, snapshotVersion)
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		log.Println("No branches changed during the backfill.")
+		return nil
+	}
+	branches, err := repo.GetRichBranchDataByIDs(ctx, db, ids)
+	if err != nil {
+		return err
+	}
+
+	found := make(map[int64]*domain.Branch, len(branches))
+	for _, b := range branches {
+		found[b.ID] = b
+	}
+
+	bulk := esClient.Bulk()
+	for _, id := range ids {
+		docID := strconv.FormatInt(id, 10)
+		if branch, ok := found[id]; ok {
+			bulk.Add(elastic.NewBulkIndexRequest().Index(index).Id(docID).VersionType("external_gte").Version(version).Doc(branch))
+		} else {
+			// สาขาถูกลบระหว่าง backfill
+			bulk.Add(elastic.NewBulkDeleteRequest().Index(index).Id(docID).VersionType("external_gte").Version(version))
+		}
+	}
+	resp, err := bulk.Do(ctx)
+	if err != nil {
+		return fmt.Errorf("catch-up bulk request failed: %w", err)
+	}
+
+	failed := 0
+	for _, item := range resp.Items {
+		for op, result := range item {
+			if result.Status < 300 || result.Status == http.StatusConflict || (op == "delete" && result.Status == http.StatusNotFound) {
+				continue
+			}
+			failed++
+			log.Printf("ERROR: Catch-up %s of branch %s failed: %v", op, result.Id, result.Error)
+		}
+	}
+	log.Printf("Caught up %d branches changed during the backfill (%d failed).", len(ids)-failed, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d branches could not be caught up", failed)
+	}
+	return nil
+}
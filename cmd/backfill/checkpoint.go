@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"ES/internal/domain"
+)
+
+// checkpoint คือความคืบหน้าของการ backfill ที่บันทึกลงไฟล์หลังจาก Elasticsearch ยืนยันแต่ละหน้าแล้ว
+// ถ้า backfill หยุดกลางคัน -resume จะอ่านไฟล์นี้และทำต่อจากสาขาถัดจาก LastID
+// ด้วย index, version และ filter เดิม ไม่ต้องเริ่มจากสาขาแรกใหม่
+type checkpoint struct {
+	Index     string              `json:"index"`   // index หรือ alias ที่กำลังเขียน
+	Rebuild   bool                `json:"rebuild"` // true ถ้าเป็นการสร้าง index ใหม่ทั้งหมดแล้วย้าย alias
+	Version   int64               `json:"version"` // external version ที่ใช้เขียน (ID ล่าสุดของ outbox ตอนเริ่ม)
+	Filter    domain.BranchFilter `json:"filter"`
+	LastID    int64               `json:"last_id"` // ID ของสาขาสุดท้ายที่ Elasticsearch ยืนยันแล้ว
+	Indexed   int64               `json:"indexed"` // จำนวนสาขาที่ index แล้วทั้งหมด รวมทุกครั้งที่ resume
+	StartedAt time.Time           `json:"started_at"`
+	UpdatedAt time.Time           `json:"updated_at"`
+}
+
+// loadCheckpoint อ่าน checkpoint จากไฟล์
+func loadCheckpoint(path string) (*checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("no checkpoint at %s to resume from", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+	var cp checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint %s: %w", path, err)
+	}
+	return &cp, nil
+}
+
+// save เขียน checkpoint ลงไฟล์ชั่วคราวแล้ว rename ทับ ไฟล์จึงไม่เสียแม้โปรแกรมหยุดระหว่างเขียน
+func (cp *checkpoint) save(path string) error {
+	cp.UpdatedAt = time.Now()
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal checkpoint: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to replace checkpoint: %w", err)
+	}
+	return nil
+}
+
+// removeCheckpoint ลบ checkpoint หลังจาก backfill เสร็จสมบูรณ์
+func removeCheckpoint(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove checkpoint: %w", err)
+	}
+	return nil
+}
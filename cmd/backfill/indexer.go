@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"ES/internal/domain"
+
+	"github.com/olivere/elastic/v7"
+)
+
+// bulkIndexer ส่ง document ของสาขาไปยัง Elasticsearch ผ่าน BulkProcessor
+// BulkProcessor รวม document เป็น _bulk request ตามจำนวนหรือขนาดที่กำหนด และส่งพร้อมกันหลาย worker
+// ผลลัพธ์ของแต่ละ item ถูกนับใน After callback ซึ่งอาจถูกเรียกจากหลาย goroutine จึงใช้ atomic
+type bulkIndexer struct {
+	processor *elastic.BulkProcessor
+	index     string
+	version   int64
+
+	queued    int64
+	succeeded int64
+	failed    int64
+	started   time.Time
+}
+
+// bulkIndexerOptions คือการตั้งค่าของ BulkProcessor
+type bulkIndexerOptions struct {
+	workers     int // จำนวน _bulk request ที่ส่งพร้อมกัน
+	bulkActions int // จำนวน document ต่อ _bulk request
+	bulkSizeMB  int // ขนาดสูงสุดของ _bulk request (MB)
+}
+
+func newBulkIndexer(ctx context.Context, esClient *elastic.Client, index string, version int64, opts bulkIndexerOptions) (*bulkIndexer, error) {
+	b := &bulkIndexer{index: index, version: version, started: time.Now()}
+	processor, err := esClient.BulkProcessor().
+		Name("backfill").
+		Workers(opts.workers).
+		BulkActions(opts.bulkActions).
+		BulkSize(opts.bulkSizeMB << 20).
+		FlushInterval(5 * time.Second).
+		After(b.after).
+		Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start bulk processor: %w", err)
+	}
+	b.processor = processor
+	return b, nil
+}
+
+// Add ใส่ document ของสาขาเข้าคิว ส่งจริงเมื่อครบ bulkActions/bulkSizeMB หรือเมื่อ Flush
+// ใช้ version_type=external_gte กับ version ของ snapshot เหมือนกับ event ของ worker (ดู cmd/worker/handlers.go)
+func (b *bulkIndexer) Add(branch *domain.Branch) {
+	atomic.AddInt64(&b.queued, 1)
+	b.processor.Add(elastic.NewBulkIndexRequest().
+		Index(b.index).
+		Id(strconv.FormatInt(branch.ID, 10)).
+		VersionType("external_gte").
+		Version(b.version).
+		Doc(branch))
+}
+
+// Flush ส่ง document ที่ค้างอยู่ทั้งหมดและรอจนได้ผลลัพธ์
+func (b *bulkIndexer) Flush() error {
+	return b.processor.Flush()
+}
+
+// Close ส่ง document ที่ค้างอยู่และหยุด BulkProcessor
+func (b *bulkIndexer) Close() error {
+	return b.processor.Close()
+}
+
+func (b *bulkIndexer) after(executionID int64, requests []elastic.BulkableRequest, response *elastic.BulkResponse, err error) {
+	if err != nil {
+		atomic.AddInt64(&b.failed, int64(len(requests)))
+		log.Printf("ERROR: Bulk request %d with %d documents failed: %v", executionID, len(requests), err)
+		return
+	}
+	for _, item := range response.Items {
+		for _, result := range item {
+			// 409 หมายถึงมี version ที่ใหม่กว่าอยู่แล้ว จึงถือว่าสำเร็จ
+			if result.Status < 300 || result.Status == http.StatusConflict {
+				atomic.AddInt64(&b.succeeded, 1)
+				continue
+			}
+			atomic.AddInt64(&b.failed, 1)
+			log.Printf("ERROR: Failed to index branch ID %s: %v", result.Id, result.Error)
+		}
+	}
+}
+
+// Succeeded คืนจำนวน document ที่ Elasticsearch ยืนยันแล้ว
+func (b *bulkIndexer) Succeeded() int64 { return atomic.LoadInt64(&b.succeeded) }
+
+// Failed คืนจำนวน document ที่เขียนไม่สำเร็จ
+func (b *bulkIndexer) Failed() int64 { return atomic.LoadInt64(&b.failed) }
+
+// logProgress พิมพ์จำนวน document ที่อ่าน ยืนยันแล้ว และความเร็วเฉลี่ยตั้งแต่เริ่ม
+func (b *bulkIndexer) logProgress(lastID int64) {
+	elapsed := time.Since(b.started)
+	succeeded := b.Succeeded()
+	log.Printf("Progress: read %d, indexed %d, failed %d, last branch ID %d (%.0f docs/s, elapsed %s).",
+		atomic.LoadInt64(&b.queued), succeeded, b.Failed(), lastID,
+		float64(succeeded)/elapsed.Seconds(), elapsed.Round(time.Second))
+}
@@ -3,83 +3,322 @@ package main
 import (
 	"context"
 	"database/sql"
+	"flag"
+	"fmt"
 	"log"
-	"os"
 	"strconv"
+	"strings"
 	"time"
 
+	"ES/internal/config"
+	"ES/internal/domain"
 	"ES/internal/repositories"
 
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/olivere/elastic/v7"
 )
 
+// Backfill เขียน document ของสาขาจาก MySQL ลง Elasticsearch มีสองแบบ
+//
+// ไม่ระบุ filter: สร้าง index ของสาขาใหม่ทั้งหมดโดยไม่กระทบการค้นหาที่ใช้งานอยู่
+//
+//  1. สร้าง index ใหม่ชื่อ branches_v<timestamp> ด้วย mapping ปัจจุบัน
+//  2. อ่านสาขาจาก MySQL ทีละหน้า แล้วเติมลงใน index ใหม่ผ่าน Bulk API แบบขนาน
+//  3. ตรวจว่าจำนวน document ตรงกับจำนวนสาขาใน MySQL
+//  4. ย้าย alias "branches" ไปที่ index ใหม่ใน request เดียว
+//  5. index สาขาที่เปลี่ยนแปลงระหว่างการ backfill ซ้ำอีกครั้ง (ดู catchUp)
+//
+// ถ้าขั้นตอนใดล้มเหลวก่อนย้าย alias index เดิมจะยังถูกใช้งานต่อ และ index ใหม่จะถูกทิ้งไว้ให้ตรวจสอบหรือ -resume
+//
+// ระบุ filter (-ids, -from-id, -to-id, -updated-after): เขียนเฉพาะสาขาที่เลือกลง alias ที่ใช้งานอยู่โดยตรง
+//
+// ทั้งสองแบบบันทึก checkpoint หลังจากแต่ละหน้าถูกยืนยัน และ -resume จะทำต่อจาก checkpoint
 func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("failed to load configuration: %v", err)
+	}
+
+	alias := flag.String("alias", cfg.Elasticsearch.BranchIndex, "ชื่อ alias ของสาขาที่ API และ worker ใช้")
+	deleteOld := flag.Bool("delete-old", false, "ลบ index ที่ alias เคยชี้อยู่หลังจากย้าย alias สำเร็จ")
+	pageSize := flag.Int("page-size", 1000, "จำนวนสาขาที่อ่านจาก MySQL ต่อหนึ่ง query (checkpoint ถูกบันทึกทุกหน้า)")
+	workers := flag.Int("workers", 4, "จำนวน _bulk request ที่ส่งไป Elasticsearch พร้อมกัน")
+	bulkActions := flag.Int("bulk-actions", 500, "จำนวน document ต่อหนึ่ง _bulk request")
+	bulkSizeMB := flag.Int("bulk-size-mb", 5, "ขนาดสูงสุดของหนึ่ง _bulk request (MB)")
+	ids := flag.String("ids", "", "เฉพาะสาขาที่มี ID เหล่านี้ คั่นด้วยจุลภาค เช่น 1,5,9")
+	fromID := flag.Int64("from-id", 0, "เฉพาะสาขาที่ ID ตั้งแต่ค่านี้")
+	toID := flag.Int64("to-id", 0, "เฉพาะสาขาที่ ID ไม่เกินค่านี้")
+	updatedAfter := flag.String("updated-after", "", "เฉพาะสาขาที่ updated_at หลังเวลานี้ (RFC3339 หรือ YYYY-MM-DD ตามเวลา UTC)")
+	checkpointPath := flag.String("checkpoint", "backfill.checkpoint.json", "ไฟล์ที่บันทึกความคืบหน้า")
+	resume := flag.Bool("resume", false, "ทำต่อจาก checkpoint ด้วย index, version และ filter เดิม")
+	dryRun := flag.Bool("dry-run", false, "แสดงสาขาที่จะถูก index โดยไม่เขียนลง Elasticsearch")
+	flag.Parse()
+
+	filter, err := parseFilter(*ids, *fromID, *toID, *updatedAfter)
+	if err != nil {
+		log.Fatalf("Invalid filter: %v", err)
+	}
+
 	log.Println("--- Starting Backfill Process ---")
 	startTime := time.Now()
 
 	ctx := context.Background()
 
 	// --- 1. Connect to MySQL ---
-	dsn := os.Getenv("DATABASE_DSN")
-	if dsn == "" {
-		dsn = "root:123456@tcp(127.0.0.1:3306)/TTDB?parseTime=true"
-	}
-	db, err := sql.Open("mysql", dsn)
+	db, err := cfg.Database.OpenDB()
 	if err != nil {
-		log.Fatalf("failed to open database connection: %v", err)
+		log.Fatalf("%v", err)
 	}
 	defer db.Close()
 	log.Println("Successfully connected to MySQL.")
 
-	// --- 2. Connect to Elasticsearch ---
-	esClient, err := elastic.NewClient(
-		elastic.SetURL("http://localhost:9200"),
-		elastic.SetSniff(false),
-	)
+	// เราจะใช้ Repository ที่มีอยู่แล้วเพื่อดึงข้อมูล
+	repo := repositories.NewMySQLRepository(db)
+
+	// --- 2. โหลด checkpoint (ถ้า resume) ---
+	var cp *checkpoint
+	if *resume {
+		cp, err = loadCheckpoint(*checkpointPath)
+		if err != nil {
+			log.Fatalf("Failed to resume: %v", err)
+		}
+		if !filter.IsEmpty() {
+			log.Println("WARNING: Filter flags are ignored when resuming; the filter saved in the checkpoint is used.")
+		}
+		filter = cp.Filter
+		log.Printf("Resuming backfill into %s after branch ID %d (%d branches already indexed).", cp.Index, cp.LastID, cp.Indexed)
+	}
+
+	// --- 3. Dry run: แสดงเฉพาะสาขาที่จะถูก index ---
+	if *dryRun {
+		afterID := int64(0)
+		if cp != nil {
+			afterID = cp.LastID
+		}
+		if err := printDryRun(ctx, db, repo, filter, afterID, *pageSize); err != nil {
+			log.Fatalf("Dry run failed: %v", err)
+		}
+		return
+	}
+
+	// --- 4. Connect to Elasticsearch ---
+	esClient, err := cfg.Elasticsearch.NewClient()
 	if err != nil {
 		log.Fatalf("Error creating the Elasticsearch client: %s", err)
 	}
 	log.Println("Successfully connected to Elasticsearch.")
+	indexes := repositories.NewElasticsearchRepository(esClient, *alias)
 
-	// --- 3. Initialize Repository ---
-	// เราจะใช้ Repository ที่มีอยู่แล้วเพื่อดึงข้อมูล
-	repo := repositories.NewMySQLRepository(db)
+	// --- 5. เลือก index ปลายทาง ---
+	if cp == nil {
+		// worker ใช้ ID ของ outbox event เป็น external version ของ document
+		// backfill จึงเขียนด้วย version นี้ (external_gte) เพื่อให้ event ที่เกิดขึ้นระหว่างหรือหลังการ backfill ชนะเสมอ
+		// และ event เก่าที่ยังค้างอยู่จะไม่เขียนทับข้อมูลที่ใหม่กว่าของ backfill
+		// ต้องอ่านก่อนอ่านข้อมูลสาขา
+		version, err := repo.GetLatestOutboxEventID(ctx, db)
+		if err != nil {
+			log.Fatalf("Failed to read latest outbox event id: %v", err)
+		}
+		cp = &checkpoint{Index: *alias, Rebuild: filter.IsEmpty(), Version: version, Filter: filter, StartedAt: startTime}
 
-	// --- 4. Get ALL rich branch data in a SINGLE query ---
-	log.Println("Fetching all rich branch data from MySQL in a single query...")
-	allBranches, err := repo.GetAllRichBranchData(ctx, db)
+		if cp.Rebuild {
+			cp.Index = repositories.NewBranchIndexName(*alias, startTime)
+			if err := indexes.CreateBranchIndex(ctx, cp.Index); err != nil {
+				log.Fatalf("Failed to create index: %v", err)
+			}
+			log.Printf("Created index %s (mapping version %d).", cp.Index, repositories.BranchMappingVersion)
+		} else {
+			// การเขียนเฉพาะบางสาขาไม่ต้องสร้าง index ใหม่ แต่ index ต้องมีอยู่แล้ว
+			if _, err := indexes.EnsureBranchIndex(ctx); err != nil {
+				log.Fatalf("Failed to ensure %s index: %v", *alias, err)
+			}
+			log.Printf("Writing selected branches directly to %s.", *alias)
+		}
+		if err := cp.save(*checkpointPath); err != nil {
+			log.Fatalf("%v", err)
+		}
+	}
+	log.Printf("Indexing with external version %d (latest outbox event id).", cp.Version)
+
+	// --- 6. อ่านสาขาจาก MySQL ทีละหน้าและส่งเข้า BulkProcessor ---
+	indexer, err := newBulkIndexer(ctx, esClient, cp.Index, cp.Version, bulkIndexerOptions{
+		workers:     *workers,
+		bulkActions: *bulkActions,
+		bulkSizeMB:  *bulkSizeMB,
+	})
 	if err != nil {
-		log.Fatalf("Failed to get all rich branch data: %v", err)
+		log.Fatalf("Failed to create bulk indexer: %v", err)
 	}
 
-	if len(allBranches) == 0 {
-		log.Println("No branches found in the database. Exiting.")
-		return
+	// --- 7. Stream and index ---
+	// หลังแต่ละหน้า รอให้ Elasticsearch ยืนยันทุก document ก่อนบันทึก checkpoint
+	// checkpoint จึงไม่เลยสาขาที่ยังเขียนไม่สำเร็จ
+	log.Printf("Streaming branches from MySQL (page size %d, %d bulk workers, %d documents per bulk)...", *pageSize, *workers, *bulkActions)
+	resumedAfter := cp.LastID
+	seen := make(map[int64]bool)
+	err = repo.StreamRichBranchData(ctx, db, filter, cp.LastID, *pageSize, func(page []*domain.Branch) error {
+		for _, branch := range page {
+			indexer.Add(branch)
+			seen[branch.ID] = true
+		}
+		if err := indexer.Flush(); err != nil {
+			return err
+		}
+		if indexer.Failed() > 0 {
+			return fmt.Errorf("%d branches failed to index", indexer.Failed())
+		}
+		cp.LastID = page[len(page)-1].ID
+		cp.Indexed += int64(len(page))
+		indexer.logProgress(cp.LastID)
+		return cp.save(*checkpointPath)
+	})
+	if closeErr := indexer.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		log.Fatalf("Failed to backfill branches: %v. Run again with -resume to continue after branch ID %d.", err, cp.LastID)
+	}
+	log.Printf("Indexed %d branches into %s.", cp.Indexed, cp.Index)
+
+	if cp.Rebuild {
+		finishRebuild(ctx, db, repo, esClient, indexes, cp, *alias, *deleteOld)
+	} else {
+		for _, id := range filter.IDs {
+			if !seen[id] && id > resumedAfter {
+				log.Printf("WARNING: Branch ID %d was requested but does not match the filter or does not exist in MySQL.", id)
+			}
+		}
+	}
+
+	if err := removeCheckpoint(*checkpointPath); err != nil {
+		log.Printf("WARNING: %v", err)
+	}
+
+	log.Printf("--- Backfill Process Finished ---")
+	elapsed := time.Since(startTime)
+	log.Printf("Successfully backfilled %d branches.", cp.Indexed)
+	log.Printf("Total time taken: %v (%.0f branches/s)", elapsed, float64(indexer.Succeeded())/elapsed.Seconds())
+}
+
+// checkRebuildCount ตรวจว่าจำนวน document ใน index ใหม่ตรงกับจำนวนสาขาใน MySQL
+// ยอมให้ต่างกันได้ไม่เกินจำนวนสาขาที่เปลี่ยนแปลงระหว่าง backfill เพราะสาขาเหล่านั้นถูกเขียนอีกครั้งตอน catch up
+func checkRebuildCount(docCount, mysqlCount int64, changed int) error {
+	diff := docCount - mysqlCount
+	if diff < 0 {
+		diff = -diff
+	}
+	if diff > int64(changed) {
+		return fmt.Errorf("index has %d documents but MySQL has %d branches, and only %d branches changed during the backfill",
+			docCount, mysqlCount, changed)
 	}
+	return nil
+}
 
-	log.Printf("Found %d branches to backfill.", len(allBranches))
+// finishRebuild ตรวจจำนวน document ย้าย alias ไปที่ index ใหม่ catch up และลบ index เก่า (ถ้าสั่ง)
+func finishRebuild(ctx context.Context, db *sql.DB, repo backfillRepository, esClient *elastic.Client, indexes branchIndexes, cp *checkpoint, alias string, deleteOld bool) {
+	// --- 8. ตรวจจำนวน document ก่อนย้าย alias ---
+	// นับจาก MySQL โดยตรง ไม่ใช่จำนวนที่ process นี้ stream มา (cp.Indexed) ซึ่งจับแถวที่ stream พลาดไม่ได้
+	docCount, err := indexes.CountBranchDocuments(ctx, cp.Index)
+	if err != nil {
+		log.Fatalf("Failed to count documents in %s: %v. Alias %s was not switched.", cp.Index, err, alias)
+	}
+	mysqlCount, err := repo.CountBranches(ctx, db, domain.BranchFilter{})
+	if err != nil {
+		log.Fatalf("Failed to count branches in MySQL: %v. Alias %s was not switched.", err, alias)
+	}
+	// สาขาที่ถูกสร้างหรือลบหลัง snapshot ยังไม่อยู่ใน index ใหม่ และจะถูกเขียนตอน catch up หลังย้าย alias
+	changed, err := repo.GetBranchIDsWithEventsAfter(ctx, db, cp.Version)
+	if err != nil {
+		log.Fatalf("Failed to find branches changed during the backfill: %v. Alias %s was not switched.", err, alias)
+	}
+	if err := checkRebuildCount(docCount, mysqlCount, len(changed)); err != nil {
+		log.Fatalf("CRITICAL: %s: %v. Alias %s was not switched; inspect or delete %s.", cp.Index, err, alias, cp.Index)
+	}
+	log.Printf("%s has %d documents; MySQL has %d branches (%d changed during the backfill).", cp.Index, docCount, mysqlCount, len(changed))
 
-	// --- 5. Loop through the results and index to Elasticsearch ---
-	successCount := 0
-	for _, branchData := range allBranches {
-		log.Printf("Indexing branch ID: %d...", branchData.ID)
+	// --- 9. ย้าย alias ---
+	previous, legacyRemoved, err := indexes.SwapBranchAlias(ctx, cp.Index)
+	if err != nil {
+		log.Fatalf("Failed to switch alias: %v", err)
+	}
+	if legacyRemoved {
+		log.Printf("WARNING: Removed concrete index %s so that it can be used as an alias.", alias)
+	}
+	log.Printf("Alias %s now points to %s (previously %v).", alias, cp.Index, previous)
 
-		// ส่งข้อมูลไป Index ใน Elasticsearch
-		_, err = esClient.Index().
-			Index("branches").
-			Id(strconv.FormatInt(branchData.ID, 10)).
-			BodyJson(branchData).
-			Do(ctx)
+	// --- 10. Catch up กับการเปลี่ยนแปลงระหว่าง backfill ---
+	if err := catchUp(ctx, db, repo, esClient, cp.Index, cp.Version); err != nil {
+		log.Printf("ERROR: Catch-up after switching alias failed: %v. Run backfill again or requeue the affected outbox events.", err)
+	}
 
-		if err != nil {
-			log.Printf("ERROR: Failed to index branch ID %d to Elasticsearch: %v. Skipping.", branchData.ID, err)
-			continue
+	// --- 11. ลบ index เก่า (ถ้าสั่ง) ---
+	var old []string
+	for _, name := range previous {
+		if name != cp.Index {
+			old = append(old, name)
 		}
-		successCount++
 	}
+	if !deleteOld {
+		if len(old) > 0 {
+			log.Printf("Kept old indices %v. Use -delete-old to remove them after the next run.", old)
+		}
+		return
+	}
+	if err := indexes.DeleteBranchIndices(ctx, old...); err != nil {
+		log.Printf("ERROR: %v", err)
+	} else if len(old) > 0 {
+		log.Printf("Deleted old indices %v.", old)
+	}
+}
 
-	log.Printf("--- Backfill Process Finished ---")
-	log.Printf("Successfully backfilled %d out of %d branches.", successCount, len(allBranches))
-	log.Printf("Total time taken: %v", time.Since(startTime))
+// branchIndexes คือส่วนของ elasticsearchRepository ที่ใช้ตอนย้าย alias
+type branchIndexes interface {
+	CountBranchDocuments(ctx context.Context, index string) (int64, error)
+	SwapBranchAlias(ctx context.Context, newIndex string) ([]string, bool, error)
+	DeleteBranchIndices(ctx context.Context, names ...string) error
+}
+
+// printDryRun แสดงสาขาที่ตรงกับ filter โดยไม่เขียนอะไรเลย
+func printDryRun(ctx context.Context, db *sql.DB, repo backfillRepository, filter domain.BranchFilter, afterID int64, pageSize int) error {
+	var count int
+	err := repo.StreamRichBranchData(ctx, db, filter, afterID, pageSize, func(page []*domain.Branch) error {
+		for _, branch := range page {
+			count++
+			log.Printf("[dry-run] Would index branch ID %d: %s / %s (%d products, %d interests)",
+				branch.ID, branch.Name.TH, branch.Name.EN, len(branch.ProductIDs), len(branch.InterestIDs))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if filter.IsEmpty() {
+		log.Printf("[dry-run] Would rebuild the index with %d branches and switch the alias to it.", count)
+	} else {
+		log.Printf("[dry-run] Would write %d branches to the live alias.", count)
+	}
+	return nil
+}
+
+// parseFilter สร้าง domain.BranchFilter จาก flag
+func parseFilter(ids string, fromID, toID int64, updatedAfter string) (domain.BranchFilter, error) {
+	filter := domain.BranchFilter{FromID: fromID, ToID: toID}
+	if fromID < 0 || toID < 0 || (toID > 0 && fromID > toID) {
+		return filter, fmt.Errorf("invalid ID range %d-%d", fromID, toID)
+	}
+	if ids != "" {
+		for _, s := range strings.Split(ids, ",") {
+			id, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+			if err != nil || id <= 0 {
+				return filter, fmt.Errorf("invalid branch ID %q", s)
+			}
+			filter.IDs = append(filter.IDs, id)
+		}
+	}
+	t, err := domain.ParseTime(updatedAfter)
+	if err != nil {
+		return filter, fmt.Errorf("invalid -updated-after %q: use RFC3339 or YYYY-MM-DD", updatedAfter)
+	}
+	filter.UpdatedAfter = t
+	return filter, nil
 }
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFilter(t *testing.T) {
+	filter, err := parseFilter("3, 7,9", 2, 20, "2025-11-01T08:00:00Z")
+	require.NoError(t, err)
+	assert.Equal(t, []int64{3, 7, 9}, filter.IDs)
+	assert.Equal(t, int64(2), filter.FromID)
+	assert.Equal(t, int64(20), filter.ToID)
+	require.NotNil(t, filter.UpdatedAfter)
+	assert.True(t, filter.UpdatedAfter.Equal(time.Date(2025, 11, 1, 8, 0, 0, 0, time.UTC)))
+	assert.False(t, filter.IsEmpty())
+
+	filter, err = parseFilter("", 0, 0, "")
+	require.NoError(t, err)
+	assert.True(t, filter.IsEmpty())
+
+	// วันที่อย่างเดียวคือ 00:00 UTC เหมือนใน API ไม่ขึ้นกับเขตเวลาของเครื่องที่รัน
+	filter, err = parseFilter("", 0, 0, "2025-11-01")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC), *filter.UpdatedAfter)
+
+	_, err = parseFilter("1,x", 0, 0, "")
+	assert.Error(t, err)
+	_, err = parseFilter("", 10, 5, "")
+	assert.Error(t, err)
+	_, err = parseFilter("", 0, 0, "yesterday")
+	assert.Error(t, err)
+}
+
+func TestCheckRebuildCount(t *testing.T) {
+	assert.NoError(t, checkRebuildCount(100, 100, 0))
+	// สาขาที่ถูกสร้างหรือลบระหว่าง backfill ถูกเขียนตอน catch up จึงต่างกันได้ไม่เกินจำนวนนั้น
+	assert.NoError(t, checkRebuildCount(100, 102, 2))
+	assert.NoError(t, checkRebuildCount(101, 100, 1))
+	// แถวที่ stream พลาดไปทำให้จำนวนไม่ตรงกับ MySQL
+	assert.Error(t, checkRebuildCount(98, 100, 0))
+	assert.Error(t, checkRebuildCount(100, 103, 2))
+}
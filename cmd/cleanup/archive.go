@@ -0,0 +1,99 @@
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// archivedEvent คือแถวของ outbox_events ที่ถูกเก็บก่อนลบ (หนึ่งบรรทัดในไฟล์ NDJSON)
+type archivedEvent struct {
+	ID            int64           `json:"id"`
+	AggregateID   string          `json:"aggregate_id"`
+	AggregateType string          `json:"aggregate_type"`
+	EventType     string          `json:"event_type"`
+	Payload       json.RawMessage `json:"payload"`
+	Status        string          `json:"status"`
+	Attempts      int             `json:"attempts"`
+	LastError     *string         `json:"last_error,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+// archiver เก็บ event ที่กำลังจะถูกลบ ถูกเรียกภายใน transaction ก่อน DELETE
+// ถ้า transaction ไม่สำเร็จหลังจาก Archive แล้ว event เดิมอาจถูกเก็บซ้ำในรอบถัดไป (at-least-once)
+type archiver interface {
+	Archive(ctx context.Context, tx *sql.Tx, events []archivedEvent) error
+}
+
+// fileArchiver เขียน event ลงไฟล์ NDJSON แบบ gzip หนึ่งไฟล์ต่อการรันหนึ่งครั้ง
+// แต่ละ batch เป็น gzip member แยกกันที่ปิดสมบูรณ์แล้ว ถ้าโปรแกรมหยุดกลางทาง batch ก่อนหน้าจึงยังอ่านได้
+// (gunzip และ gzip.Reader อ่าน member ที่ต่อกันเป็นไฟล์เดียวได้)
+type fileArchiver struct {
+	path string
+}
+
+func newFileArchiver(dir string, now time.Time) (*fileArchiver, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create archive directory: %w", err)
+	}
+	name := "outbox_events_" + now.UTC().Format("20060102T150405Z") + ".ndjson.gz"
+	return &fileArchiver{path: filepath.Join(dir, name)}, nil
+}
+
+func (a *fileArchiver) Archive(_ context.Context, _ *sql.Tx, events []archivedEvent) error {
+	f, err := os.OpenFile(a.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open archive file: %w", err)
+	}
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	encoder := json.NewEncoder(gz)
+	for _, event := range events {
+		if err := encoder.Encode(event); err != nil {
+			return fmt.Errorf("failed to write event %d to archive: %w", event.ID, err)
+		}
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to finish archive batch: %w", err)
+	}
+	// ต้องแน่ใจว่าข้อมูลอยู่บนดิสก์ก่อนที่แถวจะถูกลบ
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("failed to sync archive file: %w", err)
+	}
+	return f.Close()
+}
+
+// tableArchiver คัดลอก event ไปตาราง outbox_events_archive (สร้างโดย migration 0002)
+// ใน transaction เดียวกับ DELETE จึงไม่มีแถวที่ถูกลบโดยไม่ถูกเก็บ
+type tableArchiver struct{}
+
+func (tableArchiver) Archive(ctx context.Context, tx *sql.Tx, events []archivedEvent) error {
+	placeholders, args := idPlaceholders(events)
+	// INSERT IGNORE: แถวที่เคยถูกเก็บแล้ว (เช่น จากรอบที่ transaction ล้มเหลว) ไม่ทำให้ทั้ง batch ล้มเหลว
+	query := `
+		INSERT IGNORE INTO outbox_events_archive
+			(id, aggregate_id, aggregate_type, event_type, payload, status, attempts, last_error, created_at)
+		SELECT id, aggregate_id, aggregate_type, event_type, payload, status, attempts, last_error, created_at
+		FROM outbox_events
+		WHERE id IN (` + placeholders + `)`
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to copy events to outbox_events_archive: %w", err)
+	}
+	return nil
+}
+
+// idPlaceholders คืน "?, ?, ..." และ id ของ event สำหรับเงื่อนไข IN
+func idPlaceholders(events []archivedEvent) (string, []interface{}) {
+	args := make([]interface{}, len(events))
+	for i, event := range events {
+		args[i] = event.ID
+	}
+	return strings.TrimSuffix(strings.Repeat("?, ", len(events)), ", "), args
+}
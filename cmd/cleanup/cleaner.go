@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"ES/internal/config"
+)
+
+// cleaner ลบ outbox event ที่เก่ากว่า retention ของแต่ละสถานะทีละ batch
+// DELETE ขนาดใหญ่ครั้งเดียว lock แถวจำนวนมากและทำให้ worker ที่ claim event อยู่ต้องรอ
+// จึงลบครั้งละไม่เกิน batchSize แถวและพักระหว่าง batch
+type cleaner struct {
+	db        *sql.DB
+	batchSize int
+	pause     time.Duration
+	archiver  archiver // nil = ลบอย่างเดียว
+	now       func() time.Time
+}
+
+// statusResult คือผลของการ cleanup สถานะหนึ่ง
+type statusResult struct {
+	Status  string
+	Cutoff  time.Time
+	Deleted int64
+}
+
+// Run ลบ event ของทุกสถานะใน policies ตามลำดับ หยุดเมื่อ ctx ถูกยกเลิก (ระหว่าง batch)
+// คืนผลของสถานะที่ทำไปแล้วแม้จะมี error
+func (c *cleaner) Run(ctx context.Context, policies []config.StatusRetention) ([]statusResult, error) {
+	var results []statusResult
+	for _, p := range policies {
+		result := statusResult{Status: p.Status, Cutoff: c.now().Add(-p.Retention)}
+		log.Printf("Deleting %s events created before %s (retention %s)", p.Status, result.Cutoff.Format(time.RFC3339), p.Retention)
+
+		for {
+			n, err := c.deleteBatch(ctx, p.Status, result.Cutoff)
+			result.Deleted += n
+			if err != nil {
+				return append(results, result), fmt.Errorf("failed to clean up %s events: %w", p.Status, err)
+			}
+			if n < int64(c.batchSize) {
+				break
+			}
+			select {
+			case <-ctx.Done():
+				return append(results, result), ctx.Err()
+			case <-time.After(c.pause):
+			}
+		}
+
+		log.Printf("Deleted %d %s events.", result.Deleted, p.Status)
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// deleteBatch ลบ event ไม่เกิน batchSize แถว คืนจำนวนแถวที่ลบ
+func (c *cleaner) deleteBatch(ctx context.Context, status string, cutoff time.Time) (int64, error) {
+	if c.archiver == nil {
+		// ORDER BY created_at ใช้ index idx_status_created_at จึงไม่ต้อง scan ทั้งตาราง
+		res, err := c.db.ExecContext(ctx,
+			"DELETE FROM outbox_events WHERE status = ? AND created_at < ? ORDER BY created_at LIMIT ?",
+			status, cutoff, c.batchSize)
+		if err != nil {
+			return 0, err
+		}
+		return res.RowsAffected()
+	}
+
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Rollback หลัง Commit ไม่มีผล
+
+	// FOR UPDATE กันไม่ให้แถวถูกเปลี่ยนสถานะ (เช่น requeue) ระหว่างที่เก็บและลบ
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, aggregate_id, aggregate_type, event_type, payload, status, attempts, last_error, created_at
+		FROM outbox_events
+		WHERE status = ? AND created_at < ?
+		ORDER BY created_at, id
+		LIMIT ?
+		FOR UPDATE`, status, cutoff, c.batchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to select events: %w", err)
+	}
+	events, err := scanArchivedEvents(rows)
+	if err != nil {
+		return 0, err
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	if err := c.archiver.Archive(ctx, tx, events); err != nil {
+		return 0, err
+	}
+
+	placeholders, args := idPlaceholders(events)
+	res, err := tx.ExecContext(ctx, "DELETE FROM outbox_events WHERE id IN ("+placeholders+")", args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete events: %w", err)
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	// คืนจำนวนที่ select ได้เพื่อให้ Run ตัดสินว่ายังมี batch ถัดไปหรือไม่
+	if deleted != int64(len(events)) {
+		log.Printf("WARNING: Archived %d %s events but deleted %d", len(events), status, deleted)
+	}
+	return int64(len(events)), nil
+}
+
+// Count นับ event ของแต่ละสถานะที่จะถูกลบ (สำหรับ -dry-run)
+func (c *cleaner) Count(ctx context.Context, policies []config.StatusRetention) ([]statusResult, error) {
+	var results []statusResult
+	for _, p := range policies {
+		result := statusResult{Status: p.Status, Cutoff: c.now().Add(-p.Retention)}
+		err := c.db.QueryRowContext(ctx,
+			"SELECT COUNT(*) FROM outbox_events WHERE status = ? AND created_at < ?",
+			p.Status, result.Cutoff).Scan(&result.Deleted)
+		if err != nil {
+			return results, fmt.Errorf("failed to count %s events: %w", p.Status, err)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func scanArchivedEvents(rows *sql.Rows) ([]archivedEvent, error) {
+	defer rows.Close()
+	var events []archivedEvent
+	for rows.Next() {
+		var (
+			e         archivedEvent
+			payload   []byte
+			lastError sql.NullString
+		)
+		if err := rows.Scan(&e.ID, &e.AggregateID, &e.AggregateType, &e.EventType, &payload, &e.Status, &e.Attempts, &lastError, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan event: %w", err)
+		}
+		if len(payload) > 0 {
+			e.Payload = payload
+		}
+		if lastError.Valid {
+			e.LastError = &lastError.String
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+
+	"ES/internal/config"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var fixedNow = time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+var eventColumns = []string{"id", "aggregate_id", "aggregate_type", "event_type", "payload", "status", "attempts", "last_error", "created_at"}
+
+func TestCleanerRun_DeletesInBatchesUntilShortBatch(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	c := &cleaner{db: db, batchSize: 2, now: func() time.Time { return fixedNow }}
+	processedCutoff := fixedNow.Add(-7 * 24 * time.Hour)
+	deadCutoff := fixedNow.Add(-30 * 24 * time.Hour)
+
+	deleteQuery := regexp.QuoteMeta("DELETE FROM outbox_events WHERE status = ? AND created_at < ? ORDER BY created_at LIMIT ?")
+	mock.ExpectExec(deleteQuery).WithArgs("processed", processedCutoff, 2).WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(deleteQuery).WithArgs("processed", processedCutoff, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(deleteQuery).WithArgs("dead", deadCutoff, 2).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	results, err := c.Run(context.Background(), []config.StatusRetention{
+		{Status: "processed", Retention: 7 * 24 * time.Hour},
+		{Status: "dead", Retention: 30 * 24 * time.Hour},
+	})
+
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, int64(3), results[0].Deleted)
+	assert.Equal(t, int64(0), results[1].Deleted)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCleanerRun_ArchivesToTableBeforeDeleting(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	c := &cleaner{db: db, batchSize: 10, archiver: tableArchiver{}, now: func() time.Time { return fixedNow }}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, .* FROM outbox_events\s+WHERE status = \? AND created_at < \?.*FOR UPDATE`).
+		WithArgs("failed", fixedNow.Add(-time.Hour), 10).
+		WillReturnRows(sqlmock.NewRows(eventColumns).
+			AddRow(5, "1", "branch", "updated", []byte(`{"id":1}`), "failed", 3, "es down", fixedNow.Add(-2*time.Hour)).
+			AddRow(8, "2", "branch", "deleted", nil, "failed", 3, nil, fixedNow.Add(-2*time.Hour)))
+	mock.ExpectExec(`INSERT IGNORE INTO outbox_events_archive.*WHERE id IN \(\?, \?\)`).
+		WithArgs(int64(5), int64(8)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM outbox_events WHERE id IN (?, ?)")).
+		WithArgs(int64(5), int64(8)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	results, err := c.Run(context.Background(), []config.StatusRetention{{Status: "failed", Retention: time.Hour}})
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), results[0].Deleted)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFileArchiver_AppendsReadableGzipMembers(t *testing.T) {
+	a, err := newFileArchiver(t.TempDir(), fixedNow)
+	require.NoError(t, err)
+
+	lastError := "boom"
+	require.NoError(t, a.Archive(context.Background(), nil, []archivedEvent{
+		{ID: 1, AggregateID: "1", AggregateType: "branch", EventType: "updated", Payload: json.RawMessage(`{"id":1}`), Status: "processed", CreatedAt: fixedNow},
+	}))
+	require.NoError(t, a.Archive(context.Background(), nil, []archivedEvent{
+		{ID: 2, AggregateID: "2", AggregateType: "branch", EventType: "deleted", Status: "dead", Attempts: 10, LastError: &lastError, CreatedAt: fixedNow},
+	}))
+
+	f, err := os.Open(a.path)
+	require.NoError(t, err)
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	require.NoError(t, err)
+
+	var lines []archivedEvent
+	scanner := bufio.NewScanner(gz)
+	for scanner.Scan() {
+		var e archivedEvent
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &e))
+		lines = append(lines, e)
+	}
+	require.NoError(t, scanner.Err())
+	require.Len(t, lines, 2)
+	assert.JSONEq(t, `{"id":1}`, string(lines[0].Payload))
+	assert.Equal(t, "boom", *lines[1].LastError)
+}
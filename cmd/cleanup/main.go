@@ -2,44 +2,93 @@ package main
 
 import (
 	"context"
-	"database/sql"
+	"flag"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
+	"ES/internal/config"
+
 	_ "github.com/go-sql-driver/mysql"
 )
 
+// Cleanup ลบ outbox event ที่เก่ากว่า retention ของแต่ละสถานะ (ดู config.CleanupConfig)
+// ถ้าตั้ง cleanup.archive ไว้ event จะถูกเก็บเป็นไฟล์หรือลงตาราง outbox_events_archive ก่อนลบ
 func main() {
+	dryRun := flag.Bool("dry-run", false, "แสดงจำนวน event ที่จะถูกลบของแต่ละสถานะโดยไม่ลบจริง")
+	flag.Parse()
+
 	log.Println("--- Starting Outbox Cleanup Process ---")
 
-	// --- 1. Connect to MySQL ---
-	dsn := os.Getenv("DATABASE_DSN")
-	if dsn == "" {
-		dsn = "root:123456@tcp(127.0.0.1:3306)/TTDB?parseTime=true"
+	// --- 0. โหลดการตั้งค่า (ดู internal/config) ---
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("failed to load configuration: %v", err)
 	}
-	db, err := sql.Open("mysql", dsn)
+	policies := cfg.Cleanup.Retention.ByStatus()
+	if len(policies) == 0 {
+		log.Println("Retention is disabled for every status. Nothing to do.")
+		return
+	}
+
+	// Ctrl+C หยุดหลังจาก batch ที่กำลังทำอยู่เสร็จ
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// --- 1. Connect to MySQL ---
+	db, err := cfg.Database.OpenDB()
 	if err != nil {
-		log.Fatalf("failed to open database connection: %v", err)
+		log.Fatalf("%v", err)
 	}
 	defer db.Close()
 
-	// --- 2. กำหนดระยะเวลาที่จะเก็บข้อมูลไว้ (เช่น 7 วัน) ---
-	retentionDays := 7
-	cutoffDate := time.Now().AddDate(0, 0, -retentionDays)
-	log.Printf("Deleting processed events older than %d days (before %s)", retentionDays, cutoffDate.Format("2006-01-02"))
+	c := &cleaner{
+		db:        db,
+		batchSize: cfg.Cleanup.BatchSize,
+		pause:     cfg.Cleanup.BatchPause,
+		now:       time.Now,
+	}
 
-	// --- 3. รันคำสั่ง DELETE ---
-	query := "DELETE FROM outbox_events WHERE status = 'processed' AND created_at < ?"
-	result, err := db.ExecContext(context.Background(), query, cutoffDate)
-	if err != nil {
-		log.Fatalf("Failed to execute delete query: %v", err)
+	// --- 2. Dry run: นับอย่างเดียว ---
+	if *dryRun {
+		results, err := c.Count(ctx, policies)
+		if err != nil {
+			log.Fatalf("Failed to count events: %v", err)
+		}
+		var total int64
+		for _, r := range results {
+			log.Printf("[dry-run] %d %s events created before %s would be deleted", r.Deleted, r.Status, r.Cutoff.Format(time.RFC3339))
+			total += r.Deleted
+		}
+		log.Printf("--- Dry Run Finished. %d events would be deleted (archive: %s). ---", total, cfg.Cleanup.Archive)
+		return
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	// --- 3. เลือกที่เก็บ event ก่อนลบ ---
+	switch cfg.Cleanup.Archive {
+	case config.ArchiveFile:
+		fa, err := newFileArchiver(cfg.Cleanup.ArchiveDir, time.Now())
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		log.Printf("Archiving deleted events to %s", fa.path)
+		c.archiver = fa
+	case config.ArchiveTable:
+		log.Println("Archiving deleted events to table outbox_events_archive")
+		c.archiver = tableArchiver{}
+	}
+
+	// --- 4. ลบทีละ batch ---
+	results, err := c.Run(ctx, policies)
+	var total int64
+	for _, r := range results {
+		total += r.Deleted
+	}
 	if err != nil {
-		log.Fatalf("Failed to get rows affected: %v", err)
+		log.Fatalf("Cleanup stopped after deleting %d events: %v", total, err)
 	}
 
-	log.Printf("--- Cleanup Process Finished. Deleted %d old events. ---", rowsAffected)
+	log.Printf("--- Cleanup Process Finished. Deleted %d old events. ---", total)
 }
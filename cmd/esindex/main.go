@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"ES/internal/config"
+	"ES/internal/repositories"
+)
+
+const usage = `Usage: esindex [-index <alias>] <command>
+
+Commands:
+  apply   ติดตั้ง index template ของสาขา สร้าง index และ alias ถ้ายังไม่มี และรายงาน mapping ที่ไม่ตรง
+  check   รายงาน mapping ที่ไม่ตรงกับที่โปรเจกต์กำหนด (exit code 1 ถ้าไม่ตรง)
+  show    พิมพ์ index template ที่โปรเจกต์กำหนดเป็น JSON
+`
+
+func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("failed to load configuration: %v", err)
+	}
+
+	index := flag.String("index", cfg.Elasticsearch.BranchIndex, "ชื่อ alias ของสาขา")
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	command := flag.Arg(0)
+
+	if command == "show" {
+		printTemplate(*index)
+		return
+	}
+
+	ctx := context.Background()
+
+	// --- 1. Connect to Elasticsearch ---
+	esClient, err := cfg.Elasticsearch.NewClient()
+	if err != nil {
+		log.Fatalf("Error creating the Elasticsearch client: %s", err)
+	}
+	repo := repositories.NewElasticsearchRepository(esClient, *index)
+
+	// --- 2. Run command ---
+	switch command {
+	case "apply":
+		created, err := repo.EnsureBranchIndex(ctx)
+		if err != nil {
+			log.Fatalf("Failed to apply branch index template: %v", err)
+		}
+		log.Printf("Index template %s is at mapping version %d.", repositories.BranchIndexTemplateName, repositories.BranchMappingVersion)
+		if created != "" {
+			log.Printf("Created index %s behind alias %s.", created, *index)
+		}
+		// template ไม่มีผลกับ index ที่มีอยู่แล้ว จึงต้องรายงานถ้า index ปัจจุบันยังไม่ตรง
+		if reportDrift(ctx, repo, *index) {
+			log.Println("The live index was created with an older mapping. Run cmd/backfill to rebuild it with the current template.")
+		}
+	case "check":
+		if reportDrift(ctx, repo, *index) {
+			os.Exit(1)
+		}
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+// reportDrift พิมพ์ความแตกต่างระหว่าง mapping ที่ใช้งานอยู่กับ mapping ที่กำหนด คืนค่า true ถ้าไม่ตรงกัน
+func reportDrift(ctx context.Context, repo interface {
+	BranchMappingDrift(ctx context.Context) ([]string, error)
+}, index string) bool {
+	drift, err := repo.BranchMappingDrift(ctx)
+	if err != nil {
+		log.Fatalf("Failed to check mapping of %s: %v", index, err)
+	}
+	if len(drift) == 0 {
+		log.Printf("Mapping of %s matches mapping version %d.", index, repositories.BranchMappingVersion)
+		return false
+	}
+	log.Printf("Mapping of %s has drifted from mapping version %d:", index, repositories.BranchMappingVersion)
+	for _, d := range drift {
+		log.Printf("  - %s", d)
+	}
+	return true
+}
+
+func printTemplate(index string) {
+	body, err := json.MarshalIndent(repositories.BranchIndexTemplate(index, index+"_*"), "", "  ")
+	if err != nil {
+		log.Fatalf("Failed to marshal index template: %v", err)
+	}
+	fmt.Println(string(body))
+}
@@ -2,35 +2,37 @@ package main
 
 import (
 	"context"
-	"database/sql"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 
+	"ES/internal/config"       // Configuration
 	"ES/internal/handlers"     // Driving Adapter
 	"ES/internal/ports"        // Ports
 	"ES/internal/repositories" // Driven Adapter
 	"ES/internal/services"     // Core Logic
-	"os"
 
 	"github.com/gin-gonic/gin"
-	"github.com/go-redis/redis/v8"
 	_ "github.com/go-sql-driver/mysql"
 )
 
 func main() {
-	// --- 1. ตั้งค่า Database Connection ---
-	// อ่านค่า DSN จาก Environment Variable เพื่อความยืดหยุ่น
-	dsn := os.Getenv("DATABASE_DSN")
-	if dsn == "" {
-		// กำหนดค่า default สำหรับการพัฒนาในเครื่อง
-		dsn = "root:123456@tcp(127.0.0.1:3306)/TTDB?parseTime=true"
+	// --- 0. โหลดการตั้งค่า ---
+	// ค่า default สำหรับการพัฒนาในเครื่อง ทับได้ด้วยไฟล์ CONFIG_FILE และ Environment Variable (ดู internal/config)
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("failed to load configuration: %v", err)
 	}
 
-	db, err := sql.Open("mysql", dsn)
+	// --- 1. ตั้งค่า Database Connection ---
+	db, err := cfg.Database.OpenDB()
 	if err != nil {
-		log.Fatalf("failed to open database connection: %v", err)
+		log.Fatalf("%v", err)
 	}
-	defer db.Close()
 
 	if err := db.Ping(); err != nil {
 		log.Fatalf("failed to ping database: %v", err)
@@ -39,16 +41,19 @@ func main() {
 	fmt.Println("Successfully connected to the database.")
 
 	// --- 1.5. ตั้งค่า Redis Connection ---
-	redisClient := redis.NewClient(&redis.Options{
-		Addr:     "localhost:6379", // ที่อยู่ของ Redis
-		Password: "",               // ไม่มีรหัสผ่าน
-		DB:       0,                // ใช้ DB default
-	})
+	redisClient := cfg.Redis.NewClient()
 	if _, err := redisClient.Ping(context.Background()).Result(); err != nil {
 		log.Fatalf("failed to connect to redis: %v", err)
 	}
 	fmt.Println("Successfully connected to Redis.")
 
+	// --- 1.6. ตั้งค่า Elasticsearch Connection (ใช้สำหรับค้นหาสาขา) ---
+	esClient, err := cfg.Elasticsearch.NewClient()
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	fmt.Println("Successfully connected to Elasticsearch.")
+
 	// --- 2. Dependency Injection (ประกอบร่าง) ---
 	// Repository (Driven Adapter) -> Service (Core) -> Handler (Driving Adapter)
 
@@ -60,15 +65,22 @@ func main() {
 	var productRepo ports.ProductRepository = repo
 	var productOptionRepo ports.ProductOptionRepository = repo
 	var outboxRepo ports.OutboxRepository = repo
+	var branchSearcher ports.BranchSearcher = repositories.NewElasticsearchRepository(esClient, cfg.Elasticsearch.BranchIndex)
+	var notifier ports.OutboxNotifier = repositories.NewRedisOutboxNotifier(redisClient, cfg.Outbox.Channel)
+	var outboxAdminRepo ports.OutboxAdminRepository = repo
 
 	// สร้าง Service โดยส่ง db (สำหรับ transaction) และ Repository เข้าไป
-	var branchSvc ports.BranchService = services.NewBranchService(db, branchRepo, outboxRepo, redisClient)
-	var interestSvc ports.InterestService = services.NewInterestService(db, interestRepo)
-	var productSvc ports.ProductService = services.NewProductService(db, productRepo)
-	var productOptionSvc ports.ProductOptionService = services.NewProductOptionService(db, productOptionRepo)
+	var branchSvc ports.BranchService = services.NewBranchService(db, branchRepo, outboxRepo, notifier)
+	// Service ของ interest, product และ product_option ต้องใช้ branchRepo และ outboxRepo ด้วย
+	// เพื่อสั่ง reindex สาขาที่ได้รับผลกระทบผ่าน Outbox
+	var interestSvc ports.InterestService = services.NewInterestService(db, interestRepo, branchRepo, outboxRepo, notifier)
+	var productSvc ports.ProductService = services.NewProductService(db, productRepo, branchRepo, outboxRepo, notifier)
+	var productOptionSvc ports.ProductOptionService = services.NewProductOptionService(db, productOptionRepo, branchRepo, outboxRepo, notifier)
+	var outboxAdminSvc ports.OutboxAdminService = services.NewOutboxAdminService(db, outboxAdminRepo, notifier)
 
 	// สร้าง Handler โดยส่ง Service เข้าไป
-	httpHandler := handlers.NewHTTPHandler(branchSvc, interestSvc, productSvc, productOptionSvc)
+	httpHandler := handlers.NewHTTPHandler(branchSvc, branchSearcher, interestSvc, productSvc, productOptionSvc)
+	adminHandler := handlers.NewAdminHandler(outboxAdminSvc)
 
 	// --- 3. ตั้งค่า Gin Router ---
 	router := gin.Default()
@@ -77,32 +89,102 @@ func main() {
 	branchRoutes := router.Group("/branches")
 	{
 		branchRoutes.POST("/", httpHandler.CreateBranch) // Create ยังคงอยู่
+		branchRoutes.GET("/", httpHandler.ListBranches)
+		branchRoutes.GET("/search", httpHandler.SearchBranches)
 		branchRoutes.GET("/:id", httpHandler.GetBranch)
 		branchRoutes.PUT("/:id", httpHandler.UpdateBranch)
 		branchRoutes.DELETE("/:id", httpHandler.DeleteBranch)
+		branchRoutes.GET("/:id/location", httpHandler.GetBranchLocation)
+		branchRoutes.PUT("/:id/location", httpHandler.PutBranchLocation)
+		branchRoutes.DELETE("/:id/location", httpHandler.DeleteBranchLocation)
+		branchRoutes.POST("/:id/interests/:interestId", httpHandler.LinkBranchInterest)
+		branchRoutes.DELETE("/:id/interests/:interestId", httpHandler.UnlinkBranchInterest)
 	}
 
 	interestRoutes := router.Group("/interests")
 	{
+		interestRoutes.POST("/", httpHandler.CreateInterest)
+		interestRoutes.GET("/", httpHandler.ListInterests)
+		interestRoutes.GET("/:id", httpHandler.GetInterest)
 		interestRoutes.PUT("/:id", httpHandler.UpdateInterest)
 		interestRoutes.DELETE("/:id", httpHandler.DeleteInterest)
 	}
 
 	productRoutes := router.Group("/products")
 	{
+		productRoutes.POST("/", httpHandler.CreateProduct)
+		productRoutes.GET("/", httpHandler.ListProducts)
+		productRoutes.GET("/:id", httpHandler.GetProduct)
 		productRoutes.PUT("/:id", httpHandler.UpdateProduct)
 		productRoutes.DELETE("/:id", httpHandler.DeleteProduct)
+		productRoutes.GET("/:id/options", httpHandler.ListProductOptions)
+		productRoutes.POST("/:id/options", httpHandler.CreateProductOption)
 	}
 
 	productOptionRoutes := router.Group("/product-options")
 	{
+		productOptionRoutes.GET("/:id", httpHandler.GetProductOption)
 		productOptionRoutes.PUT("/:id", httpHandler.UpdateProductOption)
 		productOptionRoutes.DELETE("/:id", httpHandler.DeleteProductOption)
 	}
 
+	// Admin API สำหรับตรวจสอบและจัดการ outbox เปิดเฉพาะเมื่อตั้ง ADMIN_TOKEN (http.admin_token)
+	if cfg.HTTP.AdminToken != "" {
+		outboxAdminRoutes := router.Group("/admin/outbox", handlers.RequireAdminToken(cfg.HTTP.AdminToken))
+		{
+			outboxAdminRoutes.GET("/events", adminHandler.ListOutboxEvents)
+			outboxAdminRoutes.GET("/events/:id", adminHandler.GetOutboxEvent)
+			outboxAdminRoutes.GET("/stats", adminHandler.GetOutboxStats)
+			outboxAdminRoutes.POST("/requeue", adminHandler.RequeueOutboxEvents)
+			outboxAdminRoutes.POST("/purge", adminHandler.PurgeOutboxEvents)
+			outboxAdminRoutes.GET("/audit", adminHandler.ListOutboxAudit)
+		}
+	} else {
+		log.Println("ADMIN_TOKEN is not set; /admin endpoints are disabled.")
+	}
+
 	// --- 4. รันเซิร์ฟเวอร์ ---
-	fmt.Println("Starting server on :8080")
-	if err := router.Run(":8080"); err != nil {
+	// ใช้ http.Server แทน router.Run เพื่อให้ปิดเซิร์ฟเวอร์แบบ graceful ได้
+	srv := &http.Server{
+		Addr:         cfg.HTTP.Addr,
+		Handler:      router,
+		ReadTimeout:  cfg.HTTP.ReadTimeout,
+		WriteTimeout: cfg.HTTP.WriteTimeout,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		fmt.Printf("Starting server on %s\n", cfg.HTTP.Addr)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+
+	select {
+	case err := <-serverErr:
 		log.Fatalf("failed to run server: %v", err)
+	case <-ctx.Done():
+	}
+
+	// --- 5. Graceful Shutdown ---
+	// หยุดรับ connection ใหม่ และรอให้ request ที่กำลังทำงานอยู่ (รวมถึง transaction) เสร็จภายในเวลาที่กำหนด
+	shutdownTimeout := cfg.HTTP.ShutdownTimeout
+	log.Printf("Shutdown signal received. Waiting up to %s for in-flight requests to finish...", shutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("WARNING: Server did not shut down cleanly: %v", err)
+	}
+
+	esClient.Stop()
+	if err := redisClient.Close(); err != nil {
+		log.Printf("WARNING: Failed to close Redis client: %v", err)
+	}
+	if err := db.Close(); err != nil {
+		log.Printf("WARNING: Failed to close database: %v", err)
 	}
+	log.Println("Server shut down.")
 }
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"ES/internal/config"
+	"ES/internal/migrations"
+
+	_ "github.com/go-sql-driver/mysql"
+)
+
+const usage = `Usage: migrate [-dir <path>] <command> [args]
+
+Commands:
+  up [N]         รัน migration ที่ยังไม่ได้รันทั้งหมด (หรือแค่ N เวอร์ชันถัดไป)
+  down [N]       ย้อน migration ล่าสุด N เวอร์ชัน (default 1)
+  status         แสดงว่า migration ใดรันแล้วหรือยังไม่ได้รัน
+  create <name>  สร้างไฟล์ up/down ของ migration เวอร์ชันถัดไปใน -dir
+  seed           ใส่ข้อมูลตัวอย่างสำหรับการพัฒนา (รันหลัง up)
+
+migration ถูกฝังไว้ใน binary ตอน build ไฟล์ที่สร้างด้วย create จึงมีผลหลังจาก build ใหม่ (หรือ go run ใหม่)
+`
+
+var namePattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+func main() {
+	dir := flag.String("dir", migrations.Dir, "โฟลเดอร์ของไฟล์ migration (ใช้กับ create)")
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	flag.Parse()
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	command, args := flag.Arg(0), flag.Args()[1:]
+
+	if command == "create" {
+		if len(args) != 1 {
+			flag.Usage()
+			os.Exit(2)
+		}
+		if err := create(*dir, args[0]); err != nil {
+			log.Fatalf("Failed to create migration: %v", err)
+		}
+		return
+	}
+
+	// --- 0. โหลดการตั้งค่า (ดู internal/config) ---
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("failed to load configuration: %v", err)
+	}
+
+	// --- 1. Connect to MySQL ---
+	db, err := cfg.Database.OpenDB()
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	defer db.Close()
+
+	all, err := migrations.Embedded()
+	if err != nil {
+		log.Fatalf("Failed to load migrations: %v", err)
+	}
+	migrator := migrations.NewMigrator(db, all)
+	ctx := context.Background()
+
+	// --- 2. Run command ---
+	switch command {
+	case "up":
+		done, err := migrator.Up(ctx, stepsArg(args, 0))
+		if err != nil {
+			log.Fatalf("Migration failed after applying %d migrations: %v", len(done), err)
+		}
+		log.Printf("Applied %d migrations.", len(done))
+	case "down":
+		done, err := migrator.Down(ctx, stepsArg(args, 1))
+		if err != nil {
+			log.Fatalf("Rollback failed after rolling back %d migrations: %v", len(done), err)
+		}
+		log.Printf("Rolled back %d migrations.", len(done))
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			log.Fatalf("Failed to read migration status: %v", err)
+		}
+		printStatus(statuses)
+	case "seed":
+		files, err := migrations.Seed(ctx, db)
+		if err != nil {
+			log.Fatalf("Seeding failed: %v", err)
+		}
+		log.Printf("Seeded sample data from %s.", strings.Join(files, ", "))
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+// stepsArg อ่านจำนวนเวอร์ชันจาก argument แรก ถ้าไม่ระบุคืน def
+func stepsArg(args []string, def int) int {
+	if len(args) == 0 {
+		return def
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n <= 0 || len(args) > 1 {
+		log.Fatalf("invalid step count %q: must be a positive integer", strings.Join(args, " "))
+	}
+	return n
+}
+
+func printStatus(statuses []migrations.Status) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATUS")
+	for _, s := range statuses {
+		state := "pending"
+		switch {
+		case s.Missing:
+			state = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05") + " (file missing)"
+		case s.AppliedAt != nil:
+			state = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, state)
+	}
+	w.Flush()
+}
+
+// create เขียนไฟล์ up/down ว่างของเวอร์ชันถัดไป (เวอร์ชันสูงสุดใน dir + 1)
+func create(dir, name string) error {
+	if !namePattern.MatchString(name) {
+		return fmt.Errorf("name %q must contain only lowercase letters, digits and underscores", name)
+	}
+	existing, err := migrations.Load(os.DirFS(dir))
+	if err != nil {
+		return err
+	}
+	var version int64 = 1
+	if len(existing) > 0 {
+		version = existing[len(existing)-1].Version + 1
+	}
+
+	up, down := migrations.FileNames(version, name)
+	files := map[string]string{
+		up:   fmt.Sprintf("-- %04d_%s: เขียนการเปลี่ยนแปลง schema ที่นี่\n", version, name),
+		down: fmt.Sprintf("-- %04d_%s: เขียนคำสั่งที่ย้อนการเปลี่ยนแปลงของไฟล์ up ที่นี่\n", version, name),
+	}
+	for _, file := range []string{up, down} {
+		path := filepath.Join(dir, file)
+		// O_EXCL กันการเขียนทับไฟล์ที่มีอยู่แล้ว
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err != nil {
+			return err
+		}
+		if _, err := f.WriteString(files[file]); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+		log.Printf("Created %s", path)
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"ES/internal/domain"
+)
+
+// priceTolerance คือความต่างของราคาที่ยอมรับได้ ราคาถูกเก็บใน index เป็น scaled_float ความละเอียด 0.01
+const priceTolerance = 0.005
+
+// driftKind คือประเภทของความไม่ตรงกันระหว่าง MySQL กับ Elasticsearch
+type driftKind string
+
+const (
+	driftMissing    driftKind = "missing"    // มีใน MySQL แต่ไม่มีใน index
+	driftExtra      driftKind = "extra"      // มีใน index แต่ไม่มีใน MySQL
+	driftMismatched driftKind = "mismatched" // มีทั้งสองที่แต่ข้อมูลไม่ตรงกัน
+)
+
+// branchDrift คือความไม่ตรงกันของสาขาหนึ่งสาขา
+type branchDrift struct {
+	ID     int64
+	Kind   driftKind
+	Fields []string // รายละเอียดของ field ที่ไม่ตรง (เฉพาะ driftMismatched)
+}
+
+func (d branchDrift) String() string {
+	if d.Kind == driftMismatched {
+		return fmt.Sprintf("branch %d is %s: %v", d.ID, d.Kind, d.Fields)
+	}
+	return fmt.Sprintf("branch %d is %s", d.ID, d.Kind)
+}
+
+// compareBranch เปรียบเทียบสาขาจาก MySQL (expected) กับ document ใน index (actual) แบบ field ต่อ field
+// คืนรายการ field ที่ไม่ตรงกัน (ว่างถ้าตรงกัน)
+// ลำดับของ product_ids และ interest_ids ไม่มีผล เพราะ GROUP_CONCAT ไม่รับประกันลำดับ
+func compareBranch(expected, actual *domain.Branch) []string {
+	var diffs []string
+	if expected.ID != actual.ID {
+		diffs = append(diffs, fmt.Sprintf("id: %d != %d", expected.ID, actual.ID))
+	}
+	if expected.Name.TH != actual.Name.TH {
+		diffs = append(diffs, fmt.Sprintf("name.th: %q != %q", expected.Name.TH, actual.Name.TH))
+	}
+	if expected.Name.EN != actual.Name.EN {
+		diffs = append(diffs, fmt.Sprintf("name.en: %q != %q", expected.Name.EN, actual.Name.EN))
+	}
+	if e, a := provinceOf(expected), provinceOf(actual); e != a {
+		diffs = append(diffs, fmt.Sprintf("location.province_id: %s != %s", e, a))
+	}
+	if e, a := locationNameOf(expected), locationNameOf(actual); e != a {
+		diffs = append(diffs, fmt.Sprintf("location.name: %s != %s", e, a))
+	}
+	if e, a := addressOf(expected), addressOf(actual); e != a {
+		diffs = append(diffs, fmt.Sprintf("location.address: %q != %q", e, a))
+	}
+	if e, a := coordinatesOf(expected), coordinatesOf(actual); e != a {
+		diffs = append(diffs, fmt.Sprintf("location.coordinates: %s != %s", e, a))
+	}
+	if e, a := sortedInts(expected.ProductIDs), sortedInts(actual.ProductIDs); !equalInts(e, a) {
+		diffs = append(diffs, fmt.Sprintf("product_ids: %v != %v", e, a))
+	}
+	if e, a := sortedInts(expected.InterestIDs), sortedInts(actual.InterestIDs); !equalInts(e, a) {
+		diffs = append(diffs, fmt.Sprintf("interest_ids: %v != %v", e, a))
+	}
+	comparePrice(&diffs, "min_normal_price", expected.MinNormalPrice, actual.MinNormalPrice)
+	comparePrice(&diffs, "max_normal_price", expected.MaxNormalPrice, actual.MaxNormalPrice)
+	comparePrice(&diffs, "min_tagthai_price", expected.MinTagthaiPrice, actual.MinTagthaiPrice)
+	comparePrice(&diffs, "max_tagthai_price", expected.MaxTagthaiPrice, actual.MaxTagthaiPrice)
+	if expected.UpdatedAt != nil && (actual.UpdatedAt == nil || !expected.UpdatedAt.Equal(*actual.UpdatedAt)) {
+		diffs = append(diffs, fmt.Sprintf("updated_at: %v != %v", expected.UpdatedAt, actual.UpdatedAt))
+	}
+	return diffs
+}
+
+func provinceOf(b *domain.Branch) string {
+	if b.Location == nil {
+		return "<nil>"
+	}
+	return fmt.Sprint(b.Location.ProvinceID)
+}
+
+func locationNameOf(b *domain.Branch) string {
+	if b.Location == nil || b.Location.Name == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("%q/%q", b.Location.Name.TH, b.Location.Name.EN)
+}
+
+func addressOf(b *domain.Branch) string {
+	if b.Location == nil {
+		return ""
+	}
+	return b.Location.Address
+}
+
+// coordinatesOf ปัดพิกัดเป็น 6 ตำแหน่งตามความละเอียดของคอลัมน์ latitude/longitude ใน MySQL
+func coordinatesOf(b *domain.Branch) string {
+	if b.Location == nil || b.Location.Coordinates == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("%.6f,%.6f", b.Location.Coordinates.Lat, b.Location.Coordinates.Lon)
+}
+
+func comparePrice(diffs *[]string, field string, expected, actual *float64) {
+	switch {
+	case expected == nil && actual == nil:
+		return
+	case expected == nil || actual == nil:
+		*diffs = append(*diffs, fmt.Sprintf("%s: %s != %s", field, formatPrice(expected), formatPrice(actual)))
+	case math.Abs(*expected-*actual) > priceTolerance:
+		*diffs = append(*diffs, fmt.Sprintf("%s: %s != %s", field, formatPrice(expected), formatPrice(actual)))
+	}
+}
+
+func formatPrice(p *float64) string {
+	if p == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("%.2f", *p)
+}
+
+func sortedInts(values []int) []int {
+	out := append([]int(nil), values...)
+	sort.Ints(out)
+	return out
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// diffBranches เปรียบเทียบสาขาจาก MySQL กับ document ที่อ่านได้จาก index (key คือ ID)
+// คืนความไม่ตรงกันของสาขาใน expected (missing/mismatched) เรียงตาม ID
+func diffBranches(expected []*domain.Branch, documents map[int64]*domain.Branch) []branchDrift {
+	var drift []branchDrift
+	for _, branch := range expected {
+		doc, ok := documents[branch.ID]
+		if !ok {
+			drift = append(drift, branchDrift{ID: branch.ID, Kind: driftMissing})
+			continue
+		}
+		if fields := compareBranch(branch, doc); len(fields) > 0 {
+			drift = append(drift, branchDrift{ID: branch.ID, Kind: driftMismatched, Fields: fields})
+		}
+	}
+	return drift
+}
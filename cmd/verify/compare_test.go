@@ -0,0 +1,75 @@
+package main
+
+import (
+	"testing"
+
+	"ES/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func floatPtr(v float64) *float64 { return &v }
+
+func TestCompareBranch_IgnoresIDOrder(t *testing.T) {
+	expected := &domain.Branch{
+		ID:             1,
+		Name:           domain.BranchNameJSON{TH: "สาขา", EN: "Branch"},
+		Location:       &domain.BranchLocation{ProvinceID: 10},
+		ProductIDs:     []int{3, 1, 2},
+		MinNormalPrice: floatPtr(99.99),
+	}
+	actual := &domain.Branch{
+		ID:             1,
+		Name:           domain.BranchNameJSON{TH: "สาขา", EN: "Branch"},
+		Location:       &domain.BranchLocation{ProvinceID: 10},
+		ProductIDs:     []int{1, 2, 3},
+		InterestIDs:    []int{},
+		MinNormalPrice: floatPtr(99.99),
+	}
+
+	assert.Empty(t, compareBranch(expected, actual))
+}
+
+func TestCompareBranch_ReportsFieldDifferences(t *testing.T) {
+	expected := &domain.Branch{
+		ID:             1,
+		Name:           domain.BranchNameJSON{TH: "สาขาใหม่", EN: "Branch"},
+		InterestIDs:    []int{4},
+		MaxNormalPrice: floatPtr(150),
+	}
+	actual := &domain.Branch{
+		ID:             1,
+		Name:           domain.BranchNameJSON{TH: "สาขาเก่า", EN: "Branch"},
+		Location:       &domain.BranchLocation{ProvinceID: 10, Coordinates: &domain.GeoPoint{Lat: 13.7, Lon: 100.5}},
+		MaxNormalPrice: floatPtr(120),
+		MinNormalPrice: floatPtr(100),
+	}
+
+	assert.Equal(t, []string{
+		`name.th: "สาขาใหม่" != "สาขาเก่า"`,
+		"location.province_id: <nil> != 10",
+		"location.coordinates: <nil> != 13.700000,100.500000",
+		"interest_ids: [4] != []",
+		"min_normal_price: <nil> != 100.00",
+		"max_normal_price: 150.00 != 120.00",
+	}, compareBranch(expected, actual))
+}
+
+func TestDiffBranches(t *testing.T) {
+	expected := []*domain.Branch{
+		{ID: 1, Name: domain.BranchNameJSON{TH: "ก"}},
+		{ID: 2, Name: domain.BranchNameJSON{TH: "ข"}},
+		{ID: 3, Name: domain.BranchNameJSON{TH: "ค"}},
+	}
+	documents := map[int64]*domain.Branch{
+		1: {ID: 1, Name: domain.BranchNameJSON{TH: "ก"}},
+		3: {ID: 3, Name: domain.BranchNameJSON{TH: "X"}},
+	}
+
+	drift := diffBranches(expected, documents)
+
+	assert.Equal(t, []branchDrift{
+		{ID: 2, Kind: driftMissing},
+		{ID: 3, Kind: driftMismatched, Fields: []string{`name.th: "ค" != "X"`}},
+	}, drift)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+
+	"ES/internal/domain"
+
+	"github.com/olivere/elastic/v7"
+)
+
+// fetchDocuments อ่าน document ของสาขาตาม ID จาก index ด้วย _mget request เดียว
+// คืน map จาก ID ไปยัง document เฉพาะที่พบใน index
+func fetchDocuments(ctx context.Context, esClient *elastic.Client, index string, ids []int64) (map[int64]*domain.Branch, error) {
+	documents := make(map[int64]*domain.Branch, len(ids))
+	if len(ids) == 0 {
+		return documents, nil
+	}
+
+	mget := esClient.MultiGet()
+	for _, id := range ids {
+		mget.Add(elastic.NewMultiGetItem().Index(index).Id(strconv.FormatInt(id, 10)))
+	}
+	res, err := mget.Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get documents from %s: %w", index, err)
+	}
+
+	for _, doc := range res.Docs {
+		if doc.Error != nil {
+			return nil, fmt.Errorf("failed to get document %s: %s", doc.Id, doc.Error.Reason)
+		}
+		if !doc.Found {
+			continue
+		}
+		id, err := strconv.ParseInt(doc.Id, 10, 64)
+		if err != nil {
+			continue // document ที่ ID ไม่ใช่ตัวเลขจะถูกรายงานเป็น extra โดย scanDocumentIDs
+		}
+		var branch domain.Branch
+		if err := json.Unmarshal(doc.Source, &branch); err != nil {
+			log.Printf("WARNING: could not unmarshal branch document %s: %v", doc.Id, err)
+			branch = domain.Branch{ID: id} // ถือว่าข้อมูลไม่ตรงกัน
+		}
+		documents[id] = &branch
+	}
+	return documents, nil
+}
+
+// scanDocumentIDs อ่าน _id ของทุก document ใน index ด้วย scroll และเรียก fn กับแต่ละ ID
+func scanDocumentIDs(ctx context.Context, esClient *elastic.Client, index string, fn func(id string)) error {
+	scroll := esClient.Scroll(index).
+		Size(1000).
+		FetchSource(false).
+		Sort("_doc", true)
+	defer scroll.Clear(context.Background())
+
+	for {
+		res, err := scroll.Do(ctx)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to scroll %s: %w", index, err)
+		}
+		for _, hit := range res.Hits.Hits {
+			fn(hit.Id)
+		}
+	}
+}
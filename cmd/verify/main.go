@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"ES/internal/config"
+	"ES/internal/domain"
+	"ES/internal/repositories"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/olivere/elastic/v7"
+)
+
+// Verify เปรียบเทียบสาขาใน MySQL (source of truth) กับ document ใน index ของสาขาแบบ field ต่อ field
+// และรายงานสาขาที่ไม่มีใน index (missing) มีเกินใน index (extra) และข้อมูลไม่ตรงกัน (mismatched)
+// ความไม่ตรงกันเกิดได้จาก notification ที่หายไป event ที่ถูกบันทึกเป็น failed/dead
+// หรือการแก้ข้อมูลใน MySQL โดยตรงโดยไม่ผ่าน service
+//
+// exit code เป็น 1 ถ้าพบความไม่ตรงกัน (แม้จะสั่ง -repair แล้ว) เพื่อให้ CI หรือ cron แจ้งเตือนได้
+func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("failed to load configuration: %v", err)
+	}
+
+	alias := flag.String("alias", cfg.Elasticsearch.BranchIndex, "ชื่อ alias ของสาขาที่ตรวจ")
+	pageSize := flag.Int("page-size", 500, "จำนวนสาขาที่อ่านและเปรียบเทียบต่อหนึ่งรอบ")
+	settle := flag.Duration("settle", 10*time.Second, "รอเท่านี้แล้วตรวจสาขาที่ไม่ตรงซ้ำ เพื่อตัดการเปลี่ยนแปลงที่ worker ยังทำไม่เสร็จ (0 = ไม่ตรวจซ้ำ)")
+	repair := flag.Bool("repair", false, "เขียน outbox event ของสาขาที่ไม่ตรงกัน ให้ worker แก้ index")
+	flag.Parse()
+
+	ctx := context.Background()
+	startTime := time.Now()
+
+	// --- 1. Connect to MySQL ---
+	db, err := cfg.Database.OpenDB()
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	defer db.Close()
+
+	// --- 2. Connect to Elasticsearch ---
+	esClient, err := cfg.Elasticsearch.NewClient()
+	if err != nil {
+		log.Fatalf("Error creating the Elasticsearch client: %s", err)
+	}
+
+	repo := repositories.NewMySQLRepository(db)
+
+	// --- 3. เปรียบเทียบทุกสาขาใน MySQL กับ index ทีละหน้า ---
+	log.Printf("Comparing branches in MySQL with %s...", *alias)
+	var drift []branchDrift
+	inMySQL := make(map[int64]bool)
+	err = repo.StreamRichBranchData(ctx, db, domain.BranchFilter{}, 0, *pageSize, func(page []*domain.Branch) error {
+		ids := make([]int64, len(page))
+		for i, b := range page {
+			ids[i] = b.ID
+			inMySQL[b.ID] = true
+		}
+		documents, err := fetchDocuments(ctx, esClient, *alias, ids)
+		if err != nil {
+			return err
+		}
+		drift = append(drift, diffBranches(page, documents)...)
+		return nil
+	})
+	if err != nil {
+		log.Fatalf("Failed to compare branches: %v", err)
+	}
+
+	// --- 4. หา document ที่ไม่มีสาขาใน MySQL ---
+	var unknown []string // document ที่ _id ไม่ใช่ ID ของสาขา แก้อัตโนมัติไม่ได้
+	err = scanDocumentIDs(ctx, esClient, *alias, func(docID string) {
+		id, err := strconv.ParseInt(docID, 10, 64)
+		if err != nil {
+			unknown = append(unknown, docID)
+			return
+		}
+		if !inMySQL[id] {
+			drift = append(drift, branchDrift{ID: id, Kind: driftExtra})
+		}
+	})
+	if err != nil {
+		log.Fatalf("Failed to scan %s: %v", *alias, err)
+	}
+	log.Printf("Compared %d branches in %s; %d differ.", len(inMySQL), time.Since(startTime).Round(time.Millisecond), len(drift))
+
+	// --- 5. ตรวจซ้ำเฉพาะสาขาที่ไม่ตรง ---
+	if len(drift) > 0 && *settle > 0 {
+		log.Printf("Re-checking %d branches in %s to skip changes that are still being indexed...", len(drift), *settle)
+		time.Sleep(*settle)
+		drift, err = recheck(ctx, db, repo, esClient, *alias, drift)
+		if err != nil {
+			log.Fatalf("Failed to re-check branches: %v", err)
+		}
+	}
+
+	// --- 6. รายงาน ---
+	counts := map[driftKind]int{}
+	for _, d := range drift {
+		counts[d.Kind]++
+		log.Printf("DRIFT: %s", d)
+	}
+	for _, docID := range unknown {
+		log.Printf("DRIFT: document %q in %s is not a branch ID and must be removed by hand", docID, *alias)
+	}
+	log.Printf("Summary: %d missing, %d extra, %d mismatched, %d unknown documents.",
+		counts[driftMissing], counts[driftExtra], counts[driftMismatched], len(unknown))
+
+	if len(drift) == 0 && len(unknown) == 0 {
+		log.Println("MySQL and Elasticsearch are consistent.")
+		return
+	}
+
+	// --- 7. Repair ---
+	if *repair && len(drift) > 0 {
+		ids := make([]int64, len(drift))
+		for i, d := range drift {
+			ids[i] = d.ID
+		}
+		updated, deleted, err := enqueueRepairs(ctx, db, repo, ids)
+		if err != nil {
+			log.Fatalf("Failed to enqueue repairs: %v", err)
+		}
+		log.Printf("Enqueued %d 'updated' and %d 'deleted' outbox events. Notifying outbox worker.", updated, deleted)
+
+		redisClient := cfg.Redis.NewClient()
+		if err := repositories.NewRedisOutboxNotifier(redisClient, cfg.Outbox.Channel).Notify(ctx); err != nil {
+			// event อยู่ใน outbox แล้ว worker จะเจอในการ poll รอบถัดไป
+			log.Printf("WARNING: Failed to publish notification to Redis: %v", err)
+		}
+		redisClient.Close()
+	}
+	os.Exit(1)
+}
+
+// recheck อ่านสาขาที่ไม่ตรงกันจาก MySQL และ index ใหม่อีกครั้ง แล้วคืนเฉพาะสาขาที่ยังไม่ตรง
+func recheck(ctx context.Context, db *sql.DB, repo verifyRepository, esClient *elastic.Client, index string, drift []branchDrift) ([]branchDrift, error) {
+	ids := make([]int64, len(drift))
+	for i, d := range drift {
+		ids[i] = d.ID
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	branches, err := repo.GetRichBranchDataByIDs(ctx, db, ids)
+	if err != nil {
+		return nil, err
+	}
+	documents, err := fetchDocuments(ctx, esClient, index, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	remaining := diffBranches(branches, documents)
+	inMySQL := make(map[int64]bool, len(branches))
+	for _, b := range branches {
+		inMySQL[b.ID] = true
+	}
+	for _, id := range ids {
+		if _, indexed := documents[id]; indexed && !inMySQL[id] {
+			remaining = append(remaining, branchDrift{ID: id, Kind: driftExtra})
+		}
+	}
+	sort.Slice(remaining, func(i, j int) bool { return remaining[i].ID < remaining[j].ID })
+	return remaining, nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"ES/internal/domain"
+	"ES/internal/ports"
+)
+
+// verifyRepository คือส่วนของ MySQL repository ที่ verify ใช้
+type verifyRepository interface {
+	StreamRichBranchData(ctx context.Context, dbtx ports.DBTX, filter domain.BranchFilter, afterID int64, pageSize int, fn func(page []*domain.Branch) error) error
+	GetRichBranchDataByIDs(ctx context.Context, dbtx ports.DBTX, ids []int64) ([]*domain.Branch, error)
+	CreateEvent(ctx context.Context, dbtx ports.DBTX, aggregateID string, aggregateType string, eventType string, payload []byte) error
+}
+
+// enqueueRepairs เขียน outbox event ของสาขาที่ไม่ตรงกันใน transaction เดียว ให้ worker แก้ index ตามปกติ
+// ข้อมูลถูกอ่านใหม่ภายใน transaction: สาขาที่ยังมีอยู่จะได้ event "updated" พร้อมข้อมูลล่าสุด
+// สาขาที่ไม่มีแล้วจะได้ event "deleted" จึงถูกต้องแม้ข้อมูลเปลี่ยนไประหว่างที่ตรวจ
+// การแก้ผ่าน outbox ทำให้ document ได้ version เป็น ID ของ event ใหม่ ซึ่งชนะ document เดิมเสมอ
+func enqueueRepairs(ctx context.Context, db *sql.DB, repo verifyRepository, ids []int64) (updated, deleted int, err error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	branches, err := repo.GetRichBranchDataByIDs(ctx, tx, ids)
+	if err != nil {
+		return 0, 0, err
+	}
+	found := make(map[int64]*domain.Branch, len(branches))
+	for _, b := range branches {
+		found[b.ID] = b
+	}
+
+	for _, id := range ids {
+		aggregateID := strconv.FormatInt(id, 10)
+		if branch, ok := found[id]; ok {
+			payload, err := json.Marshal(branch)
+			if err != nil {
+				return 0, 0, fmt.Errorf("failed to marshal payload for branch %d: %w", id, err)
+			}
+			if err := repo.CreateEvent(ctx, tx, aggregateID, "branch", "updated", payload); err != nil {
+				return 0, 0, fmt.Errorf("failed to create outbox event for branch %d: %w", id, err)
+			}
+			updated++
+			continue
+		}
+		payload, _ := json.Marshal(map[string]int64{"id": id})
+		if err := repo.CreateEvent(ctx, tx, aggregateID, "branch", "deleted", payload); err != nil {
+			return 0, 0, fmt.Errorf("failed to create outbox event for branch %d: %w", id, err)
+		}
+		deleted++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit repair events: %w", err)
+	}
+	return updated, deleted, nil
+}
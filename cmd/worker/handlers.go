@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/olivere/elastic/v7"
+)
+
+// EventHandler แปลง outbox event เป็น request ของ Elasticsearch Bulk API
+// handler แต่ละตัวถูกลงทะเบียนตาม aggregate_type และ event_type ใน handlerRegistry
+// การเพิ่ม projection ใหม่ (เช่น index ของ products หรือ interests) จึงทำได้ด้วยการเพิ่ม handler
+// โดยไม่ต้องแก้ loop ของ worker
+//
+// error ที่คืนจาก BuildRequest ถือเป็น error ถาวร เพราะ event เดิมจะแปลงไม่ได้ทุกครั้ง
+type EventHandler interface {
+	BuildRequest(event OutboxEvent) (elastic.BulkableRequest, error)
+}
+
+// EventHandlerFunc ทำให้ฟังก์ชันธรรมดาใช้เป็น EventHandler ได้
+type EventHandlerFunc func(event OutboxEvent) (elastic.BulkableRequest, error)
+
+// BuildRequest เรียก f(event)
+func (f EventHandlerFunc) BuildRequest(event OutboxEvent) (elastic.BulkableRequest, error) {
+	return f(event)
+}
+
+// errUnhandled หมายถึงไม่มี handler ที่ลงทะเบียนไว้สำหรับ aggregate_type/event_type ของ event
+// event เหล่านี้จะถูกบันทึกเป็นสถานะ 'unhandled' แยกจาก 'dead' เพื่อให้สั่ง requeue ได้หลังจาก deploy handler แล้ว
+var errUnhandled = errors.New("no handler registered")
+
+type handlerKey struct {
+	aggregateType string
+	eventType     string
+}
+
+// handlerRegistry เก็บ EventHandler แยกตาม aggregate_type และ event_type
+type handlerRegistry struct {
+	handlers map[handlerKey]EventHandler
+}
+
+func newHandlerRegistry() *handlerRegistry {
+	return &handlerRegistry{handlers: make(map[handlerKey]EventHandler)}
+}
+
+// Register ลงทะเบียน handler ของ event ประเภทหนึ่ง ลงทะเบียนซ้ำจะเขียนทับของเดิม
+func (r *handlerRegistry) Register(aggregateType, eventType string, handler EventHandler) {
+	r.handlers[handlerKey{aggregateType: aggregateType, eventType: eventType}] = handler
+}
+
+// BuildRequest หา handler ของ event แล้วสร้าง request
+// ถ้าไม่มี handler จะคืน error ที่ห่อ errUnhandled
+func (r *handlerRegistry) BuildRequest(event OutboxEvent) (elastic.BulkableRequest, error) {
+	handler, ok := r.handlers[handlerKey{aggregateType: event.AggregateType, eventType: event.EventType}]
+	if !ok {
+		return nil, fmt.Errorf("%w for aggregate type %q and event type %q", errUnhandled, event.AggregateType, event.EventType)
+	}
+	return handler.BuildRequest(event)
+}
+
+// --- Document Handlers ---
+// ใช้ได้กับทุก aggregate ที่ payload คือ document ทั้งก้อน และ aggregate_id คือ _id ใน index
+
+// indexDocumentHandler เขียน payload ของ event เป็น document ใน index
+//
+// ใช้ version_type=external โดยใช้ ID ของ outbox event เป็น version
+// ID เพิ่มขึ้นเสมอตามลำดับที่ event ถูกเขียน (ภายใน transaction ที่แก้ข้อมูล) ดังนั้นถ้า event เก่า
+// มาถึงหลัง event ใหม่ (worker คนละตัว หรือ retry) Elasticsearch จะปฏิเสธด้วย version conflict แทนที่จะเขียนทับ
+func indexDocumentHandler(index string) EventHandler {
+	return EventHandlerFunc(func(event OutboxEvent) (elastic.BulkableRequest, error) {
+		var payloadData map[string]interface{}
+		if err := json.Unmarshal(event.Payload, &payloadData); err != nil {
+			return nil, permanent(fmt.Errorf("failed to unmarshal payload: %w", err))
+		}
+		return elastic.NewBulkIndexRequest().
+			Index(index).
+			Id(event.AggregateID).
+			VersionType("external").
+			Version(event.ID).
+			Doc(payloadData), nil
+	})
+}
+
+// deleteDocumentHandler ลบ document ของ aggregate ออกจาก index
+// การลบก็ใช้ version เดียวกัน Elasticsearch จะเก็บ tombstone ไว้ (index.gc_deletes)
+// ทำให้ event "updated" ที่เก่ากว่าซึ่งมาถึงทีหลังไม่สามารถสร้าง document กลับขึ้นมาได้
+func deleteDocumentHandler(index string) EventHandler {
+	return EventHandlerFunc(func(event OutboxEvent) (elastic.BulkableRequest, error) {
+		return elastic.NewBulkDeleteRequest().
+			Index(index).
+			Id(event.AggregateID).
+			VersionType("external").
+			Version(event.ID), nil
+	})
+}
+
+// registerBranchHandlers ลงทะเบียน handler ของ aggregate "branch" ซึ่งเขียนลง index ของสาขา
+func registerBranchHandlers(r *handlerRegistry, index string) {
+	r.Register("branch", "created", indexDocumentHandler(index))
+	r.Register("branch", "updated", indexDocumentHandler(index))
+	r.Register("branch", "deleted", deleteDocumentHandler(index))
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/olivere/elastic/v7"
+)
+
+// branchIndexManager คือส่วนของ elasticsearchRepository ที่ใช้จัดการ index ของสาขา
+type branchIndexManager interface {
+	EnsureBranchIndex(ctx context.Context) (string, error)
+	BranchMappingDrift(ctx context.Context) ([]string, error)
+}
+
+// ensureBranchIndex ติดตั้ง index template สร้าง index ถ้ายังไม่มี และเตือนถ้า mapping ที่ใช้อยู่ไม่ตรงกับที่กำหนด
+// worker ยังทำงานต่อได้แม้ mapping ไม่ตรง เพราะ document ยังถูกเขียนได้ แต่การค้นหาอาจให้ผลไม่ถูกต้องจนกว่าจะ reindex
+//
+// branchIndexName คือ alias ที่ชี้ไปยัง index ของสาขาที่ใช้งานอยู่ worker เขียนผ่าน alias เสมอ
+// เมื่อ backfill ย้าย alias ไป index ใหม่ การอัปเดตจึงไปถึง index ใหม่ทันที
+func ensureBranchIndex(ctx context.Context, indexes branchIndexManager, branchIndexName string) {
+	created, err := indexes.EnsureBranchIndex(ctx)
+	if err != nil {
+		log.Fatalf("Failed to ensure index %s: %v", branchIndexName, err)
+	}
+	if created != "" {
+		log.Printf("Created index %s behind alias %s from the managed template.", created, branchIndexName)
+	}
+
+	drift, err := indexes.BranchMappingDrift(ctx)
+	if err != nil {
+		log.Printf("WARNING: Could not check mapping of %s: %v", branchIndexName, err)
+		return
+	}
+	for _, d := range drift {
+		log.Printf("WARNING: Mapping drift in %s: %s", branchIndexName, d)
+	}
+	if len(drift) > 0 {
+		log.Printf("WARNING: Run 'go run ./cmd/backfill' to rebuild %s with the current mapping.", branchIndexName)
+	}
+}
+
+// indexEvents ส่ง event ทั้ง batch ไปยัง Elasticsearch ใน _bulk request เดียว
+// แล้วจับคู่ผลลัพธ์ของแต่ละ item กลับไปยัง event ตามลำดับ
+// คืน slice ของ error ที่มีขนาดเท่ากับ events โดย nil หมายถึงสำเร็จ
+// document ที่มีปัญหาจะทำให้ล้มเหลวเฉพาะ event ของตัวเองเท่านั้น ไม่ทำให้ทั้ง batch ล้มเหลว
+func indexEvents(ctx context.Context, esClient *elastic.Client, handlers *handlerRegistry, events []OutboxEvent) []error {
+	results := make([]error, len(events))
+
+	// 1. สร้าง request ของแต่ละ event; event ที่สร้าง request ไม่ได้จะไม่ถูกส่งไป
+	bulk := esClient.Bulk()
+	var sent []int // index ใน events ของ request ที่อยู่ใน bulk ตามลำดับ
+	for i, event := range events {
+		req, err := handlers.BuildRequest(event)
+		if err != nil {
+			results[i] = err
+			continue
+		}
+		bulk.Add(req)
+		sent = append(sent, i)
+	}
+	if len(sent) == 0 {
+		return results
+	}
+
+	// 2. ส่ง bulk request; ถ้าทั้ง request ล้มเหลว (เช่น ต่อ Elasticsearch ไม่ได้) ทุก event ที่ส่งไปถือว่าล้มเหลว
+	resp, err := bulk.Do(ctx)
+	if err == nil && len(resp.Items) != len(sent) {
+		err = fmt.Errorf("bulk response has %d items, expected %d", len(resp.Items), len(sent))
+	}
+	if err != nil {
+		for _, i := range sent {
+			results[i] = fmt.Errorf("bulk request failed: %w", err)
+		}
+		return results
+	}
+
+	// 3. Item ใน response เรียงตามลำดับเดียวกับ request
+	for n, i := range sent {
+		results[i] = bulkItemError(resp.Items[n])
+	}
+	return results
+}
+
+// bulkItemError แปลงผลลัพธ์ของ item หนึ่งรายการใน Bulk response เป็น error
+// การลบ document ที่ไม่มีอยู่แล้ว (404) ถือว่าสำเร็จ
+// version conflict (409) หมายถึง document ใน index ใหม่กว่า event นี้อยู่แล้ว จึงถือว่าสำเร็จเช่นกัน
+// error ที่คืนเป็น *elastic.Error เพื่อให้ isRetryable แยกได้ว่าควรลองใหม่หรือไม่ (เช่น 400 ไม่ควรลองใหม่)
+func bulkItemError(item map[string]*elastic.BulkResponseItem) error {
+	for op, result := range item {
+		if result == nil {
+			return fmt.Errorf("empty bulk response item for %s", op)
+		}
+		if result.Status >= 200 && result.Status < 300 {
+			return nil
+		}
+		if op == "delete" && result.Status == http.StatusNotFound {
+			return nil
+		}
+		if isVersionConflict(result) {
+			log.Printf("Skipped stale %s of document %s: index already holds a newer version (%s)", op, result.Id, result.Error.Reason)
+			return nil
+		}
+		return &elastic.Error{Status: result.Status, Details: result.Error}
+	}
+	return fmt.Errorf("empty bulk response item")
+}
+
+// isVersionConflict บอกว่า item ถูกปฏิเสธเพราะ version ของ event เก่ากว่า document ที่มีอยู่
+func isVersionConflict(result *elastic.BulkResponseItem) bool {
+	return result.Status == http.StatusConflict &&
+		result.Error != nil &&
+		result.Error.Type == "version_conflict_engine_exception"
+}
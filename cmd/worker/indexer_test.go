@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestESClient สร้าง client ที่ชี้ไปยัง Elasticsearch จำลองซึ่งตอบ _bulk ด้วย body ที่กำหนด
+func newTestESClient(t *testing.T, status int, body string) *elastic.Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := elastic.NewClient(
+		elastic.SetURL(server.URL),
+		elastic.SetSniff(false),
+		elastic.SetHealthcheck(false),
+		elastic.SetMaxRetries(0),
+	)
+	require.NoError(t, err)
+	return client
+}
+
+func newTestHandlers() *handlerRegistry {
+	handlers := newHandlerRegistry()
+	registerBranchHandlers(handlers, "branches")
+	return handlers
+}
+
+func TestIndexEvents_MapsEachItemToItsEvent(t *testing.T) {
+	// item ที่ 2 (event ID 3) ถูกปฏิเสธด้วย 400 ส่วนการลบ document ที่ไม่มีอยู่ (404) ถือว่าสำเร็จ
+	client := newTestESClient(t, http.StatusOK, `{
+		"took": 3, "errors": true,
+		"items": [
+			{"index":  {"_index": "branches", "_id": "1", "status": 201}},
+			{"index":  {"_index": "branches", "_id": "2", "status": 400,
+			            "error": {"type": "mapper_parsing_exception", "reason": "failed to parse field [min_normal_price]"}}},
+			{"delete": {"_index": "branches", "_id": "3", "status": 404, "result": "not_found"}},
+			{"index":  {"_index": "branches", "_id": "4", "status": 429,
+			            "error": {"type": "es_rejected_execution_exception", "reason": "rejected"}}},
+			{"index":  {"_index": "branches", "_id": "5", "status": 409,
+			            "error": {"type": "version_conflict_engine_exception", "reason": "[5]: version conflict, current version [9] is higher than the one provided [6]"}}}
+		]}`)
+
+	events := []OutboxEvent{
+		{ID: 1, AggregateID: "1", AggregateType: "branch", EventType: "created", Payload: []byte(`{"id":1}`)},
+		{ID: 2, AggregateID: "9", AggregateType: "branch", EventType: "updated", Payload: []byte(`not json`)},
+		{ID: 3, AggregateID: "2", AggregateType: "branch", EventType: "updated", Payload: []byte(`{"id":2,"min_normal_price":"abc"}`)},
+		{ID: 4, AggregateID: "3", AggregateType: "branch", EventType: "deleted", Payload: []byte(`{"id":3}`)},
+		{ID: 5, AggregateID: "4", AggregateType: "branch", EventType: "updated", Payload: []byte(`{"id":4}`)},
+		{ID: 6, AggregateID: "5", AggregateType: "branch", EventType: "updated", Payload: []byte(`{"id":5}`)},
+	}
+
+	results := indexEvents(context.Background(), client, newTestHandlers(), events)
+	require.Len(t, results, len(events))
+
+	assert.NoError(t, results[0])
+	// payload ที่ unmarshal ไม่ได้จะไม่ถูกส่งไปใน bulk และไม่ควรลองใหม่
+	assert.Error(t, results[1])
+	assert.False(t, isRetryable(results[1]))
+	assert.Error(t, results[2])
+	assert.Contains(t, results[2].Error(), "mapper_parsing_exception")
+	assert.False(t, isRetryable(results[2]))
+	assert.NoError(t, results[3])
+	assert.Error(t, results[4])
+	assert.True(t, isRetryable(results[4]))
+	// event ที่เก่ากว่า document ใน index ถือว่าสำเร็จ ไม่ใช่ความล้มเหลว
+	assert.NoError(t, results[5])
+}
+
+func TestBuildRequest_UsesOutboxIDAsExternalVersion(t *testing.T) {
+	handlers := newTestHandlers()
+	for _, eventType := range []string{"updated", "deleted"} {
+		req, err := handlers.BuildRequest(OutboxEvent{ID: 42, AggregateID: "7", AggregateType: "branch", EventType: eventType, Payload: []byte(`{"id":7}`)})
+		require.NoError(t, err)
+
+		lines, err := req.Source()
+		require.NoError(t, err)
+		assert.Contains(t, lines[0], `"version":42`, eventType)
+		assert.Contains(t, lines[0], `"version_type":"external"`, eventType)
+	}
+}
+
+func TestIndexEvents_WholeRequestFailureFailsEverySentEvent(t *testing.T) {
+	client := newTestESClient(t, http.StatusServiceUnavailable, `{"error": {"type": "unavailable", "reason": "cluster down"}, "status": 503}`)
+
+	events := []OutboxEvent{
+		{ID: 1, AggregateID: "1", AggregateType: "branch", EventType: "updated", Payload: []byte(`{"id":1}`)},
+		{ID: 2, AggregateID: "2", AggregateType: "branch", EventType: "deleted"},
+	}
+
+	results := indexEvents(context.Background(), client, newTestHandlers(), events)
+
+	for _, err := range results {
+		assert.Error(t, err)
+		assert.True(t, isRetryable(err))
+	}
+}
+
+func TestBuildRequest_UnregisteredEventIsUnhandled(t *testing.T) {
+	handlers := newTestHandlers()
+
+	_, err := handlers.BuildRequest(OutboxEvent{ID: 1, AggregateID: "1", AggregateType: "product", EventType: "updated"})
+	assert.ErrorIs(t, err, errUnhandled)
+
+	_, err = handlers.BuildRequest(OutboxEvent{ID: 2, AggregateID: "1", AggregateType: "branch", EventType: "renamed"})
+	assert.ErrorIs(t, err, errUnhandled)
+
+	// handler ใหม่ลงทะเบียนได้โดยไม่ต้องแก้ worker
+	handlers.Register("product", "updated", indexDocumentHandler("products"))
+	req, err := handlers.BuildRequest(OutboxEvent{ID: 3, AggregateID: "1", AggregateType: "product", EventType: "updated", Payload: []byte(`{"id":1}`)})
+	require.NoError(t, err)
+	lines, err := req.Source()
+	require.NoError(t, err)
+	assert.Contains(t, lines[0], `"_index":"products"`)
+}
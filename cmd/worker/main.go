@@ -3,157 +3,272 @@ package main
 import (
 	"context"
 	"database/sql"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"ES/internal/config"
+	"ES/internal/repositories"
 
-	"github.com/go-redis/redis/v8"
 	_ "github.com/go-sql-driver/mysql"
-	"github.com/olivere/elastic/v7" // ต้อง go get package นี้
+	"github.com/olivere/elastic/v7"
 )
 
+// OutboxEvent คือแถวหนึ่งในตาราง outbox_events ที่ worker ตัวนี้ claim มาแล้ว
 type OutboxEvent struct {
 	ID            int64
 	AggregateID   string
 	AggregateType string
 	EventType     string
 	Payload       []byte
+	Attempts      int // จำนวนครั้งที่ถูก claim รวมครั้งปัจจุบัน
 }
 
 func main() {
-	// --- 1. Connect to MySQL ---
-	dsn := os.Getenv("DATABASE_DSN")
-	if dsn == "" {
-		dsn = "root:123456@tcp(127.0.0.1:3306)/TTDB?parseTime=true"
+	// --- 0. โหลดการตั้งค่า (ดู internal/config) ---
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("failed to load configuration: %v", err)
 	}
-	db, err := sql.Open("mysql", dsn)
+
+	// --- 1. Connect to MySQL ---
+	db, err := cfg.Database.OpenDB()
 	if err != nil {
-		log.Fatalf("failed to open database connection: %v", err)
+		log.Fatalf("%v", err)
 	}
-	defer db.Close()
 
 	// --- 2. Connect to Elasticsearch ---
-	esClient, err := elastic.NewClient(
-		elastic.SetURL("http://localhost:9200"), // URL ของ Elasticsearch
-		elastic.SetSniff(false),
-	)
+	esClient, err := cfg.Elasticsearch.NewClient()
 	if err != nil {
 		log.Fatalf("Error creating the Elasticsearch client: %s", err)
 	}
 	log.Println("Successfully connected to Elasticsearch.")
 
-	// --- 3. Connect to Redis and Subscribe ---
-	redisClient := redis.NewClient(&redis.Options{
-		Addr: "localhost:6379",
-	})
+	// --- 2.5 ติดตั้ง index template และตรวจ mapping ---
+	// ต้องทำก่อนเขียน document แรก ไม่อย่างนั้น Elasticsearch จะสร้าง index ด้วย dynamic mapping
+	branchIndex := cfg.Elasticsearch.BranchIndex
+	ensureBranchIndex(context.Background(), repositories.NewElasticsearchRepository(esClient, branchIndex), branchIndex)
+
+	// --- 3. Connect to Redis ---
+	redisClient := cfg.Redis.NewClient()
 	if _, err := redisClient.Ping(context.Background()).Result(); err != nil {
 		log.Fatalf("failed to connect to redis: %v", err)
 	}
 	log.Println("Successfully connected to Redis.")
 
-	pubsub := redisClient.Subscribe(context.Background(), "outbox_channel")
-	defer pubsub.Close()
-
 	// --- 4. Start Worker ---
-	log.Println("Worker started. Waiting for notifications on 'outbox_channel'...")
-
-	// ประมวลผลครั้งแรกเผื่อมี event ค้างอยู่ตอน worker ปิดไป
-	processEvents(db, esClient)
+	// worker แต่ละตัวต้องมี ID ไม่ซ้ำกัน เพื่อใช้เป็นเจ้าของ lease ของ event ที่ claim ไป
+	workerID := cfg.Worker.ID
+	if workerID == "" {
+		hostname, _ := os.Hostname()
+		workerID = fmt.Sprintf("%s-%d", hostname, os.Getpid())
+	}
+	// --- 4.5 ลงทะเบียน handler ของแต่ละ aggregate ---
+	// projection ใหม่เพิ่มได้ด้วยการลงทะเบียน handler ตรงนี้ โดยไม่ต้องแก้ loop ของ worker
+	handlers := newHandlerRegistry()
+	registerBranchHandlers(handlers, branchIndex)
 
-	// รอรับ message จาก channel
-	for msg := range pubsub.Channel() {
-		log.Printf("Received notification: %s. Triggering event processing.", msg.Payload)
-		processEvents(db, esClient)
+	w := &worker{
+		db:        db,
+		esClient:  esClient,
+		handlers:  handlers,
+		id:        workerID,
+		batchSize: cfg.Worker.BatchSize,
+		lease:     cfg.Worker.LeaseDuration,
+		retry: retryPolicy{
+			maxAttempts: cfg.Worker.MaxAttempts,
+			baseDelay:   cfg.Worker.RetryBaseDelay,
+			maxDelay:    cfg.Worker.RetryMaxDelay,
+		},
 	}
-}
+	pollInterval := cfg.Worker.PollInterval
+	debounce := cfg.Worker.Debounce
+	log.Printf("Worker %s started (batch size %d, lease %s, max attempts %d, poll every %s). Waiting for notifications on '%s'...",
+		w.id, w.batchSize, w.lease, w.retry.maxAttempts, pollInterval, cfg.Outbox.Channel)
 
-func processEvents(db *sql.DB, esClient *elastic.Client) {
-	log.Println("--- Checking for new events... ---")
-	ctx := context.Background()
+	// --- 5. รอรับสัญญาณปิดโปรแกรม ---
+	// เมื่อได้ SIGINT/SIGTERM worker จะหยุด claim batch ใหม่ และรอให้ batch ที่กำลังทำอยู่เสร็จภายในเวลาที่กำหนด
+	// batchCtx แยกจาก ctx เพื่อให้ batch ที่ claim ไปแล้วทำต่อได้หลังได้สัญญาณ และถูกยกเลิกเมื่อเกินเวลาเท่านั้น
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	batchCtx, cancelBatch := context.WithCancel(context.Background())
+	defer cancelBatch()
+	shutdownTimeout := cfg.Worker.ShutdownTimeout
 
-	// 1. ดึง Events ที่เป็น pending
-	rows, err := db.QueryContext(ctx, "SELECT id, aggregate_id, aggregate_type, event_type, payload FROM outbox_events WHERE status = 'pending' ORDER BY created_at ASC LIMIT 10")
-	if err != nil {
-		log.Printf("Error querying events: %v", err)
-		return
-	}
-	defer rows.Close()
+	// --- 6. Subscribe และรอรับ notification ---
+	// Redis pub/sub เป็นแบบ fire-and-forget ถ้า worker หลุดการเชื่อมต่อตอนที่ service publish หรือ publish ล้มเหลว
+	// notification นั้นจะหายไป จึงต้องมีการ poll เป็นระยะควบคู่กันไปด้วย
+	trigger := make(chan struct{}, 1)
+	listenerDone := make(chan struct{})
+	go func() {
+		defer close(listenerDone)
+		listenForNotifications(ctx, redisClient, cfg.Outbox.Channel, trigger)
+	}()
 
-	var events []OutboxEvent
-	for rows.Next() {
-		var event OutboxEvent
-		if err := rows.Scan(&event.ID, &event.AggregateID, &event.AggregateType, &event.EventType, &event.Payload); err != nil {
-			log.Printf("Error scanning event row: %v", err)
-			continue
+	runDone := make(chan struct{})
+	go func() {
+		defer close(runDone)
+		w.run(ctx, batchCtx, trigger, pollInterval, debounce)
+	}()
+
+	// --- 7. Graceful Shutdown ---
+	<-ctx.Done()
+	log.Printf("Shutdown signal received. Waiting up to %s for the in-flight batch to finish...", shutdownTimeout)
+	select {
+	case <-runDone:
+		log.Println("Worker stopped cleanly.")
+	case <-time.After(shutdownTimeout):
+		// ยกเลิก batch แล้วรอให้ goroutine คืนก่อนปิด client ไม่อย่างนั้น batch จะล้มกลางคันด้วย connection ที่ถูกปิด
+		log.Printf("WARNING: In-flight batch did not finish within %s. Cancelling it.", shutdownTimeout)
+		cancelBatch()
+		<-runDone
+		// คืน event ที่ยังค้างเป็น 'processing' ให้ worker ตัวอื่น claim ได้ทันที แทนการรอ lease หมดอายุ
+		releaseCtx, cancelRelease := context.WithTimeout(context.Background(), 5*time.Second)
+		released, err := releaseLeases(releaseCtx, db, w.id)
+		cancelRelease()
+		if err != nil {
+			log.Printf("WARNING: Failed to release leases: %v. The events will be retried when their leases expire.", err)
+		} else {
+			log.Printf("Released %d unfinished events back to pending.", released)
 		}
-		events = append(events, event)
 	}
+	<-listenerDone
 
-	if len(events) == 0 {
-		log.Println("--- No new events found. ---")
-		return
+	esClient.Stop()
+	if err := redisClient.Close(); err != nil {
+		log.Printf("WARNING: Failed to close Redis client: %v", err)
 	}
+	if err := db.Close(); err != nil {
+		log.Printf("WARNING: Failed to close database: %v", err)
+	}
+	log.Println("Worker shut down.")
+}
 
-	log.Printf("Found %d new events to process.", len(events))
+// run ประมวลผล Outbox เมื่อได้รับ notification (แบบ debounce) หรือเมื่อถึงรอบ poll จนกว่า ctx จะถูกยกเลิก
+// batch ใช้ batchCtx ดู drain
+func (w *worker) run(ctx, batchCtx context.Context, trigger <-chan struct{}, pollInterval, debounce time.Duration) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
 
-	// 2. ประมวลผลแต่ละ Event
-	for _, event := range events {
-		err := handleEvent(ctx, esClient, event)
+	// ประมวลผลครั้งแรกเผื่อมี event ค้างอยู่ตอน worker ปิดไป
+	w.drain(ctx, batchCtx)
 
-		// 3. อัปเดตสถานะ Event
-		var newStatus string
-		if err != nil {
-			log.Printf("Failed to process event ID %d: %v", event.ID, err)
-			newStatus = "failed"
-		} else {
-			log.Printf("Successfully processed event ID %d", event.ID)
-			newStatus = "processed"
+	// notification ที่มาติดกันเป็นชุดจะถูกรวม (debounce) ให้เหลือการ drain ครั้งเดียว
+	var debounceTimer <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-trigger:
+			if debounceTimer == nil {
+				debounceTimer = time.After(debounce)
+			}
+		case <-debounceTimer:
+			debounceTimer = nil
+			w.drain(ctx, batchCtx)
+			// เพิ่งประมวลผลไป ไม่จำเป็นต้อง poll ซ้ำทันที
+			ticker.Reset(pollInterval)
+		case <-ticker.C:
+			log.Println("Poll interval elapsed. Triggering event processing.")
+			w.drain(ctx, batchCtx)
 		}
+	}
+}
 
-		_, updateErr := db.ExecContext(ctx, "UPDATE outbox_events SET status = ? WHERE id = ?", newStatus, event.ID)
-		if updateErr != nil {
-			log.Printf("CRITICAL: Failed to update status for event ID %d: %v", event.ID, updateErr)
+// worker เก็บ dependency และค่าตั้งค่าที่ใช้ประมวลผล Outbox
+type worker struct {
+	db        *sql.DB
+	esClient  *elastic.Client
+	handlers  *handlerRegistry
+	id        string // ID ของ worker ใช้เป็นเจ้าของ lease
+	batchSize int
+	lease     time.Duration
+	retry     retryPolicy
+}
+
+// drain claim และประมวลผล event ทีละ batch ไปเรื่อยๆ จนไม่เหลือ event ที่พร้อมประมวลผล
+// ทำให้ notification เพียงครั้งเดียวสามารถเคลียร์ backlog ทั้งหมดได้
+// event ที่อยู่ระหว่างรอ backoff จะยังไม่ถูก claim จึงไม่ทำให้ loop นี้หมุนค้าง
+//
+// เมื่อ ctx ถูกยกเลิก (กำลังปิดโปรแกรม) จะไม่ claim batch ใหม่ แต่ batch ที่ claim ไปแล้วจะถูกทำจนเสร็จ
+// ด้วย batchCtx เพื่อไม่ให้ document ถูก index แล้วแต่สถานะของ event ไม่ถูกบันทึก
+// batchCtx ถูกยกเลิกเฉพาะเมื่อ batch ทำไม่เสร็จภายในเวลาปิดโปรแกรม
+func (w *worker) drain(ctx, batchCtx context.Context) {
+	log.Println("--- Checking for new events... ---")
+	total := 0
+	for ctx.Err() == nil {
+		processed, err := w.processBatch(batchCtx)
+		if err != nil {
+			log.Printf("Error claiming events: %v", err)
+			break
 		}
+		total += processed
+		// ได้น้อยกว่าขนาด batch แปลว่าไม่มี event ที่พร้อมเหลืออยู่แล้ว
+		if processed < w.batchSize {
+			break
+		}
+	}
+
+	if total == 0 {
+		log.Println("--- No new events found. ---")
+		return
 	}
+	log.Printf("--- Drained %d events. ---", total)
 }
 
-func handleEvent(ctx context.Context, esClient *elastic.Client, event OutboxEvent) error {
-	// Logic การส่งข้อมูลไป Elasticsearch
-	// ในตัวอย่างนี้ เราจะจัดการเฉพาะ "branch"
-	if event.AggregateType != "branch" {
-		return fmt.Errorf("unhandled aggregate type: %s", event.AggregateType)
+// processBatch claim event หนึ่ง batch และประมวลผล คืนจำนวน event ที่ claim ได้
+func (w *worker) processBatch(ctx context.Context) (int, error) {
+	// 1. Claim events ที่พร้อมประมวลผล (pending, failed ที่ถึงเวลาลองใหม่ หรือ processing ที่ lease หมดอายุแล้ว)
+	// ทำให้รัน worker หลาย replica พร้อมกันได้โดยไม่ประมวลผล event ซ้ำ
+	events, err := claimEvents(ctx, w.db, w.id, w.batchSize, w.lease)
+	if err != nil {
+		return 0, err
+	}
+	if len(events) == 0 {
+		return 0, nil
 	}
 
-	indexName := "branches" // ชื่อ index ใน Elasticsearch
+	log.Printf("Claimed %d events to process.", len(events))
 
-	switch event.EventType {
-	case "created", "updated":
-		var payloadData map[string]interface{}
-		if err := json.Unmarshal(event.Payload, &payloadData); err != nil {
-			return fmt.Errorf("failed to unmarshal payload: %w", err)
-		}
+	// 2. ส่งทั้ง batch ไปยัง Elasticsearch ใน _bulk request เดียว
+	results := indexEvents(ctx, w.esClient, w.handlers, events)
 
-		_, err := esClient.Index().
-			Index(indexName).
-			Id(event.AggregateID).
-			BodyJson(payloadData).
-			Do(ctx)
-		return err
-
-	case "deleted":
-		_, err := esClient.Delete().
-			Index(indexName).
-			Id(event.AggregateID).
-			Do(ctx)
-		// สำหรับการลบ ถ้าไม่เจอ (404) ก็ถือว่าสำเร็จ
-		if err != nil && elastic.IsNotFound(err) {
-			log.Printf("Document with ID %s already deleted. Considering it a success.", event.AggregateID)
-			return nil
-		}
-		return err
+	// 3. อัปเดตสถานะของแต่ละ Event ตามผลลัพธ์ของ item ที่ตรงกัน
+	for i, event := range events {
+		w.recordOutcome(ctx, event, results[i])
+	}
+	return len(events), nil
+}
 
+// recordOutcome บันทึกผลการประมวลผลของ event หนึ่งรายการลง outbox_events
+// error ชั่วคราว (เช่น Elasticsearch ล่ม) จะถูกลองใหม่แบบ backoff จนครบ maxAttempts แล้วจึงเป็น 'dead'
+// error ที่ลองใหม่ก็ไม่มีทางสำเร็จ จะเป็น 'dead' ทันที ส่วน event ที่ไม่มี handler จะเป็น 'unhandled'
+func (w *worker) recordOutcome(ctx context.Context, event OutboxEvent, err error) {
+	var owned bool
+	var updateErr error
+	switch {
+	case err == nil:
+		log.Printf("Successfully processed event ID %d", event.ID)
+		owned, updateErr = markProcessed(ctx, w.db, w.id, event.ID)
+	case errors.Is(err, errUnhandled):
+		log.Printf("Event ID %d is unhandled: %v", event.ID, err)
+		owned, updateErr = markUnhandled(ctx, w.db, w.id, event.ID, err)
+	case !isRetryable(err) || w.retry.exhausted(event.Attempts):
+		log.Printf("Event ID %d is dead after %d attempt(s): %v", event.ID, event.Attempts, err)
+		owned, updateErr = markFailed(ctx, w.db, w.id, event.ID, err, 0)
 	default:
-		return fmt.Errorf("unhandled event type: %s", event.EventType)
+		retryIn := w.retry.backoff(event.Attempts)
+		log.Printf("Failed to process event ID %d (attempt %d/%d), retrying in %s: %v", event.ID, event.Attempts, w.retry.maxAttempts, retryIn, err)
+		owned, updateErr = markFailed(ctx, w.db, w.id, event.ID, err, retryIn)
+	}
+	if updateErr != nil {
+		log.Printf("CRITICAL: Failed to update status for event ID %d: %v", event.ID, updateErr)
+	} else if !owned {
+		// lease หมดอายุระหว่างประมวลผลและ worker ตัวอื่น claim แถวนี้ไปแล้ว ให้ worker ตัวนั้นเป็นผู้บันทึกผล
+		log.Printf("WARNING: Lease on event ID %d was lost before its status could be recorded", event.ID)
 	}
 }
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/olivere/elastic/v7"
+)
+
+// retryPolicy กำหนดว่า event ที่ล้มเหลวจะถูกลองใหม่กี่ครั้งและห่างกันเท่าไร
+type retryPolicy struct {
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+}
+
+// backoff คืนระยะเวลารอก่อนลองครั้งถัดไปแบบ exponential (base * 2^(attempt-1)) ที่มีเพดาน maxDelay
+// และสุ่ม jitter ในครึ่งหลังของช่วง เพื่อไม่ให้ event จำนวนมากที่ล้มเหลวพร้อมกันกลับมาลองพร้อมกันอีก
+func (p retryPolicy) backoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	delay := p.maxDelay
+	if shift := attempt - 1; shift < 32 {
+		if d := p.baseDelay << uint(shift); d > 0 && d < p.maxDelay {
+			delay = d
+		}
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// exhausted บอกว่า event ที่ลองมาแล้ว attempts ครั้งควรถูกย้ายไปเป็น 'dead' หรือไม่
+func (p retryPolicy) exhausted(attempts int) bool {
+	return attempts >= p.maxAttempts
+}
+
+// permanentError ห่อ error ที่ลองใหม่กี่ครั้งก็ไม่มีทางสำเร็จ เช่น payload ที่ unmarshal ไม่ได้
+// หรือ aggregate_type ที่ worker ไม่รู้จัก event เหล่านี้จะถูกย้ายไปเป็น 'dead' ทันที
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// permanent ทำเครื่องหมายว่า err เป็น error ที่ไม่ควรลองใหม่
+func permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// isRetryable จัดประเภท error ว่าควรลองใหม่หรือไม่
+// นอกจาก error ที่ถูกทำเครื่องหมายด้วย permanent() แล้ว Elasticsearch ตอบ 400 (เช่น mapper_parsing_exception)
+// ก็ถือว่าไม่ควรลองใหม่ เพราะ document เดิมจะถูกปฏิเสธซ้ำทุกครั้ง
+func isRetryable(err error) bool {
+	var pe *permanentError
+	if errors.As(err, &pe) {
+		return false
+	}
+	var esErr *elastic.Error
+	if errors.As(err, &esErr) && esErr.Status == http.StatusBadRequest {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicyBackoff_GrowsAndIsCapped(t *testing.T) {
+	policy := retryPolicy{maxAttempts: 5, baseDelay: 2 * time.Second, maxDelay: 30 * time.Second}
+
+	// jitter อยู่ในครึ่งหลังของช่วง: [delay/2, delay]
+	for attempt, expected := range map[int]time.Duration{
+		1:  2 * time.Second,
+		2:  4 * time.Second,
+		3:  8 * time.Second,
+		5:  30 * time.Second, // 32s ถูกจำกัดที่ maxDelay
+		64: 30 * time.Second, // shift ขนาดใหญ่ต้องไม่ overflow
+	} {
+		for i := 0; i < 20; i++ {
+			got := policy.backoff(attempt)
+			assert.GreaterOrEqual(t, got, expected/2, "attempt %d", attempt)
+			assert.LessOrEqual(t, got, expected, "attempt %d", attempt)
+		}
+	}
+
+	assert.False(t, policy.exhausted(4))
+	assert.True(t, policy.exhausted(5))
+}
+
+func TestIsRetryable(t *testing.T) {
+	assert.True(t, isRetryable(errors.New("connection refused")))
+	assert.True(t, isRetryable(&elastic.Error{Status: 503}))
+
+	assert.False(t, isRetryable(permanent(errors.New("failed to unmarshal payload"))))
+	assert.False(t, isRetryable(fmt.Errorf("wrapped: %w", permanent(errors.New("unhandled aggregate type")))))
+	assert.False(t, isRetryable(&elastic.Error{Status: 400}))
+}
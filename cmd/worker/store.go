@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// claimEvents จอง (claim) event ที่พร้อมประมวลผลให้กับ worker ตัวนี้ภายใน transaction เดียว
+//
+// - ใช้ SELECT ... FOR UPDATE SKIP LOCKED เพื่อให้ worker หลายตัวที่ claim พร้อมกันไม่ได้แถวเดียวกัน
+// - แถวที่ถูก claim จะเปลี่ยนเป็น 'processing' พร้อม locked_by/locked_until (lease) และนับ attempts เพิ่ม 1
+// - event ที่ 'failed' จะถูก claim ได้อีกเมื่อถึง next_attempt_at (retry แบบ backoff)
+// - ถ้า worker ตายระหว่างทาง lease จะหมดอายุและแถวนั้นจะถูก claim ใหม่ได้
+//
+// เวลาของ lease ใช้ NOW() ของ MySQL เพื่อไม่ให้ขึ้นกับนาฬิกาของแต่ละเครื่อง
+func claimEvents(ctx context.Context, db *sql.DB, workerID string, limit int, lease time.Duration) ([]OutboxEvent, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin claim transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// 1. ล็อกแถวที่พร้อม โดยข้ามแถวที่ worker ตัวอื่นกำลังล็อกอยู่
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id FROM outbox_events
+		WHERE status = 'pending'
+			OR (status = 'failed' AND next_attempt_at <= NOW())
+			OR (status = 'processing' AND locked_until < NOW())
+		ORDER BY id ASC
+		LIMIT ?
+		FOR UPDATE SKIP LOCKED`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select claimable events: %w", err)
+	}
+	var ids []interface{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan claimable event id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read claimable events: %w", err)
+	}
+
+	if len(ids) == 0 {
+		return nil, tx.Commit()
+	}
+
+	// 2. ทำเครื่องหมายว่าแถวเหล่านี้เป็นของ worker ตัวนี้จนกว่า lease จะหมด
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+	claimArgs := append([]interface{}{workerID, int64(lease / time.Second)}, ids...)
+	if _, err := tx.ExecContext(ctx,
+		"UPDATE outbox_events SET status = 'processing', attempts = attempts + 1, locked_by = ?, locked_until = NOW() + INTERVAL ? SECOND WHERE id IN ("+placeholders+")",
+		claimArgs...); err != nil {
+		return nil, fmt.Errorf("failed to claim events: %w", err)
+	}
+
+	// 3. อ่านข้อมูลเต็มของแถวที่ claim ได้
+	eventRows, err := tx.QueryContext(ctx,
+		"SELECT id, aggregate_id, aggregate_type, event_type, payload, attempts FROM outbox_events WHERE id IN ("+placeholders+") ORDER BY id ASC",
+		ids...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load claimed events: %w", err)
+	}
+	defer eventRows.Close()
+
+	events := make([]OutboxEvent, 0, len(ids))
+	for eventRows.Next() {
+		var event OutboxEvent
+		if err := eventRows.Scan(&event.ID, &event.AggregateID, &event.AggregateType, &event.EventType, &event.Payload, &event.Attempts); err != nil {
+			return nil, fmt.Errorf("failed to scan claimed event: %w", err)
+		}
+		events = append(events, event)
+	}
+	if err := eventRows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read claimed events: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit claim transaction: %w", err)
+	}
+	return events, nil
+}
+
+// markProcessed บันทึกว่า event ถูกประมวลผลสำเร็จและปล่อย lease
+// เงื่อนไข locked_by ป้องกันไม่ให้ worker ที่ lease หมดอายุไปแล้ว (และแถวถูก claim ใหม่) เขียนทับผลของ worker ตัวใหม่
+// คืนค่า false ถ้า worker ตัวนี้ไม่ได้เป็นเจ้าของแถวนั้นแล้ว
+func markProcessed(ctx context.Context, db *sql.DB, workerID string, eventID int64) (bool, error) {
+	return releaseEvent(ctx, db,
+		"UPDATE outbox_events SET status = 'processed', next_attempt_at = NULL, last_error = NULL, locked_by = NULL, locked_until = NULL WHERE id = ? AND status = 'processing' AND locked_by = ?",
+		eventID, workerID)
+}
+
+// markFailed บันทึก error ล่าสุดของ event และปล่อย lease
+// ถ้า retryIn > 0 event จะเป็น 'failed' และถูก claim ใหม่ได้เมื่อถึง next_attempt_at
+// ถ้า retryIn == 0 event จะเป็น 'dead' และจะไม่ถูกประมวลผลอีกจนกว่าจะมีคนสั่ง requeue
+func markFailed(ctx context.Context, db *sql.DB, workerID string, eventID int64, cause error, retryIn time.Duration) (bool, error) {
+	lastError := truncateError(cause.Error(), maxLastErrorLength)
+	if retryIn <= 0 {
+		return releaseEvent(ctx, db,
+			"UPDATE outbox_events SET status = 'dead', next_attempt_at = NULL, last_error = ?, locked_by = NULL, locked_until = NULL WHERE id = ? AND status = 'processing' AND locked_by = ?",
+			lastError, eventID, workerID)
+	}
+	// next_attempt_at มีความละเอียดระดับวินาที จึงปัดขึ้นให้รออย่างน้อย 1 วินาที
+	retrySeconds := int64((retryIn + time.Second - 1) / time.Second)
+	return releaseEvent(ctx, db,
+		"UPDATE outbox_events SET status = 'failed', next_attempt_at = NOW() + INTERVAL ? SECOND, last_error = ?, locked_by = NULL, locked_until = NULL WHERE id = ? AND status = 'processing' AND locked_by = ?",
+		retrySeconds, lastError, eventID, workerID)
+}
+
+// markUnhandled บันทึกว่าไม่มี handler สำหรับ event นี้และปล่อย lease
+// event ที่เป็น 'unhandled' จะไม่ถูก claim อีกจนกว่าจะมีคนสั่ง requeue (เช่น หลังจาก deploy handler ใหม่)
+func markUnhandled(ctx context.Context, db *sql.DB, workerID string, eventID int64, cause error) (bool, error) {
+	return releaseEvent(ctx, db,
+		"UPDATE outbox_events SET status = 'unhandled', next_attempt_at = NULL, last_error = ?, locked_by = NULL, locked_until = NULL WHERE id = ? AND status = 'processing' AND locked_by = ?",
+		truncateError(cause.Error(), maxLastErrorLength), eventID, workerID)
+}
+
+// releaseLeases คืน event ทุกรายการที่ worker ตัวนี้ยังถือ lease อยู่กลับเป็น 'pending' ใช้ตอนปิดโปรแกรมเมื่อ batch ถูกยกเลิก
+// attempts ถูกลดกลับ เพราะการประมวลผลถูกขัดจังหวะโดยการปิดโปรแกรม ไม่ได้ล้มเหลวจาก event เอง
+func releaseLeases(ctx context.Context, db *sql.DB, workerID string) (int64, error) {
+	res, err := db.ExecContext(ctx,
+		"UPDATE outbox_events SET status = 'pending', attempts = GREATEST(attempts - 1, 0), locked_by = NULL, locked_until = NULL WHERE status = 'processing' AND locked_by = ?",
+		workerID)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func releaseEvent(ctx context.Context, db *sql.DB, query string, args ...interface{}) (bool, error) {
+	res, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+// maxLastErrorLength จำกัดความยาวของ last_error ที่เก็บลง DB
+const maxLastErrorLength = 2000
+
+func truncateError(msg string, max int) string {
+	if len(msg) <= max {
+		return msg
+	}
+	// ตัดที่ขอบของตัวอักษรเพื่อไม่ให้ได้ UTF-8 ที่ไม่สมบูรณ์
+	for max > 0 && !utf8.RuneStart(msg[max]) {
+		max--
+	}
+	return msg[:max]
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReleaseLeases_ReturnsOwnProcessingEventsToPending(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	// คืนเฉพาะแถวที่ worker ตัวนี้ถืออยู่ แถวของ worker ตัวอื่นต้องไม่ถูกแตะ
+	mock.ExpectExec(regexp.QuoteMeta("SET status = 'pending', attempts = GREATEST(attempts - 1, 0), locked_by = NULL, locked_until = NULL WHERE status = 'processing' AND locked_by = ?")).
+		WithArgs("worker-1").
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	released, err := releaseLeases(context.Background(), db, "worker-1")
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), released)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
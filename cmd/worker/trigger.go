@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// subscriptionHealthCheck คือระยะเวลาที่รอ message ก่อนจะ ping เพื่อตรวจว่าการเชื่อมต่อ pub/sub ยังใช้งานได้
+const subscriptionHealthCheck = 30 * time.Second
+
+// resubscribeMaxDelay คือระยะเวลารอสูงสุดระหว่างการพยายาม subscribe ใหม่
+const resubscribeMaxDelay = 30 * time.Second
+
+// listenForNotifications subscribe channel ของ Outbox แล้วส่งสัญญาณเข้า trigger ทุกครั้งที่มี notification
+// ถ้าการเชื่อมต่อหลุด จะ subscribe ใหม่อัตโนมัติแบบ backoff และส่งสัญญาณหนึ่งครั้งหลังเชื่อมต่อได้
+// เพื่อเก็บ event ที่อาจพลาด notification ไประหว่างที่หลุด ทำงานจนกว่า ctx จะถูกยกเลิก
+func listenForNotifications(ctx context.Context, redisClient *redis.Client, channel string, trigger chan<- struct{}) {
+	delay := time.Second
+	for ctx.Err() == nil {
+		pubsub := redisClient.Subscribe(ctx, channel)
+		// รอให้ Redis ยืนยันการ subscribe ก่อน เพื่อให้รู้ว่าการเชื่อมต่อใช้งานได้จริง
+		if _, err := pubsub.Receive(ctx); err != nil {
+			pubsub.Close()
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("WARNING: Failed to subscribe to '%s': %v. Retrying in %s.", channel, err, delay)
+			sleepContext(ctx, delay)
+			delay = minDuration(delay*2, resubscribeMaxDelay)
+			continue
+		}
+
+		log.Printf("Subscribed to '%s'.", channel)
+		delay = time.Second
+		notify(trigger)
+
+		err := receiveNotifications(ctx, pubsub, trigger)
+		pubsub.Close()
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("WARNING: Lost subscription to '%s': %v. Resubscribing.", channel, err)
+	}
+}
+
+// receiveNotifications อ่าน message จาก pubsub จนกว่าการเชื่อมต่อจะใช้งานไม่ได้
+func receiveNotifications(ctx context.Context, pubsub *redis.PubSub, trigger chan<- struct{}) error {
+	for {
+		msg, err := pubsub.ReceiveTimeout(ctx, subscriptionHealthCheck)
+		if err != nil {
+			// ไม่มี message ภายในเวลาที่กำหนด ให้ ping เพื่อตรวจว่าการเชื่อมต่อยังอยู่
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				if pingErr := pubsub.Ping(ctx); pingErr != nil {
+					return pingErr
+				}
+				continue
+			}
+			return err
+		}
+
+		if m, ok := msg.(*redis.Message); ok {
+			log.Printf("Received notification: %s.", m.Payload)
+			notify(trigger)
+		}
+	}
+}
+
+// notify ส่งสัญญาณเข้า trigger แบบไม่ block ถ้ามีสัญญาณค้างอยู่แล้วก็ไม่ต้องส่งซ้ำ
+// ทำให้ notification หลายครั้งที่มาระหว่างที่ worker กำลังประมวลผลรวมเป็นการประมวลผลครั้งเดียว
+func notify(trigger chan<- struct{}) {
+	select {
+	case trigger <- struct{}{}:
+	default:
+	}
+}
+
+func sleepContext(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
+
+func minDuration(a, b time.Duration) time.Duration {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	"ES/internal/domain"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/go-sql-driver/mysql"
+	"github.com/olivere/elastic/v7"
+)
+
+// OpenDB เปิด connection pool ของ MySQL ตามการตั้งค่า (ยังไม่ได้เชื่อมต่อจริงจนกว่าจะ Ping หรือ query)
+// ต้อง import driver "github.com/go-sql-driver/mysql" ในโปรแกรมที่เรียกใช้
+func (c DatabaseConfig) OpenDB() (*sql.DB, error) {
+	dsn, err := c.driverDSN()
+	if err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database connection: %w", err)
+	}
+	db.SetMaxOpenConns(c.MaxOpenConns)
+	db.SetMaxIdleConns(c.MaxIdleConns)
+	db.SetConnMaxLifetime(c.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(c.ConnMaxIdleTime)
+	return db, nil
+}
+
+// driverDSN คืน DSN ที่บังคับให้ driver แปลงเวลาด้วย domain.TimeZone (loc) และให้ session ของ MySQL
+// ใช้เขตเวลาเดียวกัน (time_zone) เพื่อให้ NOW() และค่า DATETIME ตรงกับเวลาที่ส่งเป็น parameter
+func (c DatabaseConfig) driverDSN() (string, error) {
+	cfg, err := mysql.ParseDSN(c.DSN)
+	if err != nil {
+		return "", fmt.Errorf("invalid database DSN: %w", err)
+	}
+	cfg.ParseTime = true
+	cfg.Loc = domain.TimeZone
+	if cfg.Params == nil {
+		cfg.Params = make(map[string]string)
+	}
+	cfg.Params["time_zone"] = "'+00:00'" // offset ของ domain.TimeZone (UTC)
+	return cfg.FormatDSN(), nil
+}
+
+// NewClient สร้าง Redis client ตามการตั้งค่า
+func (c RedisConfig) NewClient() *redis.Client {
+	return redis.NewClient(&redis.Options{
+		Addr:         c.Addr,
+		Password:     c.Password,
+		DB:           c.DB,
+		PoolSize:     c.PoolSize,
+		DialTimeout:  c.DialTimeout,
+		ReadTimeout:  c.ReadTimeout,
+		WriteTimeout: c.WriteTimeout,
+	})
+}
+
+// NewClient สร้าง Elasticsearch client ตามการตั้งค่า
+func (c ElasticsearchConfig) NewClient() (*elastic.Client, error) {
+	options := []elastic.ClientOptionFunc{
+		elastic.SetURL(c.URLs...),
+		elastic.SetSniff(c.Sniff),
+		elastic.SetHealthcheckInterval(c.HealthcheckInterval),
+		elastic.SetHttpClient(&http.Client{Timeout: c.RequestTimeout}),
+	}
+	if c.Username != "" {
+		options = append(options, elastic.SetBasicAuth(c.Username, c.Password))
+	}
+	client, err := elastic.NewClient(options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Elasticsearch client: %w", err)
+	}
+	return client, nil
+}
@@ -0,0 +1,282 @@
+// Package config โหลดการตั้งค่าของทุกโปรแกรมในโปรเจกต์ (API, worker, backfill, cleanup และเครื่องมืออื่น)
+// จากค่า default, ไฟล์ YAML (ถ้ามี) และ Environment Variable ตามลำดับ ค่าที่มาทีหลังจะทับค่าก่อนหน้า
+//
+// ไฟล์ YAML ระบุได้ด้วย Environment Variable CONFIG_FILE
+// ดูชื่อ Environment Variable ของแต่ละค่าได้ที่ applyEnv
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config คือการตั้งค่าทั้งหมดของโปรเจกต์
+type Config struct {
+	Database      DatabaseConfig      `yaml:"database"`
+	Redis         RedisConfig         `yaml:"redis"`
+	Elasticsearch ElasticsearchConfig `yaml:"elasticsearch"`
+	HTTP          HTTPConfig          `yaml:"http"`
+	Outbox        OutboxConfig        `yaml:"outbox"`
+	Worker        WorkerConfig        `yaml:"worker"`
+	Cleanup       CleanupConfig       `yaml:"cleanup"`
+}
+
+// DatabaseConfig คือการตั้งค่าการเชื่อมต่อ MySQL
+type DatabaseConfig struct {
+	DSN             string        `yaml:"dsn"`
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time"`
+}
+
+// RedisConfig คือการตั้งค่าการเชื่อมต่อ Redis
+type RedisConfig struct {
+	Addr         string        `yaml:"addr"`
+	Password     string        `yaml:"password"`
+	DB           int           `yaml:"db"`
+	PoolSize     int           `yaml:"pool_size"`
+	DialTimeout  time.Duration `yaml:"dial_timeout"`
+	ReadTimeout  time.Duration `yaml:"read_timeout"`
+	WriteTimeout time.Duration `yaml:"write_timeout"`
+}
+
+// ElasticsearchConfig คือการตั้งค่าการเชื่อมต่อ Elasticsearch และชื่อ index
+type ElasticsearchConfig struct {
+	URLs                []string      `yaml:"urls"`
+	Username            string        `yaml:"username"`
+	Password            string        `yaml:"password"`
+	Sniff               bool          `yaml:"sniff"`
+	HealthcheckInterval time.Duration `yaml:"healthcheck_interval"`
+	RequestTimeout      time.Duration `yaml:"request_timeout"`
+	BranchIndex         string        `yaml:"branch_index"` // alias ที่ API ค้นหาและ worker เขียน document ของสาขา
+}
+
+// HTTPConfig คือการตั้งค่าของ API server
+type HTTPConfig struct {
+	Addr            string        `yaml:"addr"`
+	ReadTimeout     time.Duration `yaml:"read_timeout"`
+	WriteTimeout    time.Duration `yaml:"write_timeout"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	AdminToken      string        `yaml:"admin_token"` // bearer token ของ /admin (ว่าง = ปิด admin API)
+}
+
+// OutboxConfig คือการตั้งค่าของ Outbox ที่ใช้ร่วมกันระหว่าง service และ worker
+type OutboxConfig struct {
+	Channel string `yaml:"channel"` // Redis channel ที่ใช้ส่ง notification เมื่อมี event ใหม่
+}
+
+// WorkerConfig คือการตั้งค่าของ outbox worker
+type WorkerConfig struct {
+	ID              string        `yaml:"id"` // ว่าง = hostname-pid
+	BatchSize       int           `yaml:"batch_size"`
+	LeaseDuration   time.Duration `yaml:"lease_duration"`
+	MaxAttempts     int           `yaml:"max_attempts"`
+	RetryBaseDelay  time.Duration `yaml:"retry_base_delay"`
+	RetryMaxDelay   time.Duration `yaml:"retry_max_delay"`
+	PollInterval    time.Duration `yaml:"poll_interval"`
+	Debounce        time.Duration `yaml:"debounce"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+}
+
+// Archive mode ของ cmd/cleanup
+const (
+	ArchiveNone  = "none"  // ลบอย่างเดียว
+	ArchiveFile  = "file"  // เขียนเป็นไฟล์ NDJSON แบบ gzip ใน archive_dir ก่อนลบ
+	ArchiveTable = "table" // คัดลอกไปตาราง outbox_events_archive ใน transaction เดียวกับที่ลบ
+)
+
+// CleanupConfig คือการตั้งค่าของ cmd/cleanup
+type CleanupConfig struct {
+	Retention  RetentionConfig `yaml:"retention"`
+	BatchSize  int             `yaml:"batch_size"`  // จำนวนแถวสูงสุดที่ลบใน DELETE หนึ่งครั้ง
+	BatchPause time.Duration   `yaml:"batch_pause"` // เวลาพักระหว่าง batch เพื่อไม่ให้ lock ตารางนานเกินไป
+	Archive    string          `yaml:"archive"`     // none, file หรือ table
+	ArchiveDir string          `yaml:"archive_dir"` // โฟลเดอร์ของไฟล์ archive (ใช้เมื่อ archive เป็น file)
+}
+
+// RetentionConfig คือระยะเวลาที่เก็บ event แต่ละสถานะไว้ก่อนถูก cleanup ลบ (0 = ไม่ลบเลย)
+// event ที่ pending หรือ processing ยังไม่เสร็จ จึงไม่มีการตั้งค่าให้ลบ
+type RetentionConfig struct {
+	Processed time.Duration `yaml:"processed"`
+	Failed    time.Duration `yaml:"failed"` // event ที่รอ retry อยู่ ควรตั้งให้นานกว่าเวลา retry ทั้งหมดของ worker มาก
+	Dead      time.Duration `yaml:"dead"`
+	Unhandled time.Duration `yaml:"unhandled"`
+}
+
+// ByStatus คืน retention ของแต่ละสถานะที่เปิดใช้ (มากกว่า 0) เรียงตามลำดับที่ cleanup ประมวลผล
+func (r RetentionConfig) ByStatus() []StatusRetention {
+	var out []StatusRetention
+	for _, s := range []StatusRetention{
+		{Status: "processed", Retention: r.Processed},
+		{Status: "failed", Retention: r.Failed},
+		{Status: "dead", Retention: r.Dead},
+		{Status: "unhandled", Retention: r.Unhandled},
+	} {
+		if s.Retention > 0 {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// StatusRetention คือ retention ของสถานะหนึ่ง
+type StatusRetention struct {
+	Status    string
+	Retention time.Duration
+}
+
+// Default คืนค่า default สำหรับการพัฒนาในเครื่อง
+func Default() *Config {
+	return &Config{
+		Database: DatabaseConfig{
+			DSN:             "root:123456@tcp(127.0.0.1:3306)/TTDB?parseTime=true",
+			MaxOpenConns:    25,
+			MaxIdleConns:    10,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
+		},
+		Redis: RedisConfig{
+			Addr:         "localhost:6379",
+			PoolSize:     10,
+			DialTimeout:  5 * time.Second,
+			ReadTimeout:  3 * time.Second,
+			WriteTimeout: 3 * time.Second,
+		},
+		Elasticsearch: ElasticsearchConfig{
+			URLs:                []string{"http://localhost:9200"},
+			HealthcheckInterval: 60 * time.Second,
+			RequestTimeout:      30 * time.Second,
+			BranchIndex:         "branches",
+		},
+		HTTP: HTTPConfig{
+			Addr:            ":8080",
+			ReadTimeout:     15 * time.Second,
+			WriteTimeout:    30 * time.Second,
+			ShutdownTimeout: 15 * time.Second,
+		},
+		Outbox: OutboxConfig{
+			Channel: "outbox_channel",
+		},
+		Worker: WorkerConfig{
+			BatchSize:       100,
+			LeaseDuration:   60 * time.Second,
+			MaxAttempts:     10,
+			RetryBaseDelay:  5 * time.Second,
+			RetryMaxDelay:   900 * time.Second,
+			PollInterval:    30 * time.Second,
+			Debounce:        200 * time.Millisecond,
+			ShutdownTimeout: 30 * time.Second,
+		},
+		Cleanup: CleanupConfig{
+			Retention: RetentionConfig{
+				Processed: 7 * 24 * time.Hour,
+				Failed:    30 * 24 * time.Hour,
+				Dead:      30 * 24 * time.Hour,
+				Unhandled: 30 * 24 * time.Hour,
+			},
+			BatchSize:  1000,
+			BatchPause: 100 * time.Millisecond,
+			Archive:    ArchiveNone,
+			ArchiveDir: "outbox-archive",
+		},
+	}
+}
+
+// Load โหลดการตั้งค่าจากค่า default, ไฟล์ CONFIG_FILE (ถ้ามี) และ Environment Variable แล้วตรวจความถูกต้อง
+func Load() (*Config, error) {
+	cfg := Default()
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	if err := cfg.applyEnv(os.LookupEnv); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// loadFile อ่านไฟล์ YAML ทับค่าปัจจุบัน ค่าที่ไม่อยู่ในไฟล์จะคงเดิม
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true) // ชื่อ key ที่พิมพ์ผิดควรเป็น error ไม่ใช่ถูกเงียบ
+	if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// Validate ตรวจการตั้งค่าทั้งหมดและคืน error ที่รวมทุกปัญหาที่พบ
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Database.DSN != "", "database.dsn is required")
+	check(c.Database.MaxOpenConns >= 0, "database.max_open_conns must not be negative")
+	check(c.Database.MaxIdleConns >= 0, "database.max_idle_conns must not be negative")
+	check(c.Database.MaxOpenConns == 0 || c.Database.MaxIdleConns <= c.Database.MaxOpenConns,
+		"database.max_idle_conns (%d) must not exceed database.max_open_conns (%d)", c.Database.MaxIdleConns, c.Database.MaxOpenConns)
+
+	check(c.Redis.Addr != "", "redis.addr is required")
+	check(c.Redis.DB >= 0, "redis.db must not be negative")
+	check(c.Redis.PoolSize >= 0, "redis.pool_size must not be negative")
+
+	check(len(c.Elasticsearch.URLs) > 0, "elasticsearch.urls is required")
+	for _, raw := range c.Elasticsearch.URLs {
+		u, err := url.Parse(raw)
+		check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "", "elasticsearch.urls: %q is not an http(s) URL", raw)
+	}
+	check(c.Elasticsearch.RequestTimeout > 0, "elasticsearch.request_timeout must be positive")
+	check(c.Elasticsearch.BranchIndex != "", "elasticsearch.branch_index is required")
+
+	check(c.HTTP.Addr != "", "http.addr is required")
+	check(c.HTTP.ShutdownTimeout > 0, "http.shutdown_timeout must be positive")
+
+	check(c.Outbox.Channel != "", "outbox.channel is required")
+
+	check(c.Worker.BatchSize > 0, "worker.batch_size must be positive")
+	check(c.Worker.LeaseDuration > 0, "worker.lease_duration must be positive")
+	check(c.Worker.MaxAttempts > 0, "worker.max_attempts must be positive")
+	check(c.Worker.RetryBaseDelay > 0, "worker.retry_base_delay must be positive")
+	check(c.Worker.RetryMaxDelay >= c.Worker.RetryBaseDelay, "worker.retry_max_delay must not be less than worker.retry_base_delay")
+	check(c.Worker.PollInterval > 0, "worker.poll_interval must be positive")
+	check(c.Worker.Debounce >= 0, "worker.debounce must not be negative")
+	check(c.Worker.ShutdownTimeout > 0, "worker.shutdown_timeout must be positive")
+
+	r := c.Cleanup.Retention
+	check(r.Processed >= 0 && r.Failed >= 0 && r.Dead >= 0 && r.Unhandled >= 0, "cleanup.retention values must not be negative")
+	check(r.Failed == 0 || r.Failed > c.Worker.RetryMaxDelay,
+		"cleanup.retention.failed (%s) must exceed worker.retry_max_delay (%s) so events waiting for a retry are not deleted", r.Failed, c.Worker.RetryMaxDelay)
+	check(c.Cleanup.BatchSize > 0, "cleanup.batch_size must be positive")
+	check(c.Cleanup.BatchPause >= 0, "cleanup.batch_pause must not be negative")
+	switch c.Cleanup.Archive {
+	case ArchiveNone, ArchiveFile, ArchiveTable:
+	default:
+		check(false, "cleanup.archive must be one of %s, %s or %s, got %q", ArchiveNone, ArchiveFile, ArchiveTable, c.Cleanup.Archive)
+	}
+	check(c.Cleanup.Archive != ArchiveFile || c.Cleanup.ArchiveDir != "", "cleanup.archive_dir is required when cleanup.archive is %s", ArchiveFile)
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration: %w", errors.Join(errs...))
+	}
+	return nil
+}
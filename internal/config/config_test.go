@@ -0,0 +1,137 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func envMap(values map[string]string) lookupFunc {
+	return func(key string) (string, bool) {
+		v, ok := values[key]
+		return v, ok
+	}
+}
+
+func TestDefaultIsValid(t *testing.T) {
+	assert.NoError(t, Default().Validate())
+}
+
+func TestApplyEnv_OverridesDefaults(t *testing.T) {
+	cfg := Default()
+	err := cfg.applyEnv(envMap(map[string]string{
+		"DATABASE_DSN":             "user:pass@tcp(db:3306)/TTDB?parseTime=true",
+		"ELASTICSEARCH_URLS":       "http://es1:9200, http://es2:9200",
+		"OUTBOX_CHANNEL":           "outbox_staging",
+		"OUTBOX_RETENTION_DAYS":    "30",
+		"OUTBOX_DEBOUNCE_MS":       "50",
+		"SHUTDOWN_TIMEOUT_SECONDS": "5",
+		"ELASTICSEARCH_SNIFF":      "true",
+	}))
+	require.NoError(t, err)
+
+	assert.Equal(t, "user:pass@tcp(db:3306)/TTDB?parseTime=true", cfg.Database.DSN)
+	assert.Equal(t, []string{"http://es1:9200", "http://es2:9200"}, cfg.Elasticsearch.URLs)
+	assert.True(t, cfg.Elasticsearch.Sniff)
+	assert.Equal(t, "outbox_staging", cfg.Outbox.Channel)
+	assert.Equal(t, 30*24*time.Hour, cfg.Cleanup.Retention.Processed)
+	assert.Equal(t, 50*time.Millisecond, cfg.Worker.Debounce)
+	assert.Equal(t, 5*time.Second, cfg.HTTP.ShutdownTimeout)
+	assert.Equal(t, 5*time.Second, cfg.Worker.ShutdownTimeout)
+}
+
+func TestApplyEnv_ReportsInvalidValues(t *testing.T) {
+	cfg := Default()
+	err := cfg.applyEnv(envMap(map[string]string{
+		"OUTBOX_BATCH_SIZE":    "many",
+		"OUTBOX_LEASE_SECONDS": "1m",
+	}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "OUTBOX_BATCH_SIZE")
+	assert.Contains(t, err.Error(), "OUTBOX_LEASE_SECONDS")
+	assert.Equal(t, 100, cfg.Worker.BatchSize)
+}
+
+func TestLoadFile_MergesWithDefaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+database:
+  max_open_conns: 50
+elasticsearch:
+  branch_index: branches_test
+worker:
+  lease_duration: 2m
+`), 0o644))
+
+	cfg := Default()
+	require.NoError(t, cfg.loadFile(path))
+
+	assert.Equal(t, 50, cfg.Database.MaxOpenConns)
+	assert.Equal(t, "branches_test", cfg.Elasticsearch.BranchIndex)
+	assert.Equal(t, 2*time.Minute, cfg.Worker.LeaseDuration)
+	assert.Equal(t, Default().Database.DSN, cfg.Database.DSN)
+}
+
+func TestLoadFile_RejectsUnknownKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("worker:\n  batchsize: 10\n"), 0o644))
+
+	assert.Error(t, Default().loadFile(path))
+}
+
+func TestValidate_ReportsAllProblems(t *testing.T) {
+	cfg := Default()
+	cfg.Database.DSN = ""
+	cfg.Elasticsearch.URLs = []string{"localhost:9200"}
+	cfg.Worker.RetryMaxDelay = time.Second
+
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "database.dsn is required")
+	assert.Contains(t, err.Error(), `"localhost:9200" is not an http(s) URL`)
+	assert.Contains(t, err.Error(), "worker.retry_max_delay")
+}
+
+func TestValidate_Cleanup(t *testing.T) {
+	cfg := Default()
+	cfg.Cleanup.Archive = "s3"
+	cfg.Cleanup.Retention.Failed = time.Minute
+
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `cleanup.archive must be one of none, file or table, got "s3"`)
+	assert.Contains(t, err.Error(), "cleanup.retention.failed")
+
+	// retention 0 ปิดการลบของสถานะนั้น
+	cfg = Default()
+	cfg.Cleanup.Retention.Failed = 0
+	cfg.Cleanup.Retention.Unhandled = 0
+	require.NoError(t, cfg.Validate())
+	var statuses []string
+	for _, s := range cfg.Cleanup.Retention.ByStatus() {
+		statuses = append(statuses, s.Status)
+	}
+	assert.Equal(t, []string{"processed", "dead"}, statuses)
+}
+
+func TestDriverDSN_ForcesTimeZone(t *testing.T) {
+	cfg := DatabaseConfig{DSN: "user:pass@tcp(db:3306)/TTDB?loc=Local"}
+
+	dsn, err := cfg.driverDSN()
+	require.NoError(t, err)
+	parsed, err := mysql.ParseDSN(dsn)
+	require.NoError(t, err)
+	assert.True(t, parsed.ParseTime)
+	assert.Equal(t, time.UTC, parsed.Loc)
+	assert.Equal(t, "'+00:00'", parsed.Params["time_zone"])
+	assert.Equal(t, "TTDB", parsed.DBName)
+
+	cfg.DSN = "not a dsn"
+	_, err = cfg.driverDSN()
+	assert.Error(t, err)
+}
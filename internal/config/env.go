@@ -0,0 +1,140 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// lookupFunc มีรูปแบบเดียวกับ os.LookupEnv เพื่อให้ทดสอบได้โดยไม่ต้องแก้ Environment Variable จริง
+type lookupFunc func(key string) (string, bool)
+
+// applyEnv ทับค่าปัจจุบันด้วย Environment Variable ที่ตั้งไว้
+// ค่าเวลาระบุเป็นตัวเลขตามหน่วยในชื่อตัวแปร (_SECONDS, _MS, _DAYS) เพื่อให้เข้ากับตัวแปรที่ใช้อยู่เดิม
+func (c *Config) applyEnv(lookup lookupFunc) error {
+	e := envReader{lookup: lookup}
+
+	e.str("DATABASE_DSN", &c.Database.DSN)
+	e.int("DB_MAX_OPEN_CONNS", &c.Database.MaxOpenConns)
+	e.int("DB_MAX_IDLE_CONNS", &c.Database.MaxIdleConns)
+	e.duration("DB_CONN_MAX_LIFETIME_SECONDS", time.Second, &c.Database.ConnMaxLifetime)
+	e.duration("DB_CONN_MAX_IDLE_TIME_SECONDS", time.Second, &c.Database.ConnMaxIdleTime)
+
+	e.str("REDIS_ADDR", &c.Redis.Addr)
+	e.str("REDIS_PASSWORD", &c.Redis.Password)
+	e.int("REDIS_DB", &c.Redis.DB)
+	e.int("REDIS_POOL_SIZE", &c.Redis.PoolSize)
+	e.duration("REDIS_DIAL_TIMEOUT_MS", time.Millisecond, &c.Redis.DialTimeout)
+	e.duration("REDIS_READ_TIMEOUT_MS", time.Millisecond, &c.Redis.ReadTimeout)
+	e.duration("REDIS_WRITE_TIMEOUT_MS", time.Millisecond, &c.Redis.WriteTimeout)
+
+	e.list("ELASTICSEARCH_URLS", &c.Elasticsearch.URLs)
+	e.str("ELASTICSEARCH_USERNAME", &c.Elasticsearch.Username)
+	e.str("ELASTICSEARCH_PASSWORD", &c.Elasticsearch.Password)
+	e.bool("ELASTICSEARCH_SNIFF", &c.Elasticsearch.Sniff)
+	e.duration("ELASTICSEARCH_HEALTHCHECK_INTERVAL_SECONDS", time.Second, &c.Elasticsearch.HealthcheckInterval)
+	e.duration("ELASTICSEARCH_REQUEST_TIMEOUT_SECONDS", time.Second, &c.Elasticsearch.RequestTimeout)
+	e.str("BRANCH_INDEX", &c.Elasticsearch.BranchIndex)
+
+	e.str("HTTP_ADDR", &c.HTTP.Addr)
+	e.duration("HTTP_READ_TIMEOUT_SECONDS", time.Second, &c.HTTP.ReadTimeout)
+	e.duration("HTTP_WRITE_TIMEOUT_SECONDS", time.Second, &c.HTTP.WriteTimeout)
+	e.str("ADMIN_TOKEN", &c.HTTP.AdminToken)
+
+	e.str("OUTBOX_CHANNEL", &c.Outbox.Channel)
+
+	e.str("WORKER_ID", &c.Worker.ID)
+	e.int("OUTBOX_BATCH_SIZE", &c.Worker.BatchSize)
+	e.duration("OUTBOX_LEASE_SECONDS", time.Second, &c.Worker.LeaseDuration)
+	e.int("OUTBOX_MAX_ATTEMPTS", &c.Worker.MaxAttempts)
+	e.duration("OUTBOX_RETRY_BASE_SECONDS", time.Second, &c.Worker.RetryBaseDelay)
+	e.duration("OUTBOX_RETRY_MAX_SECONDS", time.Second, &c.Worker.RetryMaxDelay)
+	e.duration("OUTBOX_POLL_INTERVAL_SECONDS", time.Second, &c.Worker.PollInterval)
+	e.duration("OUTBOX_DEBOUNCE_MS", time.Millisecond, &c.Worker.Debounce)
+
+	// OUTBOX_RETENTION_DAYS เดิมใช้กับ event ที่ processed แล้วเท่านั้น
+	e.duration("OUTBOX_RETENTION_DAYS", 24*time.Hour, &c.Cleanup.Retention.Processed)
+	e.duration("OUTBOX_FAILED_RETENTION_DAYS", 24*time.Hour, &c.Cleanup.Retention.Failed)
+	e.duration("OUTBOX_DEAD_RETENTION_DAYS", 24*time.Hour, &c.Cleanup.Retention.Dead)
+	e.duration("OUTBOX_UNHANDLED_RETENTION_DAYS", 24*time.Hour, &c.Cleanup.Retention.Unhandled)
+	e.int("CLEANUP_BATCH_SIZE", &c.Cleanup.BatchSize)
+	e.duration("CLEANUP_BATCH_PAUSE_MS", time.Millisecond, &c.Cleanup.BatchPause)
+	e.str("CLEANUP_ARCHIVE", &c.Cleanup.Archive)
+	e.str("CLEANUP_ARCHIVE_DIR", &c.Cleanup.ArchiveDir)
+
+	// SHUTDOWN_TIMEOUT_SECONDS ใช้กับทั้ง API server และ worker ตามที่เคยเป็นมา
+	e.duration("SHUTDOWN_TIMEOUT_SECONDS", time.Second, &c.HTTP.ShutdownTimeout)
+	e.duration("SHUTDOWN_TIMEOUT_SECONDS", time.Second, &c.Worker.ShutdownTimeout)
+
+	if len(e.errs) > 0 {
+		return fmt.Errorf("invalid environment: %w", errors.Join(e.errs...))
+	}
+	return nil
+}
+
+// envReader อ่าน Environment Variable แต่ละประเภทและสะสม error ไว้รายงานพร้อมกัน
+type envReader struct {
+	lookup lookupFunc
+	errs   []error
+}
+
+func (e *envReader) str(key string, dst *string) {
+	if v, ok := e.lookup(key); ok {
+		*dst = v
+	}
+}
+
+func (e *envReader) int(key string, dst *int) {
+	v, ok := e.lookup(key)
+	if !ok || v == "" {
+		return
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(v))
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("%s: %q is not an integer", key, v))
+		return
+	}
+	*dst = n
+}
+
+func (e *envReader) duration(key string, unit time.Duration, dst *time.Duration) {
+	v, ok := e.lookup(key)
+	if !ok || v == "" {
+		return
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(v))
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("%s: %q is not an integer", key, v))
+		return
+	}
+	*dst = time.Duration(n) * unit
+}
+
+func (e *envReader) bool(key string, dst *bool) {
+	v, ok := e.lookup(key)
+	if !ok || v == "" {
+		return
+	}
+	b, err := strconv.ParseBool(strings.TrimSpace(v))
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("%s: %q is not a boolean", key, v))
+		return
+	}
+	*dst = b
+}
+
+func (e *envReader) list(key string, dst *[]string) {
+	v, ok := e.lookup(key)
+	if !ok || v == "" {
+		return
+	}
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	*dst = items
+}
@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"strings"
 	"time"
 )
 
@@ -10,11 +11,46 @@ type BranchNameJSON struct {
 	TH string `json:"th"`
 }
 
-// BranchLocation เก็บข้อมูลที่ตั้งของสาขา
+// Validate ตรวจว่าชื่อมีอย่างน้อยหนึ่งภาษา field คือชื่อ field ที่ใช้ใน ValidationError
+func (n BranchNameJSON) Validate(field string) error {
+	if strings.TrimSpace(n.TH) == "" && strings.TrimSpace(n.EN) == "" {
+		return NewValidationError(field, "must have a th or en value")
+	}
+	return nil
+}
+
+// GeoPoint คือพิกัดละติจูด/ลองจิจูด รูปแบบ JSON เดียวกับ geo_point ของ Elasticsearch
+type GeoPoint struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// BranchLocation เก็บข้อมูลที่ตั้งของสาขา (สาขาหนึ่งมีได้หนึ่งที่ตั้ง)
 type BranchLocation struct {
-	ProvinceID int `json:"province_id"`
-	// สังเกต: ใน DB dump คอลัมน์ name ในตาราง branch_location เป็น float ซึ่งอาจผิดพลาด
-	// หากตั้งใจให้เป็นชื่อสถานที่ ควรเปลี่ยนเป็น string
+	ProvinceID  int             `json:"province_id"`
+	Name        *BranchNameJSON `json:"name,omitempty"`        // ชื่อสถานที่ เช่น ชื่อห้าง (th/en)
+	Address     string          `json:"address,omitempty"`     // ที่อยู่
+	Coordinates *GeoPoint       `json:"coordinates,omitempty"` // ใช้ค้นหาสาขาใกล้ผู้ใช้
+}
+
+// Validate ตรวจสอบข้อมูลที่ตั้งก่อนบันทึก คืน ValidationError พร้อมรายละเอียดของแต่ละ field
+func (l BranchLocation) Validate() error {
+	var fields []FieldError
+	if l.ProvinceID <= 0 {
+		fields = append(fields, FieldError{Field: "province_id", Message: "must be a positive integer"})
+	}
+	if l.Coordinates != nil {
+		if l.Coordinates.Lat < -90 || l.Coordinates.Lat > 90 {
+			fields = append(fields, FieldError{Field: "coordinates.lat", Message: "must be between -90 and 90"})
+		}
+		if l.Coordinates.Lon < -180 || l.Coordinates.Lon > 180 {
+			fields = append(fields, FieldError{Field: "coordinates.lon", Message: "must be between -180 and 180"})
+		}
+	}
+	if len(fields) > 0 {
+		return &ValidationError{Fields: fields}
+	}
+	return nil
 }
 
 // Branch คือ struct หลักสำหรับข้อมูลสาขา
@@ -30,3 +66,21 @@ type Branch struct {
 	MaxTagthaiPrice *float64        `json:"max_tagthai_price,omitempty"` // ใช้ pointer เพื่อรองรับค่า null
 	UpdatedAt       *time.Time      `json:"updated_at,omitempty"`        // ใช้ pointer เพื่อให้เป็น optional
 }
+
+// BranchFilter คือเงื่อนไขเลือกสาขาจาก MySQL ค่าศูนย์ (zero value) ของแต่ละ field หมายถึงไม่กรอง
+type BranchFilter struct {
+	IDs           []int64    `json:"ids,omitempty"`            // เฉพาะสาขาที่มี ID อยู่ในรายการนี้
+	FromID        int64      `json:"from_id,omitempty"`        // ID ตั้งแต่ค่านี้ (รวมค่านี้)
+	ToID          int64      `json:"to_id,omitempty"`          // ID ไม่เกินค่านี้ (รวมค่านี้)
+	UpdatedAfter  *time.Time `json:"updated_after,omitempty"`  // updated_at หลังเวลานี้
+	UpdatedBefore *time.Time `json:"updated_before,omitempty"` // updated_at ก่อนเวลานี้
+	ProvinceID    *int       `json:"province_id,omitempty"`    // ใช้ pointer เพื่อให้เป็น optional
+	ProductIDs    []int      `json:"product_ids,omitempty"`    // สาขาที่มีสินค้าอย่างน้อยหนึ่งรายการในนี้
+	InterestIDs   []int      `json:"interest_ids,omitempty"`   // สาขาที่มีความสนใจอย่างน้อยหนึ่งรายการในนี้
+}
+
+// IsEmpty บอกว่า filter ไม่ได้กรองอะไรเลย (เลือกทุกสาขา)
+func (f BranchFilter) IsEmpty() bool {
+	return len(f.IDs) == 0 && f.FromID == 0 && f.ToID == 0 && f.UpdatedAfter == nil &&
+		f.UpdatedBefore == nil && f.ProvinceID == nil && len(f.ProductIDs) == 0 && len(f.InterestIDs) == 0
+}
@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// ค่าของ BranchListQuery.Sort
+const (
+	BranchSortID        = "id"
+	BranchSortName      = "name" // เรียงตาม name.th
+	BranchSortUpdatedAt = "updated_at"
+)
+
+// BranchListQuery คือเงื่อนไขการอ่านรายการสาขาจาก MySQL ทีละหน้าแบบ keyset
+// ทุกการเรียงใช้ ID เป็นตัวตัดสินเมื่อค่าที่ใช้เรียงเท่ากัน ทำให้ลำดับคงที่ระหว่างหน้า
+type BranchListQuery struct {
+	Filter BranchFilter
+	Sort   string        // BranchSortID (ค่าเริ่มต้น), BranchSortName หรือ BranchSortUpdatedAt
+	Desc   bool          // เรียงจากมากไปน้อย
+	After  *BranchCursor // เฉพาะสาขาที่อยู่หลัง cursor นี้ (nil คือหน้าแรก)
+	Limit  int
+}
+
+// BranchPage คือรายการสาขาหนึ่งหน้าจาก BranchListQuery
+type BranchPage struct {
+	Branches []*Branch
+	Next     *BranchCursor // ตำแหน่งเริ่มของหน้าถัดไป nil ถ้าไม่มีหน้าถัดไปแล้ว
+}
+
+// BranchCursor คือตำแหน่งของสาขาสุดท้ายในหน้าก่อน ใช้เป็นจุดเริ่มของหน้าถัดไป
+// เก็บค่าที่ใช้เรียงของสาขานั้นไว้ จึงใช้ได้กับการเรียงแบบเดียวกับที่สร้าง cursor เท่านั้น
+type BranchCursor struct {
+	Sort      string     `json:"sort"`
+	Desc      bool       `json:"desc,omitempty"`
+	ID        int64      `json:"id"`
+	Name      string     `json:"name,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"` // nil ถ้าสาขาไม่มี updated_at
+}
+
+// ErrInvalidCursor คือ error เมื่อ cursor ที่ได้รับถอดรหัสไม่ได้
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// NewBranchCursor สร้าง cursor ที่ชี้ไปยัง branch สำหรับการเรียงตาม query
+func NewBranchCursor(query BranchListQuery, branch *Branch) *BranchCursor {
+	return &BranchCursor{
+		Sort:      query.Sort,
+		Desc:      query.Desc,
+		ID:        branch.ID,
+		Name:      branch.Name.TH,
+		UpdatedAt: branch.UpdatedAt,
+	}
+}
+
+// Matches บอกว่า cursor ถูกสร้างจากการเรียงแบบเดียวกับ query หรือไม่
+func (c BranchCursor) Matches(query BranchListQuery) bool {
+	return c.Sort == query.Sort && c.Desc == query.Desc
+}
+
+// Encode แปลง cursor เป็นข้อความที่ใส่ใน URL ได้ ผู้เรียกควรถือว่าเป็นค่าทึบ (opaque)
+func (c BranchCursor) Encode() string {
+	data, _ := json.Marshal(c) // struct นี้ marshal ไม่มีทางล้มเหลว
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeBranchCursor แปลงข้อความจาก Encode กลับเป็น cursor
+func DecodeBranchCursor(s string) (*BranchCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var cursor BranchCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
@@ -0,0 +1,48 @@
+package domain
+
+import "time"
+
+// Product คือสินค้าที่สาขาขาย
+type Product struct {
+	ID        int64          `json:"id"`
+	Name      BranchNameJSON `json:"name"`
+	UpdatedAt *time.Time     `json:"updated_at,omitempty"`
+}
+
+// ProductOption คือตัวเลือกราคาของสินค้า (สินค้าหนึ่งมีได้หลายตัวเลือก)
+type ProductOption struct {
+	ID           int64    `json:"id"`
+	ProductID    int64    `json:"product_id"`
+	NormalPrice  *float64 `json:"normal_price_thb"`  // ใช้ pointer เพื่อรองรับค่า null
+	TagthaiPrice *float64 `json:"tagthai_price_thb"` // ใช้ pointer เพื่อรองรับค่า null
+}
+
+// Interest คือความสนใจที่เชื่อมโยงกับสาขา เช่น กีฬา อาหาร
+type Interest struct {
+	ID   int64          `json:"id"`
+	Name BranchNameJSON `json:"name"`
+}
+
+// PageQuery คือการแบ่งหน้าแบบ keyset เรียงตาม ID จากน้อยไปมาก
+type PageQuery struct {
+	AfterID int64 // เฉพาะรายการที่ ID มากกว่าค่านี้ (ค่า next_after_id ของหน้าก่อน)
+	Limit   int
+}
+
+// ProductPage คือรายการสินค้าหนึ่งหน้าจาก PageQuery
+type ProductPage struct {
+	Products    []*Product
+	NextAfterID int64 // AfterID ของหน้าถัดไป 0 ถ้าไม่มีหน้าถัดไปแล้ว
+}
+
+// ProductOptionPage คือรายการตัวเลือกราคาของสินค้าหนึ่งหน้าจาก PageQuery
+type ProductOptionPage struct {
+	Options     []*ProductOption
+	NextAfterID int64 // AfterID ของหน้าถัดไป 0 ถ้าไม่มีหน้าถัดไปแล้ว
+}
+
+// InterestPage คือรายการความสนใจหนึ่งหน้าจาก PageQuery
+type InterestPage struct {
+	Interests   []*Interest
+	NextAfterID int64 // AfterID ของหน้าถัดไป 0 ถ้าไม่มีหน้าถัดไปแล้ว
+}
@@ -0,0 +1,71 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Sentinel error ที่ใช้แยกประเภทของ error ด้วย errors.Is
+// repository คืน error แบบมีรายละเอียด (NotFoundError, ConflictError, ValidationError) ที่ Is ตรงกับ sentinel เหล่านี้
+// service ห่อ error ด้วย fmt.Errorf("...: %w") ได้ตามปกติโดยไม่ทำให้ประเภทหายไป
+var (
+	ErrNotFound   = errors.New("not found")
+	ErrConflict   = errors.New("conflict")
+	ErrValidation = errors.New("validation failed")
+)
+
+// NotFoundError คือ error เมื่อไม่พบข้อมูลที่ระบุ เช่น สาขาที่ไม่มีอยู่
+type NotFoundError struct {
+	Resource string // เช่น "branch", "product"
+	ID       int64
+}
+
+// NewNotFoundError สร้าง NotFoundError ของ resource ที่มี id นี้
+func NewNotFoundError(resource string, id int64) error {
+	return &NotFoundError{Resource: resource, ID: id}
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("%s with id %d not found", e.Resource, e.ID)
+}
+
+func (e *NotFoundError) Is(target error) bool { return target == ErrNotFound }
+
+// ConflictError คือ error เมื่อการเปลี่ยนแปลงขัดกับข้อมูลที่มีอยู่ เช่น ข้อมูลซ้ำ หรือยังมีข้อมูลอื่นอ้างอิงอยู่
+type ConflictError struct {
+	Resource string
+	Message  string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s conflict: %s", e.Resource, e.Message)
+}
+
+func (e *ConflictError) Is(target error) bool { return target == ErrConflict }
+
+// FieldError คือปัญหาของ field หนึ่งใน input
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError คือ error เมื่อ input ไม่ถูกต้อง เช่น อ้างถึงสินค้าที่ไม่มีอยู่
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// NewValidationError สร้าง ValidationError ของ field เดียว
+func NewValidationError(field, message string) error {
+	return &ValidationError{Fields: []FieldError{{Field: field, Message: message}}}
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		parts[i] = f.Field + ": " + f.Message
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+func (e *ValidationError) Is(target error) bool { return target == ErrValidation }
@@ -0,0 +1,83 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// สถานะของ outbox event (คอลัมน์ outbox_events.status)
+const (
+	OutboxStatusPending    = "pending"
+	OutboxStatusProcessing = "processing"
+	OutboxStatusProcessed  = "processed"
+	OutboxStatusFailed     = "failed"    // รอ retry เมื่อถึง next_attempt_at
+	OutboxStatusDead       = "dead"      // retry ครบแล้วหรือ error ที่ retry ไม่ได้
+	OutboxStatusUnhandled  = "unhandled" // ไม่มี handler ของ aggregate_type/event_type นี้
+)
+
+// OutboxStatuses คือสถานะทั้งหมดที่ถูกต้อง
+var OutboxStatuses = []string{
+	OutboxStatusPending, OutboxStatusProcessing, OutboxStatusProcessed,
+	OutboxStatusFailed, OutboxStatusDead, OutboxStatusUnhandled,
+}
+
+// RequeueableOutboxStatuses คือสถานะที่ผู้ดูแลสั่ง requeue กลับไปเป็น pending ได้
+var RequeueableOutboxStatuses = []string{OutboxStatusFailed, OutboxStatusDead, OutboxStatusUnhandled}
+
+// OutboxEvent คือหนึ่งแถวของตาราง outbox_events
+type OutboxEvent struct {
+	ID            int64           `json:"id"`
+	AggregateID   string          `json:"aggregate_id"`
+	AggregateType string          `json:"aggregate_type"`
+	EventType     string          `json:"event_type"`
+	Payload       json.RawMessage `json:"payload,omitempty"` // ไม่มีในผลลัพธ์ของ ListEvents
+	Status        string          `json:"status"`
+	Attempts      int             `json:"attempts"`
+	NextAttemptAt *time.Time      `json:"next_attempt_at,omitempty"`
+	LastError     *string         `json:"last_error,omitempty"`
+	LockedBy      *string         `json:"locked_by,omitempty"`
+	LockedUntil   *time.Time      `json:"locked_until,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+// OutboxEventFilter คือเงื่อนไขการค้นหา outbox event ค่าศูนย์ของแต่ละ field หมายถึงไม่กรอง
+// ผลลัพธ์เรียงจาก ID มากไปน้อย (ใหม่ก่อน) และแบ่งหน้าด้วย BeforeID
+type OutboxEventFilter struct {
+	Statuses      []string
+	AggregateType string
+	AggregateID   string
+	EventType     string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	BeforeID      int64 // เฉพาะ event ที่ ID น้อยกว่าค่านี้ (ID สุดท้ายของหน้าก่อน)
+	Limit         int
+}
+
+// OutboxEventPage คือรายการ outbox event หนึ่งหน้าจาก OutboxEventFilter
+type OutboxEventPage struct {
+	Events       []*OutboxEvent
+	NextBeforeID int64 // BeforeID ของหน้าถัดไป 0 ถ้าไม่มีหน้าถัดไปแล้ว
+}
+
+// OutboxStats คือภาพรวมของตาราง outbox_events
+type OutboxStats struct {
+	Counts                  map[string]int64 `json:"counts"` // จำนวน event ของแต่ละสถานะ
+	OldestPendingCreatedAt  *time.Time       `json:"oldest_pending_created_at,omitempty"`
+	OldestPendingAgeSeconds int64            `json:"oldest_pending_age_seconds"` // 0 ถ้าไม่มี event ที่ pending
+}
+
+// OutboxAuditEntry คือบันทึกการกระทำของผู้ดูแลต่อ outbox event หนึ่งครั้ง
+type OutboxAuditEntry struct {
+	ID        int64     `json:"id"`
+	Actor     string    `json:"actor"`
+	Action    string    `json:"action"` // requeue หรือ purge
+	EventIDs  []int64   `json:"event_ids"`
+	Affected  int64     `json:"affected"` // จำนวน event ที่เปลี่ยนแปลงจริง
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// การกระทำของผู้ดูแลที่ถูกบันทึกใน audit trail
+const (
+	OutboxAuditRequeue = "requeue"
+	OutboxAuditPurge   = "purge"
+)
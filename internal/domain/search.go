@@ -0,0 +1,29 @@
+package domain
+
+// BranchSearchQuery คือเงื่อนไขการค้นหาสาขาใน Elasticsearch
+type BranchSearchQuery struct {
+	Text        string // ค้นหาแบบ full-text ใน name.th และ name.en
+	ProductIDs  []int  // สาขาที่มีสินค้าอย่างน้อยหนึ่งรายการในนี้
+	InterestIDs []int  // สาขาที่มีความสนใจอย่างน้อยหนึ่งรายการในนี้
+	ProvinceID  *int   // ใช้ pointer เพื่อให้เป็น optional
+	Page        int    // เริ่มที่ 1
+	PageSize    int
+
+	// Near (ถ้ามี) จำกัดผลลัพธ์เฉพาะสาขาที่อยู่ในระยะ RadiusKm จากจุดนี้ และเรียงจากใกล้ไปไกล
+	Near     *GeoPoint
+	RadiusKm float64
+}
+
+// BranchSearchResult คือผลลัพธ์การค้นหาสาขาหนึ่งหน้า
+type BranchSearchResult struct {
+	Total    int64              `json:"total"`
+	Branches []*BranchSearchHit `json:"branches"`
+}
+
+// BranchSearchHit คือสาขาหนึ่งรายการในผลการค้นหา field ของ Branch ถูก encode เป็น JSON ในระดับเดียวกับ distance_km
+// ข้อมูลที่มีเฉพาะในผลการค้นหาอยู่ที่นี่ ไม่ใช่ใน Branch ซึ่งเป็นทั้ง document ใน index และ payload ของ outbox
+type BranchSearchHit struct {
+	*Branch
+	// DistanceKm คือระยะทางจากจุดที่ค้นหา มีค่าเฉพาะในการค้นหาแบบระบุพิกัด
+	DistanceKm *float64 `json:"distance_km,omitempty"`
+}
@@ -0,0 +1,22 @@
+package domain
+
+import "time"
+
+// TimeZone คือเขตเวลาของทั้งระบบ วันที่ที่ไม่มีเวลา (YYYY-MM-DD) จาก API และ flag ถูกตีความในเขตนี้
+// และ connection ของ MySQL ใช้เขตเดียวกันทั้งฝั่ง driver (loc) และ session (time_zone)
+// ค่า DATETIME เช่น branch.updated_at จึงเทียบกับเวลาที่ผู้ใช้ส่งมาได้ตรงกันโดยไม่ขึ้นกับเขตเวลาของเครื่อง
+var TimeZone = time.UTC
+
+// ParseTime แปลงข้อความที่เป็น RFC3339 หรือ YYYY-MM-DD (เวลา 00:00 ใน TimeZone) ค่าว่างคืน nil
+func ParseTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		if t, err = time.ParseInLocation("2006-01-02", value, TimeZone); err != nil {
+			return nil, err
+		}
+	}
+	return &t, nil
+}
@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"ES/internal/domain"
+	"ES/internal/ports"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ค่าเริ่มต้นและค่าสูงสุดของจำนวนรายการต่อหน้าใน admin API
+const (
+	defaultAdminPageSize = 50
+	maxAdminPageSize     = 500
+	// maxAdminBatchSize คือจำนวน event สูงสุดที่ requeue หรือ purge ได้ในหนึ่ง request
+	maxAdminBatchSize = 1000
+)
+
+// AdminActorHeader คือ header ที่ระบุชื่อผู้ดูแลที่สั่งการ ถูกบันทึกลง audit trail
+const AdminActorHeader = "X-Admin-Actor"
+
+// OutboxEventIDsRequest คือ body ของ requeue และ purge
+type OutboxEventIDsRequest struct {
+	IDs []int64 `json:"ids" binding:"required,min=1"`
+}
+
+// AdminHandler คือ handler ของ /admin/outbox
+type AdminHandler struct {
+	outboxAdminService ports.OutboxAdminService
+}
+
+// NewAdminHandler คือ factory function สำหรับสร้าง AdminHandler
+func NewAdminHandler(outboxAdminSvc ports.OutboxAdminService) *AdminHandler {
+	return &AdminHandler{outboxAdminService: outboxAdminSvc}
+}
+
+// RequireAdminToken คือ middleware ที่ยอมรับเฉพาะ request ที่มี header "Authorization: Bearer <token>" ตรงกับ token
+func RequireAdminToken(token string) gin.HandlerFunc {
+	expected := []byte("Bearer " + token)
+	return func(c *gin.Context) {
+		if subtle.ConstantTimeCompare([]byte(c.GetHeader("Authorization")), expected) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, ErrorResponse{Error: "Unauthorized", Code: codeUnauthorized})
+			return
+		}
+		c.Next()
+	}
+}
+
+// ListOutboxEvents คือ handler สำหรับดูรายการ outbox event (ไม่รวม payload) เรียงจากใหม่ไปเก่า
+// Query parameters:
+//   - status: สถานะ คั่นด้วย comma หรือส่งซ้ำหลายครั้ง
+//   - aggregate_type, aggregate_id, event_type
+//   - created_after, created_before: RFC3339 หรือ YYYY-MM-DD (เวลา 00:00 UTC)
+//   - before_id: ค่า next_before_id ของหน้าก่อน
+//   - limit: จำนวนรายการต่อหน้า
+func (h *AdminHandler) ListOutboxEvents(c *gin.Context) {
+	filter := domain.OutboxEventFilter{
+		AggregateType: c.Query("aggregate_type"),
+		AggregateID:   c.Query("aggregate_id"),
+		EventType:     c.Query("event_type"),
+		Limit:         defaultAdminPageSize,
+	}
+
+	for _, value := range c.QueryArray("status") {
+		for _, status := range strings.Split(value, ",") {
+			if status = strings.TrimSpace(status); status == "" {
+				continue
+			}
+			if !isOutboxStatus(status) {
+				badRequest(c, fmt.Sprintf("Invalid status %q", status))
+				return
+			}
+			filter.Statuses = append(filter.Statuses, status)
+		}
+	}
+
+	var err error
+	if filter.CreatedAfter, err = domain.ParseTime(c.Query("created_after")); err != nil {
+		badRequest(c, "Invalid created_after")
+		return
+	}
+	if filter.CreatedBefore, err = domain.ParseTime(c.Query("created_before")); err != nil {
+		badRequest(c, "Invalid created_before")
+		return
+	}
+	if v := c.Query("before_id"); v != "" {
+		if filter.BeforeID, err = strconv.ParseInt(v, 10, 64); err != nil || filter.BeforeID < 1 {
+			badRequest(c, "Invalid before_id")
+			return
+		}
+	}
+	if v := c.Query("limit"); v != "" {
+		if filter.Limit, err = strconv.Atoi(v); err != nil || filter.Limit < 1 || filter.Limit > maxAdminPageSize {
+			badRequest(c, fmt.Sprintf("limit must be between 1 and %d", maxAdminPageSize))
+			return
+		}
+	}
+
+	page, err := h.outboxAdminService.ListEvents(c.Request.Context(), filter)
+	if err != nil {
+		respondError(c, "listing outbox events", err)
+		return
+	}
+
+	response := gin.H{"data": page.Events}
+	if page.NextBeforeID > 0 {
+		response["next_before_id"] = page.NextBeforeID
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetOutboxEvent คือ handler สำหรับดู event หนึ่งรายการพร้อม payload และ error ล่าสุด
+func (h *AdminHandler) GetOutboxEvent(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		badRequest(c, "Invalid event ID")
+		return
+	}
+
+	event, err := h.outboxAdminService.GetEvent(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, "getting outbox event", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": event})
+}
+
+// GetOutboxStats คือ handler สำหรับดูจำนวน event ของแต่ละสถานะและอายุของ event ที่ pending นานที่สุด
+func (h *AdminHandler) GetOutboxStats(c *gin.Context) {
+	stats, err := h.outboxAdminService.Stats(c.Request.Context())
+	if err != nil {
+		respondError(c, "getting outbox stats", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": stats})
+}
+
+// RequeueOutboxEvents คือ handler สำหรับย้าย event ที่ failed, dead หรือ unhandled กลับไปเป็น pending
+// event ที่อยู่ในสถานะอื่นจะถูกข้าม (ดู affected ใน response)
+func (h *AdminHandler) RequeueOutboxEvents(c *gin.Context) {
+	h.modifyOutboxEvents(c, "requeueing", h.outboxAdminService.RequeueEvents)
+}
+
+// PurgeOutboxEvents คือ handler สำหรับลบ event ที่เลือก (event ที่กำลังถูกประมวลผลจะถูกข้าม)
+func (h *AdminHandler) PurgeOutboxEvents(c *gin.Context) {
+	h.modifyOutboxEvents(c, "purging", h.outboxAdminService.PurgeEvents)
+}
+
+// modifyOutboxEvents ตรวจ request ของ requeue และ purge แล้วเรียก action
+func (h *AdminHandler) modifyOutboxEvents(c *gin.Context, verb string,
+	action func(ctx context.Context, actor string, ids []int64) (*domain.OutboxAuditEntry, error)) {
+	actor := strings.TrimSpace(c.GetHeader(AdminActorHeader))
+	if actor == "" {
+		badRequest(c, AdminActorHeader+" header is required")
+		return
+	}
+
+	var req OutboxEventIDsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+	if len(req.IDs) > maxAdminBatchSize {
+		badRequest(c, fmt.Sprintf("at most %d ids per request", maxAdminBatchSize))
+		return
+	}
+
+	entry, err := action(c.Request.Context(), actor, req.IDs)
+	if err != nil {
+		respondError(c, verb+" outbox events", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": entry})
+}
+
+// ListOutboxAudit คือ handler สำหรับดู audit trail ล่าสุดของการกระทำผ่าน admin API
+func (h *AdminHandler) ListOutboxAudit(c *gin.Context) {
+	limit := defaultAdminPageSize
+	if v := c.Query("limit"); v != "" {
+		var err error
+		if limit, err = strconv.Atoi(v); err != nil || limit < 1 || limit > maxAdminPageSize {
+			badRequest(c, fmt.Sprintf("limit must be between 1 and %d", maxAdminPageSize))
+			return
+		}
+	}
+
+	entries, err := h.outboxAdminService.ListAuditEntries(c.Request.Context(), limit)
+	if err != nil {
+		respondError(c, "listing outbox audit entries", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": entries})
+}
+
+func isOutboxStatus(status string) bool {
+	for _, s := range domain.OutboxStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
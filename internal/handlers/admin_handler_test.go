@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"ES/internal/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newAdminRouter(svc *mockOutboxAdminService) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	handler := NewAdminHandler(svc)
+	router := gin.New()
+	admin := router.Group("/admin/outbox", RequireAdminToken("secret"))
+	admin.GET("/events", handler.ListOutboxEvents)
+	admin.GET("/events/:id", handler.GetOutboxEvent)
+	admin.POST("/requeue", handler.RequeueOutboxEvents)
+	return router
+}
+
+func TestAdminRoutes_RequireToken(t *testing.T) {
+	router := newAdminRouter(&mockOutboxAdminService{})
+
+	for _, header := range []string{"", "Bearer wrong", "secret"} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/admin/outbox/events", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code, header)
+	}
+}
+
+func TestListOutboxEvents_ParsesFilter(t *testing.T) {
+	svc := &mockOutboxAdminService{page: &domain.OutboxEventPage{Events: []*domain.OutboxEvent{{ID: 10}, {ID: 8}}, NextBeforeID: 8}}
+	router := newAdminRouter(svc)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/admin/outbox/events?status=failed,dead&aggregate_type=branch&aggregate_id=5&created_after=2025-01-02&before_id=100&limit=2", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	after := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, domain.OutboxEventFilter{
+		Statuses:      []string{"failed", "dead"},
+		AggregateType: "branch",
+		AggregateID:   "5",
+		CreatedAfter:  &after,
+		BeforeID:      100,
+		Limit:         2,
+	}, svc.filter)
+	// repository พบหน้าถัดไปจึงมี cursor
+	assert.Contains(t, w.Body.String(), `"next_before_id":8`)
+
+	// หน้าสุดท้ายไม่มี cursor แม้ว่าหน้าจะเต็ม
+	svc.page.NextBeforeID = 0
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/admin/outbox/events?limit=2", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "next_before_id")
+
+	for _, query := range []string{"status=done", "created_after=yesterday", "limit=0", "before_id=x"} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/admin/outbox/events?"+query, nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
+func TestGetOutboxEvent_NotFound(t *testing.T) {
+	router := newAdminRouter(&mockOutboxAdminService{})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/admin/outbox/events/42", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestRequeueOutboxEvents_RequiresActor(t *testing.T) {
+	svc := &mockOutboxAdminService{}
+	router := newAdminRouter(svc)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/admin/outbox/requeue", strings.NewReader(`{"ids":[1,2]}`))
+	req.Header.Set("Authorization", "Bearer secret")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/admin/outbox/requeue", strings.NewReader(`{"ids":[1,2]}`))
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set(AdminActorHeader, "alice")
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "alice", svc.actor)
+	assert.Equal(t, []int64{1, 2}, svc.ids)
+}
+
+// Mock OutboxAdminService
+type mockOutboxAdminService struct {
+	filter domain.OutboxEventFilter
+	page   *domain.OutboxEventPage
+	actor  string
+	ids    []int64
+}
+
+func (m *mockOutboxAdminService) ListEvents(ctx context.Context, filter domain.OutboxEventFilter) (*domain.OutboxEventPage, error) {
+	m.filter = filter
+	if m.page == nil {
+		return &domain.OutboxEventPage{Events: []*domain.OutboxEvent{}}, nil
+	}
+	return m.page, nil
+}
+
+func (m *mockOutboxAdminService) GetEvent(ctx context.Context, id int64) (*domain.OutboxEvent, error) {
+	return nil, domain.NewNotFoundError("outbox event", id)
+}
+
+func (m *mockOutboxAdminService) Stats(ctx context.Context) (*domain.OutboxStats, error) {
+	return &domain.OutboxStats{}, nil
+}
+
+func (m *mockOutboxAdminService) RequeueEvents(ctx context.Context, actor string, ids []int64) (*domain.OutboxAuditEntry, error) {
+	m.actor, m.ids = actor, ids
+	return &domain.OutboxAuditEntry{Actor: actor, Action: domain.OutboxAuditRequeue, EventIDs: ids, Affected: int64(len(ids))}, nil
+}
+
+func (m *mockOutboxAdminService) PurgeEvents(ctx context.Context, actor string, ids []int64) (*domain.OutboxAuditEntry, error) {
+	m.actor, m.ids = actor, ids
+	return &domain.OutboxAuditEntry{Actor: actor, Action: domain.OutboxAuditPurge, EventIDs: ids}, nil
+}
+
+func (m *mockOutboxAdminService) ListAuditEntries(ctx context.Context, limit int) ([]*domain.OutboxAuditEntry, error) {
+	return nil, nil
+}
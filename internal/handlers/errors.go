@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"ES/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrorResponse คือรูปแบบ body ของทุก response ที่เป็น error
+type ErrorResponse struct {
+	Error  string              `json:"error"`            // ข้อความสำหรับคนอ่าน
+	Code   string              `json:"code"`             // รหัสคงที่สำหรับโปรแกรม เช่น not_found
+	Fields []domain.FieldError `json:"fields,omitempty"` // ปัญหาของแต่ละ field เมื่อ code เป็น validation_failed
+}
+
+// รหัสใน ErrorResponse.Code
+const (
+	codeBadRequest       = "bad_request"
+	codeUnauthorized     = "unauthorized"
+	codeNotFound         = "not_found"
+	codeConflict         = "conflict"
+	codeValidationFailed = "validation_failed"
+	codeInternalError    = "internal_error"
+)
+
+// respondError แปลง error จาก service เป็น HTTP status และ ErrorResponse
+//
+//   - domain.ErrNotFound   -> 404
+//   - domain.ErrConflict   -> 409
+//   - domain.ErrValidation -> 422 พร้อมรายละเอียดของแต่ละ field
+//   - อื่นๆ                -> 500 โดยไม่เปิดเผยรายละเอียด และ log error ฉบับเต็มไว้สำหรับนักพัฒนา
+//
+// action อธิบายสิ่งที่ handler กำลังทำ (เช่น "creating branch") ใช้ใน log
+func respondError(c *gin.Context, action string, err error) {
+	var (
+		notFound   *domain.NotFoundError
+		conflict   *domain.ConflictError
+		validation *domain.ValidationError
+	)
+	switch {
+	case errors.As(err, &notFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: notFound.Error(), Code: codeNotFound})
+	case errors.As(err, &conflict):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: conflict.Error(), Code: codeConflict})
+	case errors.As(err, &validation):
+		c.JSON(http.StatusUnprocessableEntity, ErrorResponse{Error: validation.Error(), Code: codeValidationFailed, Fields: validation.Fields})
+	// sentinel ที่ไม่มีรายละเอียด (เช่น fmt.Errorf("...: %w", domain.ErrNotFound))
+	case errors.Is(err, domain.ErrNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Not found", Code: codeNotFound})
+	case errors.Is(err, domain.ErrConflict):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "Conflict", Code: codeConflict})
+	case errors.Is(err, domain.ErrValidation):
+		c.JSON(http.StatusUnprocessableEntity, ErrorResponse{Error: "Validation failed", Code: codeValidationFailed})
+	default:
+		log.Printf("Error %s: %v", action, err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "An internal server error occurred", Code: codeInternalError})
+	}
+}
+
+// badRequest ตอบ 400 เมื่อ request ผิดรูปแบบ (เช่น ID ไม่ใช่ตัวเลข หรือ JSON ไม่ถูกต้อง)
+func badRequest(c *gin.Context, message string) {
+	c.JSON(http.StatusBadRequest, ErrorResponse{Error: message, Code: codeBadRequest})
+}
@@ -1,9 +1,11 @@
 package handlers
 
 import (
-	"log"
+	"context"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"ES/internal/domain"
 	"ES/internal/ports"
@@ -12,9 +14,12 @@ import (
 )
 
 // CreateBranchRequest คือ struct สำหรับรับข้อมูล JSON จาก request body
+// ตอน update ถ้าไม่ส่ง interest_ids มา (หรือส่งเป็น null) ความสนใจที่เชื่อมโยงอยู่จะไม่เปลี่ยน
+// ส่ง [] เพื่อลบการเชื่อมโยงทั้งหมด
 type CreateBranchRequest struct {
-	Name       domain.BranchNameJSON `json:"name" binding:"required"`
-	ProductIDs []int                 `json:"product_ids"`
+	Name        domain.BranchNameJSON `json:"name" binding:"required"`
+	ProductIDs  []int                 `json:"product_ids"`
+	InterestIDs []int                 `json:"interest_ids"`
 }
 
 // UpdateNameRequest เป็น struct กลางสำหรับรับข้อมูล JSON ที่มีแค่ name
@@ -22,24 +27,54 @@ type UpdateNameRequest struct {
 	Name domain.BranchNameJSON `json:"name" binding:"required"`
 }
 
+// BranchLocationRequest คือ struct สำหรับรับข้อมูล JSON ของที่ตั้งสาขา (PUT แทนที่ทั้งหมด)
+type BranchLocationRequest struct {
+	ProvinceID  int                    `json:"province_id" binding:"required"`
+	Name        *domain.BranchNameJSON `json:"name"`
+	Address     string                 `json:"address"`
+	Coordinates *domain.GeoPoint       `json:"coordinates"`
+}
+
 // UpdateProductOptionRequest คือ struct สำหรับรับข้อมูล JSON ของ product_option
 type UpdateProductOptionRequest struct {
 	NormalPrice  float64 `json:"normal_price_thb" binding:"required"`
 	TagthaiPrice float64 `json:"tagthai_price_thb" binding:"required"`
 }
 
+// ค่าเริ่มต้นและค่าสูงสุดของจำนวนผลลัพธ์ต่อหน้าในการค้นหา
+// maxSearchWindow คือจำนวนผลลัพธ์สูงสุดที่แบ่งหน้าได้ (index.max_result_window ของ Elasticsearch)
+const (
+	defaultSearchPageSize = 20
+	maxSearchPageSize     = 100
+	maxSearchWindow       = 10000
+)
+
+// ค่าเริ่มต้นและค่าสูงสุดของจำนวนรายการต่อหน้าของ endpoint แบบ list (products, product options, interests)
+const (
+	defaultListPageSize = 50
+	maxListPageSize     = 200
+)
+
+// ค่าเริ่มต้นและค่าสูงสุดของรัศมี (กิโลเมตร) ในการค้นหาสาขาใกล้พิกัดที่ระบุ
+const (
+	defaultSearchRadiusKm = 10
+	maxSearchRadiusKm     = 1000
+)
+
 // HTTPHandler เก็บ dependency ที่จำเป็นสำหรับ handler ซึ่งก็คือ BranchService
 type HTTPHandler struct {
 	branchService        ports.BranchService
+	branchSearcher       ports.BranchSearcher
 	interestService      ports.InterestService
 	productService       ports.ProductService
 	productOptionService ports.ProductOptionService
 }
 
 // NewHTTPHandler คือ factory function สำหรับสร้าง HTTPHandler
-func NewHTTPHandler(branchSvc ports.BranchService, interestSvc ports.InterestService, productSvc ports.ProductService, productOptionSvc ports.ProductOptionService) *HTTPHandler {
+func NewHTTPHandler(branchSvc ports.BranchService, branchSearcher ports.BranchSearcher, interestSvc ports.InterestService, productSvc ports.ProductService, productOptionSvc ports.ProductOptionService) *HTTPHandler {
 	return &HTTPHandler{
 		branchService:        branchSvc,
+		branchSearcher:       branchSearcher,
 		interestService:      interestSvc,
 		productService:       productSvc,
 		productOptionService: productOptionSvc,
@@ -52,17 +87,15 @@ func (h *HTTPHandler) CreateBranch(c *gin.Context) {
 
 	// 1. แปลง JSON body ที่ส่งมาให้เป็น struct และตรวจสอบความถูกต้อง
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		badRequest(c, err.Error())
 		return
 	}
 
 	// 2. เรียกใช้ service เพื่อทำงานตาม business logic
-	branch, err := h.branchService.CreateBranchWithProducts(c.Request.Context(), req.Name, req.ProductIDs)
+	branch, err := h.branchService.CreateBranchWithProducts(c.Request.Context(), req.Name, req.ProductIDs, req.InterestIDs)
 	if err != nil {
-		// Log error ฉบับเต็มไว้สำหรับนักพัฒนา
-		log.Printf("Error creating branch: %v", err)
-		// ส่งข้อความที่เป็นกลางกลับไปให้ client
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "An internal server error occurred"})
+		// แปลง error เป็น status ที่ถูกต้อง (เช่น 422 ถ้าอ้างถึงสินค้าที่ไม่มีอยู่) ดู respondError
+		respondError(c, "creating branch", err)
 		return
 	}
 
@@ -74,38 +107,236 @@ func (h *HTTPHandler) CreateBranch(c *gin.Context) {
 func (h *HTTPHandler) GetBranch(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid branch ID"})
+		badRequest(c, "Invalid branch ID")
 		return
 	}
 
 	branch, err := h.branchService.GetBranch(c.Request.Context(), id)
 	if err != nil {
-		log.Printf("Error getting branch: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "An internal server error occurred"})
+		respondError(c, "getting branch", err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"data": branch})
 }
 
+// ListBranches คืนรายการสาขาทีละหน้าจาก MySQL (แหล่งข้อมูลจริง) สำหรับเครื่องมือของผู้ดูแลระบบ
+// Query parameters:
+//   - province_id: ID ของจังหวัด
+//   - product_ids, interest_ids: รายการ ID คั่นด้วย comma หรือส่งซ้ำหลายครั้ง
+//   - updated_after, updated_before: ช่วงของ updated_at (RFC3339 หรือ YYYY-MM-DD เวลา 00:00 UTC)
+//   - sort: id (ค่าเริ่มต้น), name หรือ updated_at และ order: asc (ค่าเริ่มต้น) หรือ desc
+//   - cursor: ค่า next_cursor ของหน้าก่อน (ต้องใช้ sort และ order เดียวกับหน้าก่อน)
+//   - limit: จำนวนสาขาต่อหน้า
+func (h *HTTPHandler) ListBranches(c *gin.Context) {
+	query := domain.BranchListQuery{Sort: domain.BranchSortID, Limit: defaultListPageSize}
+
+	var err error
+	if v := c.Query("province_id"); v != "" {
+		provinceID, err := strconv.Atoi(v)
+		if err != nil {
+			badRequest(c, "Invalid province_id")
+			return
+		}
+		query.Filter.ProvinceID = &provinceID
+	}
+	if query.Filter.ProductIDs, err = parseIntList(c.QueryArray("product_ids")); err != nil {
+		badRequest(c, "Invalid product_ids")
+		return
+	}
+	if query.Filter.InterestIDs, err = parseIntList(c.QueryArray("interest_ids")); err != nil {
+		badRequest(c, "Invalid interest_ids")
+		return
+	}
+	if query.Filter.UpdatedAfter, err = domain.ParseTime(c.Query("updated_after")); err != nil {
+		badRequest(c, "Invalid updated_after")
+		return
+	}
+	if query.Filter.UpdatedBefore, err = domain.ParseTime(c.Query("updated_before")); err != nil {
+		badRequest(c, "Invalid updated_before")
+		return
+	}
+
+	if v := c.Query("sort"); v != "" {
+		if v != domain.BranchSortID && v != domain.BranchSortName && v != domain.BranchSortUpdatedAt {
+			badRequest(c, "sort must be one of id, name, updated_at")
+			return
+		}
+		query.Sort = v
+	}
+	switch c.DefaultQuery("order", "asc") {
+	case "asc":
+	case "desc":
+		query.Desc = true
+	default:
+		badRequest(c, "order must be asc or desc")
+		return
+	}
+	if v := c.Query("cursor"); v != "" {
+		if query.After, err = domain.DecodeBranchCursor(v); err != nil {
+			badRequest(c, "Invalid cursor")
+			return
+		}
+		if !query.After.Matches(query) {
+			badRequest(c, "cursor was created with a different sort or order")
+			return
+		}
+	}
+	if v := c.Query("limit"); v != "" {
+		if query.Limit, err = strconv.Atoi(v); err != nil || query.Limit < 1 || query.Limit > maxListPageSize {
+			badRequest(c, fmt.Sprintf("limit must be between 1 and %d", maxListPageSize))
+			return
+		}
+	}
+
+	page, err := h.branchService.ListBranches(c.Request.Context(), query)
+	if err != nil {
+		respondError(c, "listing branches", err)
+		return
+	}
+
+	// next_cursor มีเฉพาะเมื่อยังมีหน้าถัดไป
+	response := gin.H{"data": page.Branches}
+	if page.Next != nil {
+		response["next_cursor"] = page.Next.Encode()
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// SearchBranches คือ handler สำหรับค้นหาสาขาจาก Elasticsearch
+// Query parameters:
+//   - q: ข้อความค้นหาในชื่อสาขา (name.th, name.en)
+//   - product_ids, interest_ids: รายการ ID คั่นด้วย comma หรือส่งซ้ำหลายครั้ง
+//   - province_id: ID ของจังหวัด
+//   - page, page_size: การแบ่งหน้า (page เริ่มที่ 1)
+//   - lat, lon, distance_km: เฉพาะสาขาในรัศมี distance_km (ค่าเริ่มต้น 10) จากพิกัดนี้ เรียงจากใกล้ไปไกล
+func (h *HTTPHandler) SearchBranches(c *gin.Context) {
+	query := domain.BranchSearchQuery{
+		Text:     strings.TrimSpace(c.Query("q")),
+		Page:     1,
+		PageSize: defaultSearchPageSize,
+	}
+
+	var err error
+	if query.ProductIDs, err = parseIntList(c.QueryArray("product_ids")); err != nil {
+		badRequest(c, "Invalid product_ids")
+		return
+	}
+	if query.InterestIDs, err = parseIntList(c.QueryArray("interest_ids")); err != nil {
+		badRequest(c, "Invalid interest_ids")
+		return
+	}
+	if v := c.Query("province_id"); v != "" {
+		provinceID, err := strconv.Atoi(v)
+		if err != nil {
+			badRequest(c, "Invalid province_id")
+			return
+		}
+		query.ProvinceID = &provinceID
+	}
+	if v := c.Query("page"); v != "" {
+		if query.Page, err = strconv.Atoi(v); err != nil || query.Page < 1 {
+			badRequest(c, "Invalid page")
+			return
+		}
+	}
+	if v := c.Query("page_size"); v != "" {
+		if query.PageSize, err = strconv.Atoi(v); err != nil || query.PageSize < 1 || query.PageSize > maxSearchPageSize {
+			badRequest(c, fmt.Sprintf("page_size must be between 1 and %d", maxSearchPageSize))
+			return
+		}
+	}
+	// ตรวจก่อนคูณ page กับ page_size เพื่อไม่ให้ค่า page ที่ใหญ่มากทำให้ผลคูณ overflow
+	if query.Page > maxSearchWindow/query.PageSize {
+		badRequest(c, fmt.Sprintf("page with page_size %d must be at most %d", query.PageSize, maxSearchWindow/query.PageSize))
+		return
+	}
+
+	if lat, lon := c.Query("lat"), c.Query("lon"); lat != "" || lon != "" {
+		point, err := parseGeoPoint(lat, lon)
+		if err != nil {
+			badRequest(c, err.Error())
+			return
+		}
+		query.Near = point
+		query.RadiusKm = defaultSearchRadiusKm
+		if v := c.Query("distance_km"); v != "" {
+			if query.RadiusKm, err = strconv.ParseFloat(v, 64); err != nil || query.RadiusKm <= 0 || query.RadiusKm > maxSearchRadiusKm {
+				badRequest(c, fmt.Sprintf("distance_km must be greater than 0 and at most %d", maxSearchRadiusKm))
+				return
+			}
+		}
+	} else if c.Query("distance_km") != "" {
+		badRequest(c, "distance_km requires lat and lon")
+		return
+	}
+
+	result, err := h.branchSearcher.SearchBranches(c.Request.Context(), query)
+	if err != nil {
+		respondError(c, "searching branches", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":      result.Branches,
+		"total":     result.Total,
+		"page":      query.Page,
+		"page_size": query.PageSize,
+	})
+}
+
+// parseGeoPoint แปลง query parameter lat และ lon (ต้องมีทั้งคู่) เป็น domain.GeoPoint
+func parseGeoPoint(lat, lon string) (*domain.GeoPoint, error) {
+	if lat == "" || lon == "" {
+		return nil, fmt.Errorf("lat and lon must be given together")
+	}
+	point := &domain.GeoPoint{}
+	var err error
+	if point.Lat, err = strconv.ParseFloat(lat, 64); err != nil || point.Lat < -90 || point.Lat > 90 {
+		return nil, fmt.Errorf("lat must be between -90 and 90")
+	}
+	if point.Lon, err = strconv.ParseFloat(lon, 64); err != nil || point.Lon < -180 || point.Lon > 180 {
+		return nil, fmt.Errorf("lon must be between -180 and 180")
+	}
+	return point, nil
+}
+
+// parseIntList แปลงค่าของ query parameter ที่อาจคั่นด้วย comma หรือส่งซ้ำหลายครั้ง (เช่น ?ids=1,2&ids=3) เป็น []int
+func parseIntList(values []string) ([]int, error) {
+	var ids []int
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			id, err := strconv.Atoi(part)
+			if err != nil {
+				return nil, err
+			}
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
 // UpdateBranch คือ handler สำหรับอัปเดตข้อมูลสาขา
 func (h *HTTPHandler) UpdateBranch(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid branch ID"})
+		badRequest(c, "Invalid branch ID")
 		return
 	}
 
 	var req CreateBranchRequest // ใช้ struct เดียวกับ Create
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		badRequest(c, err.Error())
 		return
 	}
 
-	branch, err := h.branchService.UpdateBranchWithProducts(c.Request.Context(), id, req.Name, req.ProductIDs)
+	branch, err := h.branchService.UpdateBranchWithProducts(c.Request.Context(), id, req.Name, req.ProductIDs, req.InterestIDs)
 	if err != nil {
-		log.Printf("Error updating branch: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "An internal server error occurred"})
+		respondError(c, "updating branch", err)
 		return
 	}
 
@@ -116,38 +347,207 @@ func (h *HTTPHandler) UpdateBranch(c *gin.Context) {
 func (h *HTTPHandler) DeleteBranch(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid branch ID"})
+		badRequest(c, "Invalid branch ID")
 		return
 	}
 
 	err = h.branchService.DeleteBranch(c.Request.Context(), id)
 	if err != nil {
-		log.Printf("Error deleting branch: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "An internal server error occurred"})
+		respondError(c, "deleting branch", err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Branch deleted successfully"})
 }
 
+// GetBranchLocation คือ handler สำหรับดึงที่ตั้งของสาขา (GET /branches/:id/location)
+func (h *HTTPHandler) GetBranchLocation(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		badRequest(c, "Invalid branch ID")
+		return
+	}
+
+	location, err := h.branchService.GetLocation(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, "getting branch location", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": location})
+}
+
+// PutBranchLocation คือ handler สำหรับสร้างหรือแทนที่ที่ตั้งของสาขา (PUT /branches/:id/location)
+// คืนข้อมูลสาขาฉบับล่าสุด
+func (h *HTTPHandler) PutBranchLocation(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		badRequest(c, "Invalid branch ID")
+		return
+	}
+
+	var req BranchLocationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+
+	branch, err := h.branchService.SetLocation(c.Request.Context(), id, domain.BranchLocation{
+		ProvinceID:  req.ProvinceID,
+		Name:        req.Name,
+		Address:     strings.TrimSpace(req.Address),
+		Coordinates: req.Coordinates,
+	})
+	if err != nil {
+		respondError(c, "setting branch location", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": branch})
+}
+
+// DeleteBranchLocation คือ handler สำหรับลบที่ตั้งของสาขา (DELETE /branches/:id/location)
+func (h *HTTPHandler) DeleteBranchLocation(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		badRequest(c, "Invalid branch ID")
+		return
+	}
+
+	branch, err := h.branchService.DeleteLocation(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, "deleting branch location", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": branch})
+}
+
+// LinkBranchInterest คือ handler สำหรับเชื่อมโยงความสนใจกับสาขา (POST /branches/:id/interests/:interestId)
+func (h *HTTPHandler) LinkBranchInterest(c *gin.Context) {
+	h.changeBranchInterest(c, "linking interest to branch", h.branchService.LinkInterest)
+}
+
+// UnlinkBranchInterest คือ handler สำหรับลบการเชื่อมโยงความสนใจออกจากสาขา (DELETE /branches/:id/interests/:interestId)
+func (h *HTTPHandler) UnlinkBranchInterest(c *gin.Context) {
+	h.changeBranchInterest(c, "unlinking interest from branch", h.branchService.UnlinkInterest)
+}
+
+func (h *HTTPHandler) changeBranchInterest(c *gin.Context, action string, change func(ctx context.Context, branchID, interestID int64) (*domain.Branch, error)) {
+	branchID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		badRequest(c, "Invalid branch ID")
+		return
+	}
+	interestID, err := strconv.ParseInt(c.Param("interestId"), 10, 64)
+	if err != nil {
+		badRequest(c, "Invalid interest ID")
+		return
+	}
+
+	branch, err := change(c.Request.Context(), branchID, interestID)
+	if err != nil {
+		respondError(c, action, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": branch})
+}
+
+// parsePageQuery อ่าน query parameter after_id และ limit ของ endpoint แบบ list
+// ถ้าค่าไม่ถูกต้องจะตอบ 400 และคืน false
+func parsePageQuery(c *gin.Context) (domain.PageQuery, bool) {
+	page := domain.PageQuery{Limit: defaultListPageSize}
+	var err error
+	if v := c.Query("after_id"); v != "" {
+		if page.AfterID, err = strconv.ParseInt(v, 10, 64); err != nil || page.AfterID < 0 {
+			badRequest(c, "Invalid after_id")
+			return page, false
+		}
+	}
+	if v := c.Query("limit"); v != "" {
+		if page.Limit, err = strconv.Atoi(v); err != nil || page.Limit < 1 || page.Limit > maxListPageSize {
+			badRequest(c, fmt.Sprintf("limit must be between 1 and %d", maxListPageSize))
+			return page, false
+		}
+	}
+	return page, true
+}
+
+// pageResponse คือ body ของรายการหนึ่งหน้า ใส่ next_after_id เฉพาะเมื่อมีหน้าถัดไป (nextAfterID ไม่เป็น 0)
+func pageResponse(data interface{}, nextAfterID int64) gin.H {
+	response := gin.H{"data": data}
+	if nextAfterID > 0 {
+		response["next_after_id"] = nextAfterID
+	}
+	return response
+}
+
 // --- Interest Handlers ---
 
+func (h *HTTPHandler) CreateInterest(c *gin.Context) {
+	var req UpdateNameRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+
+	interest, err := h.interestService.CreateInterest(c.Request.Context(), req.Name)
+	if err != nil {
+		respondError(c, "creating interest", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": interest})
+}
+
+func (h *HTTPHandler) GetInterest(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		badRequest(c, "Invalid interest ID")
+		return
+	}
+
+	interest, err := h.interestService.GetInterest(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, "getting interest", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": interest})
+}
+
+// ListInterests คืนความสนใจทีละหน้าเรียงตาม ID (query parameters: after_id, limit)
+func (h *HTTPHandler) ListInterests(c *gin.Context) {
+	page, ok := parsePageQuery(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.interestService.ListInterests(c.Request.Context(), page)
+	if err != nil {
+		respondError(c, "listing interests", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, pageResponse(result.Interests, result.NextAfterID))
+}
+
 func (h *HTTPHandler) UpdateInterest(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid interest ID"})
+		badRequest(c, "Invalid interest ID")
 		return
 	}
 
 	var req UpdateNameRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		badRequest(c, err.Error())
 		return
 	}
 
 	if err := h.interestService.UpdateInterest(c.Request.Context(), id, req.Name); err != nil {
-		log.Printf("Error updating interest: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "An internal server error occurred"})
+		respondError(c, "updating interest", err)
 		return
 	}
 
@@ -157,13 +557,12 @@ func (h *HTTPHandler) UpdateInterest(c *gin.Context) {
 func (h *HTTPHandler) DeleteInterest(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid interest ID"})
+		badRequest(c, "Invalid interest ID")
 		return
 	}
 
 	if err := h.interestService.DeleteInterest(c.Request.Context(), id); err != nil {
-		log.Printf("Error deleting interest: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "An internal server error occurred"})
+		respondError(c, "deleting interest", err)
 		return
 	}
 
@@ -172,22 +571,69 @@ func (h *HTTPHandler) DeleteInterest(c *gin.Context) {
 
 // --- Product Handlers ---
 
+func (h *HTTPHandler) CreateProduct(c *gin.Context) {
+	var req UpdateNameRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+
+	product, err := h.productService.CreateProduct(c.Request.Context(), req.Name)
+	if err != nil {
+		respondError(c, "creating product", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": product})
+}
+
+func (h *HTTPHandler) GetProduct(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		badRequest(c, "Invalid product ID")
+		return
+	}
+
+	product, err := h.productService.GetProduct(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, "getting product", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": product})
+}
+
+// ListProducts คืนสินค้าทีละหน้าเรียงตาม ID (query parameters: after_id, limit)
+func (h *HTTPHandler) ListProducts(c *gin.Context) {
+	page, ok := parsePageQuery(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.productService.ListProducts(c.Request.Context(), page)
+	if err != nil {
+		respondError(c, "listing products", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, pageResponse(result.Products, result.NextAfterID))
+}
+
 func (h *HTTPHandler) UpdateProduct(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		badRequest(c, "Invalid product ID")
 		return
 	}
 
 	var req UpdateNameRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		badRequest(c, err.Error())
 		return
 	}
 
 	if err := h.productService.UpdateProduct(c.Request.Context(), id, req.Name); err != nil {
-		log.Printf("Error updating product: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "An internal server error occurred"})
+		respondError(c, "updating product", err)
 		return
 	}
 
@@ -197,13 +643,12 @@ func (h *HTTPHandler) UpdateProduct(c *gin.Context) {
 func (h *HTTPHandler) DeleteProduct(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		badRequest(c, "Invalid product ID")
 		return
 	}
 
 	if err := h.productService.DeleteProduct(c.Request.Context(), id); err != nil {
-		log.Printf("Error deleting product: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "An internal server error occurred"})
+		respondError(c, "deleting product", err)
 		return
 	}
 
@@ -212,22 +657,82 @@ func (h *HTTPHandler) DeleteProduct(c *gin.Context) {
 
 // --- Product Option Handlers ---
 
+// CreateProductOption คือ handler สำหรับสร้างตัวเลือกราคาภายใต้สินค้า (POST /products/:id/options)
+func (h *HTTPHandler) CreateProductOption(c *gin.Context) {
+	productID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		badRequest(c, "Invalid product ID")
+		return
+	}
+
+	var req UpdateProductOptionRequest // ใช้ struct เดียวกับ Update
+	if err := c.ShouldBindJSON(&req); err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+
+	option, err := h.productOptionService.CreateProductOption(c.Request.Context(), productID, req.NormalPrice, req.TagthaiPrice)
+	if err != nil {
+		respondError(c, "creating product option", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": option})
+}
+
+func (h *HTTPHandler) GetProductOption(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		badRequest(c, "Invalid product option ID")
+		return
+	}
+
+	option, err := h.productOptionService.GetProductOption(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, "getting product option", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": option})
+}
+
+// ListProductOptions คืนตัวเลือกราคาของสินค้าทีละหน้าเรียงตาม ID (GET /products/:id/options, query parameters: after_id, limit)
+// ตอบ 404 ถ้าไม่มีสินค้านี้
+func (h *HTTPHandler) ListProductOptions(c *gin.Context) {
+	productID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		badRequest(c, "Invalid product ID")
+		return
+	}
+	page, ok := parsePageQuery(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.productOptionService.ListProductOptions(c.Request.Context(), productID, page)
+	if err != nil {
+		respondError(c, "listing product options", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, pageResponse(result.Options, result.NextAfterID))
+}
+
 func (h *HTTPHandler) UpdateProductOption(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product option ID"})
+		badRequest(c, "Invalid product option ID")
 		return
 	}
 
 	var req UpdateProductOptionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		badRequest(c, err.Error())
 		return
 	}
 
 	if err := h.productOptionService.UpdateProductOption(c.Request.Context(), id, req.NormalPrice, req.TagthaiPrice); err != nil {
-		log.Printf("Error updating product option: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "An internal server error occurred"})
+		respondError(c, "updating product option", err)
 		return
 	}
 
@@ -237,13 +742,12 @@ func (h *HTTPHandler) UpdateProductOption(c *gin.Context) {
 func (h *HTTPHandler) DeleteProductOption(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product option ID"})
+		badRequest(c, "Invalid product option ID")
 		return
 	}
 
 	if err := h.productOptionService.DeleteProductOption(c.Request.Context(), id); err != nil {
-		log.Printf("Error deleting product option: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "An internal server error occurred"})
+		respondError(c, "deleting product option", err)
 		return
 	}
 
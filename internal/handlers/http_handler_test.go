@@ -0,0 +1,415 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"ES/internal/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchBranches_ParsesQueryParameters(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	searcher := &mockBranchSearcher{
+		result: &domain.BranchSearchResult{
+			Total:    1,
+			Branches: []*domain.BranchSearchHit{{Branch: &domain.Branch{ID: 3, Name: domain.BranchNameJSON{EN: "Chiang Mai Branch", TH: "สาขา เชียงใหม่"}}}},
+		},
+	}
+	handler := NewHTTPHandler(nil, searcher, nil, nil, nil)
+	router := gin.New()
+	router.GET("/branches/search", handler.SearchBranches)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/branches/search?q=%E0%B9%80%E0%B8%8A%E0%B8%B5%E0%B8%A2%E0%B8%87%E0%B9%83%E0%B8%AB%E0%B8%A1%E0%B9%88&product_ids=2,3&product_ids=4&interest_ids=5&province_id=30&page=2&page_size=10", nil)
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, domain.BranchSearchQuery{
+		Text:        "เชียงใหม่",
+		ProductIDs:  []int{2, 3, 4},
+		InterestIDs: []int{5},
+		ProvinceID:  intPtr(30),
+		Page:        2,
+		PageSize:    10,
+	}, searcher.query)
+
+	var body struct {
+		Data  []domain.Branch `json:"data"`
+		Total int64           `json:"total"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, int64(1), body.Total)
+	require.Len(t, body.Data, 1)
+	assert.Equal(t, int64(3), body.Data[0].ID)
+}
+
+func TestSearchBranches_RejectsInvalidParameters(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewHTTPHandler(nil, &mockBranchSearcher{}, nil, nil, nil)
+	router := gin.New()
+	router.GET("/branches/search", handler.SearchBranches)
+
+	for _, query := range []string{
+		"product_ids=1,x", "province_id=abc", "page=0", "page_size=1000",
+		"page=101&page_size=100", "page=501", "page=9223372036854775807&page_size=100",
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/branches/search?"+query, nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
+func TestSearchBranches_ParsesGeoDistance(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	distance := 1.25
+	searcher := &mockBranchSearcher{result: &domain.BranchSearchResult{
+		Total:    1,
+		Branches: []*domain.BranchSearchHit{{Branch: &domain.Branch{ID: 3}, DistanceKm: &distance}},
+	}}
+	handler := NewHTTPHandler(nil, searcher, nil, nil, nil)
+	router := gin.New()
+	router.GET("/branches/search", handler.SearchBranches)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/branches/search?lat=13.7466&lon=100.5393&distance_km=2.5", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, &domain.GeoPoint{Lat: 13.7466, Lon: 100.5393}, searcher.query.Near)
+	assert.Equal(t, 2.5, searcher.query.RadiusKm)
+	// distance_km อยู่ระดับเดียวกับ field ของสาขาใน response
+	assert.Contains(t, w.Body.String(), `{"id":3,"name":{"en":"","th":""},"distance_km":1.25}`)
+
+	// ไม่ระบุ distance_km ใช้รัศมีเริ่มต้น
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/branches/search?lat=13.7466&lon=100.5393", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, float64(defaultSearchRadiusKm), searcher.query.RadiusKm)
+
+	for _, query := range []string{"lat=13.7", "lat=91&lon=100", "lat=13&lon=x", "lat=13&lon=100&distance_km=0", "distance_km=5"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/branches/search?"+query, nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
+func TestPutBranchLocation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	svc := &mockBranchService{}
+	handler := NewHTTPHandler(svc, nil, nil, nil, nil)
+	router := gin.New()
+	router.PUT("/branches/:id/location", handler.PutBranchLocation)
+
+	body := `{"province_id":10,"name":{"en":"Central World","th":"เซ็นทรัลเวิลด์"},"address":" 999/9 Rama I Rd ","coordinates":{"lat":13.746571,"lon":100.539302}}`
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/branches/1/location", strings.NewReader(body)))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, domain.BranchLocation{
+		ProvinceID:  10,
+		Name:        &domain.BranchNameJSON{EN: "Central World", TH: "เซ็นทรัลเวิลด์"},
+		Address:     "999/9 Rama I Rd",
+		Coordinates: &domain.GeoPoint{Lat: 13.746571, Lon: 100.539302},
+	}, svc.location)
+
+	// พิกัดที่อยู่นอกช่วงถูกปฏิเสธด้วย 422 พร้อมชื่อ field
+	svc.err = domain.BranchLocation{ProvinceID: 10, Coordinates: &domain.GeoPoint{Lat: 200}}.Validate()
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/branches/1/location", strings.NewReader(`{"province_id":10,"coordinates":{"lat":200,"lon":0}}`)))
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), `"field":"coordinates.lat"`)
+}
+
+func TestBranchHandlers_MapDomainErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	svc := &mockBranchService{}
+	handler := NewHTTPHandler(svc, nil, nil, nil, nil)
+	router := gin.New()
+	router.GET("/branches/:id", handler.GetBranch)
+	router.POST("/branches", handler.CreateBranch)
+	router.DELETE("/branches/:id", handler.DeleteBranch)
+
+	svc.err = domain.NewNotFoundError("branch", 42)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/branches/42", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.JSONEq(t, `{"error":"branch with id 42 not found","code":"not_found"}`, w.Body.String())
+
+	svc.err = domain.NewValidationError("product_id", "refers to a product that does not exist")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/branches", strings.NewReader(`{"name":{"en":"A","th":"ก"},"product_ids":[999]}`)))
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	var body ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, codeValidationFailed, body.Code)
+	assert.Equal(t, []domain.FieldError{{Field: "product_id", Message: "refers to a product that does not exist"}}, body.Fields)
+
+	svc.err = fmt.Errorf("failed to delete branch in transaction: %w", &domain.ConflictError{Resource: "branch", Message: "is still referenced by branch_location"})
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/branches/1", nil))
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// error ที่ไม่รู้จักไม่เปิดเผยรายละเอียดภายใน
+	svc.err = errors.New("dial tcp 10.0.0.1:3306: connection refused")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/branches/1", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.NotContains(t, w.Body.String(), "10.0.0.1")
+}
+
+func TestListProducts_Paginates(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	svc := &mockProductService{products: []*domain.Product{{ID: 3}, {ID: 7}, {ID: 9}}}
+	handler := NewHTTPHandler(nil, nil, nil, svc, nil)
+	router := gin.New()
+	router.GET("/products/", handler.ListProducts)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/products/?after_id=2&limit=2", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, domain.PageQuery{AfterID: 2, Limit: 2}, svc.page)
+	// ยังมีสินค้าหลังหน้านี้จึงมี cursor ของหน้าถัดไป
+	assert.Contains(t, w.Body.String(), `"next_after_id":7`)
+
+	// หน้าสุดท้ายไม่มี cursor แม้ว่าหน้าจะเต็มพอดี
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/products/?limit=3", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "next_after_id")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/products/", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, defaultListPageSize, svc.page.Limit)
+	assert.NotContains(t, w.Body.String(), "next_after_id")
+
+	for _, query := range []string{"after_id=x", "limit=0", "limit=1000"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/products/?"+query, nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
+func TestListBranches_ParsesQueryAndPaginates(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	updatedAt := time.Date(2025, 1, 15, 8, 30, 0, 0, time.UTC)
+	next := &domain.BranchCursor{Sort: domain.BranchSortName, Desc: true, ID: 2, Name: "ก", UpdatedAt: &updatedAt}
+	svc := &mockBranchService{page: &domain.BranchPage{
+		Branches: []*domain.Branch{
+			{ID: 4, Name: domain.BranchNameJSON{TH: "ข"}},
+			{ID: 2, Name: domain.BranchNameJSON{TH: "ก"}, UpdatedAt: &updatedAt},
+		},
+		Next: next,
+	}}
+	handler := NewHTTPHandler(svc, nil, nil, nil, nil)
+	router := gin.New()
+	router.GET("/branches/", handler.ListBranches)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/branches/?province_id=10&product_ids=5,6&interest_ids=3&updated_after=2025-01-01&updated_before=2025-02-01&sort=name&order=desc&limit=2", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	provinceID := 10
+	after := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	before := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, domain.BranchListQuery{
+		Filter: domain.BranchFilter{
+			ProvinceID:    &provinceID,
+			ProductIDs:    []int{5, 6},
+			InterestIDs:   []int{3},
+			UpdatedAfter:  &after,
+			UpdatedBefore: &before,
+		},
+		Sort:  domain.BranchSortName,
+		Desc:  true,
+		Limit: 2,
+	}, svc.listQuery)
+
+	// ยังมีหน้าถัดไปจึงมี cursor
+	var body struct {
+		NextCursor string `json:"next_cursor"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	require.NotEmpty(t, body.NextCursor)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/branches/?sort=name&order=desc&limit=2&cursor="+body.NextCursor, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, next, svc.listQuery.After)
+
+	// cursor ใช้กับการเรียงแบบอื่นไม่ได้
+	for _, query := range []string{
+		"cursor=" + body.NextCursor,
+		"sort=name&cursor=" + body.NextCursor,
+		"cursor=not-a-cursor",
+		"sort=province",
+		"order=up",
+		"province_id=x",
+		"product_ids=a",
+		"updated_before=yesterday",
+		"limit=0",
+		"limit=1000",
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/branches/?"+query, nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+
+	// หน้าสุดท้ายไม่มี cursor แม้จะมีสาขาครบ limit
+	svc.page = &domain.BranchPage{Branches: []*domain.Branch{{ID: 1}, {ID: 2}}}
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/branches/?limit=2", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "next_cursor")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/branches/", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, domain.BranchListQuery{Sort: domain.BranchSortID, Limit: defaultListPageSize}, svc.listQuery)
+	assert.NotContains(t, w.Body.String(), "next_cursor")
+}
+
+func TestCreateProduct(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	svc := &mockProductService{}
+	handler := NewHTTPHandler(nil, nil, nil, svc, nil)
+	router := gin.New()
+	router.POST("/products/", handler.CreateProduct)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/products/", strings.NewReader(`{"name":{"en":"Product K","th":"สินค้า K"}}`)))
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"en":"Product K"`)
+
+	// ValidationError จาก service ถูกแปลงเป็น 422 พร้อมรายละเอียดของ field
+	svc.err = domain.NewValidationError("name", "must have a th or en value")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/products/", strings.NewReader(`{"name":{}}`)))
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), `"field":"name"`)
+}
+
+// Mock ProductService
+type mockProductService struct {
+	err      error
+	products []*domain.Product
+	page     domain.PageQuery
+}
+
+func (m *mockProductService) CreateProduct(ctx context.Context, name domain.BranchNameJSON) (*domain.Product, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &domain.Product{ID: 11, Name: name}, nil
+}
+
+func (m *mockProductService) GetProduct(ctx context.Context, id int64) (*domain.Product, error) {
+	return nil, domain.NewNotFoundError("product", id)
+}
+
+func (m *mockProductService) ListProducts(ctx context.Context, page domain.PageQuery) (*domain.ProductPage, error) {
+	m.page = page
+	if len(m.products) > page.Limit {
+		return &domain.ProductPage{Products: m.products[:page.Limit], NextAfterID: m.products[page.Limit-1].ID}, nil
+	}
+	return &domain.ProductPage{Products: m.products}, nil
+}
+
+func (m *mockProductService) UpdateProduct(ctx context.Context, id int64, name domain.BranchNameJSON) error {
+	return nil
+}
+
+func (m *mockProductService) DeleteProduct(ctx context.Context, id int64) error {
+	return nil
+}
+
+// Mock BranchService
+type mockBranchService struct {
+	err       error
+	location  domain.BranchLocation
+	listQuery domain.BranchListQuery
+	page      *domain.BranchPage
+}
+
+func (m *mockBranchService) CreateBranchWithProducts(ctx context.Context, name domain.BranchNameJSON, productIDs, interestIDs []int) (*domain.Branch, error) {
+	return nil, m.err
+}
+
+func (m *mockBranchService) UpdateBranchWithProducts(ctx context.Context, id int64, name domain.BranchNameJSON, productIDs, interestIDs []int) (*domain.Branch, error) {
+	return nil, m.err
+}
+
+func (m *mockBranchService) DeleteBranch(ctx context.Context, id int64) error {
+	return m.err
+}
+
+func (m *mockBranchService) GetBranch(ctx context.Context, id int64) (*domain.Branch, error) {
+	return nil, m.err
+}
+
+func (m *mockBranchService) ListBranches(ctx context.Context, query domain.BranchListQuery) (*domain.BranchPage, error) {
+	m.listQuery = query
+	if m.err != nil {
+		return nil, m.err
+	}
+	if m.page == nil {
+		return &domain.BranchPage{}, nil
+	}
+	return m.page, nil
+}
+
+func (m *mockBranchService) LinkInterest(ctx context.Context, branchID, interestID int64) (*domain.Branch, error) {
+	return nil, m.err
+}
+
+func (m *mockBranchService) UnlinkInterest(ctx context.Context, branchID, interestID int64) (*domain.Branch, error) {
+	return nil, m.err
+}
+
+func (m *mockBranchService) GetLocation(ctx context.Context, branchID int64) (*domain.BranchLocation, error) {
+	return nil, m.err
+}
+
+func (m *mockBranchService) SetLocation(ctx context.Context, branchID int64, location domain.BranchLocation) (*domain.Branch, error) {
+	m.location = location
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &domain.Branch{ID: branchID, Location: &location}, nil
+}
+
+func (m *mockBranchService) DeleteLocation(ctx context.Context, branchID int64) (*domain.Branch, error) {
+	return nil, m.err
+}
+
+// Mock BranchSearcher
+type mockBranchSearcher struct {
+	query  domain.BranchSearchQuery
+	result *domain.BranchSearchResult
+}
+
+func (m *mockBranchSearcher) SearchBranches(ctx context.Context, query domain.BranchSearchQuery) (*domain.BranchSearchResult, error) {
+	m.query = query
+	if m.result == nil {
+		return &domain.BranchSearchResult{}, nil
+	}
+	return m.result, nil
+}
+
+func intPtr(v int) *int { return &v }
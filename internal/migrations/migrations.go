@@ -0,0 +1,99 @@
+// Package migrations เก็บ schema migration ของ MySQL แบบมีเวอร์ชัน ฝังไว้ใน binary ด้วย embed
+// และตัวรัน migration ที่บันทึกเวอร์ชันที่รันแล้วในตาราง schema_migrations (ใช้ผ่าน cmd/migrate)
+package migrations
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+//go:embed sql/*.sql
+var migrationFiles embed.FS
+
+//go:embed seed/*.sql
+var seedFiles embed.FS
+
+// Dir คือโฟลเดอร์ของไฟล์ migration ใน repository (ใช้โดย `migrate create`)
+const Dir = "internal/migrations/sql"
+
+// fileNamePattern คือรูปแบบชื่อไฟล์ migration เช่น 0002_add_outbox_trace_id.up.sql
+var fileNamePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration คือ migration หนึ่งเวอร์ชัน ประกอบด้วย SQL ของขา up และขา down
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Embedded คืน migration ทั้งหมดที่ฝังไว้ใน binary เรียงตามเวอร์ชัน
+func Embedded() ([]Migration, error) {
+	sub, err := fs.Sub(migrationFiles, "sql")
+	if err != nil {
+		return nil, err
+	}
+	return Load(sub)
+}
+
+// Load อ่านไฟล์ migration จากรากของ fsys และจับคู่ไฟล์ up/down ของแต่ละเวอร์ชัน
+// ทุกเวอร์ชันต้องมีทั้งสองไฟล์ และหนึ่งเวอร์ชันต้องมีชื่อเดียว
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+		m := fileNamePattern.FindStringSubmatch(entry.Name())
+		if m == nil {
+			return nil, fmt.Errorf("invalid migration file name %q, expected <version>_<name>.(up|down).sql", entry.Name())
+		}
+		version, err := strconv.ParseInt(m[1], 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration version in %q", entry.Name())
+		}
+		body, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = migration
+		} else if migration.Name != m[2] {
+			return nil, fmt.Errorf("migration version %d has two names: %s and %s", version, migration.Name, m[2])
+		}
+		if m[3] == "up" {
+			migration.Up = string(body)
+		} else {
+			migration.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s must have both an up and a down file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// FileNames คืนชื่อไฟล์ up และ down ของ migration เวอร์ชัน version
+func FileNames(version int64, name string) (up, down string) {
+	base := fmt.Sprintf("%04d_%s", version, name)
+	return base + ".up.sql", base + ".down.sql"
+}
@@ -0,0 +1,113 @@
+package migrations
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitStatements(t *testing.T) {
+	script := `-- comment; not a statement
+CREATE TABLE t (id int); # trailing comment;
+INSERT INTO t VALUES (1,'a;b'),(2,'it''s'),(3,'{\"k\": \"v;\"}');
+/* block; comment */ UPDATE ` + "`we;ird`" + ` SET x = "y;z";
+;
+`
+	statements := SplitStatements(script)
+
+	assert.Equal(t, []string{
+		"CREATE TABLE t (id int)",
+		`INSERT INTO t VALUES (1,'a;b'),(2,'it''s'),(3,'{\"k\": \"v;\"}')`,
+		"UPDATE `we;ird` SET x = \"y;z\"",
+	}, statements)
+}
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0002_add_column.up.sql":   {Data: []byte("ALTER TABLE t ADD c int;")},
+		"0002_add_column.down.sql": {Data: []byte("ALTER TABLE t DROP c;")},
+		"0001_baseline.up.sql":     {Data: []byte("CREATE TABLE t (id int);")},
+		"0001_baseline.down.sql":   {Data: []byte("DROP TABLE t;")},
+		"README.md":                {Data: []byte("ignored")},
+	}
+
+	migrations, err := Load(fsys)
+
+	require.NoError(t, err)
+	require.Len(t, migrations, 2)
+	assert.Equal(t, int64(1), migrations[0].Version)
+	assert.Equal(t, "baseline", migrations[0].Name)
+	assert.Equal(t, "DROP TABLE t;", migrations[0].Down)
+	assert.Equal(t, int64(2), migrations[1].Version)
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	cases := map[string]fstest.MapFS{
+		"missing down": {
+			"0001_baseline.up.sql": {Data: []byte("SELECT 1;")},
+		},
+		"bad name": {
+			"1-Baseline.up.sql": {Data: []byte("SELECT 1;")},
+		},
+		"two names for one version": {
+			"0001_a.up.sql":   {Data: []byte("SELECT 1;")},
+			"0001_a.down.sql": {Data: []byte("SELECT 1;")},
+			"0001_b.up.sql":   {Data: []byte("SELECT 1;")},
+			"0001_b.down.sql": {Data: []byte("SELECT 1;")},
+		},
+	}
+	for name, fsys := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := Load(fsys)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestEmbedded(t *testing.T) {
+	migrations, err := Embedded()
+
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+	assert.Equal(t, "baseline", migrations[0].Name)
+	assert.NotEmpty(t, SplitStatements(migrations[0].Up))
+}
+
+func TestMigratorUp_SkipsAppliedAndRecordsNew(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	migrator := NewMigrator(db, []Migration{
+		{Version: 1, Name: "baseline", Up: "CREATE TABLE a (id int);", Down: "DROP TABLE a;"},
+		{Version: 2, Name: "second", Up: "ALTER TABLE a ADD b int; ALTER TABLE a ADD c int;", Down: "SELECT 1;"},
+		{Version: 3, Name: "third", Up: "SELECT 3;", Down: "SELECT 1;"},
+	})
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT GET_LOCK(?, ?)")).
+		WithArgs(lockName, lockTimeoutSeconds).
+		WillReturnRows(sqlmock.NewRows([]string{"lock"}).AddRow(1))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT version, name, applied_at FROM schema_migrations").
+		WillReturnRows(sqlmock.NewRows([]string{"version", "name", "applied_at"}).AddRow(1, "baseline", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)))
+	// เวอร์ชัน 1 รันแล้ว จึงเริ่มที่เวอร์ชัน 2 และหยุดหลังจากรันครบ 1 step
+	mock.ExpectExec(regexp.QuoteMeta("ALTER TABLE a ADD b int")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("ALTER TABLE a ADD c int")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO schema_migrations (version, name) VALUES (?, ?)")).
+		WithArgs(int64(2), "second").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("SELECT RELEASE_LOCK(?)")).WithArgs(lockName).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	done, err := migrator.Up(context.Background(), 1)
+
+	require.NoError(t, err)
+	require.Len(t, done, 1)
+	assert.Equal(t, int64(2), done[0].Version)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
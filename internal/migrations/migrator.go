@@ -0,0 +1,235 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"log"
+	"sort"
+	"time"
+)
+
+// lockName คือชื่อ named lock ของ MySQL (GET_LOCK) ที่กันไม่ให้รัน migration พร้อมกันหลายตัว
+const lockName = "schema_migrations"
+
+// lockTimeoutSeconds คือเวลาที่รอ lock ก่อนยอมแพ้
+const lockTimeoutSeconds = 30
+
+const createTrackingTable = `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version BIGINT NOT NULL,
+		name VARCHAR(255) NOT NULL,
+		applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (version)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci`
+
+// Status คือสถานะของ migration หนึ่งเวอร์ชัน
+// Missing เป็นจริงเมื่อเวอร์ชันถูกบันทึกว่ารันแล้ว แต่ไม่มีไฟล์ migration ใน binary นี้
+type Status struct {
+	Version   int64
+	Name      string
+	AppliedAt *time.Time
+	Missing   bool
+}
+
+// Migrator รัน migration กับฐานข้อมูลและบันทึกผลในตาราง schema_migrations
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// NewMigrator คือ factory function สำหรับสร้าง Migrator จาก migration ที่เรียงตามเวอร์ชันแล้ว (ดู Embedded)
+func NewMigrator(db *sql.DB, migrations []Migration) *Migrator {
+	return &Migrator{db: db, migrations: migrations}
+}
+
+// Status คืนสถานะของทุก migration เรียงตามเวอร์ชัน
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var statuses []Status
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			s := Status{Version: migration.Version, Name: migration.Name}
+			if a, ok := applied[migration.Version]; ok {
+				s.AppliedAt = a.AppliedAt
+				delete(applied, migration.Version)
+			}
+			statuses = append(statuses, s)
+		}
+		for _, a := range applied {
+			a.Missing = true
+			statuses = append(statuses, a)
+		}
+		return nil
+	})
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, err
+}
+
+// Up รัน migration ที่ยังไม่ได้รันตามลำดับเวอร์ชัน ไม่เกิน steps เวอร์ชัน (steps <= 0 คือทั้งหมด)
+// คืนรายการ migration ที่รันสำเร็จ
+//
+// คำสั่ง DDL ของ MySQL commit ตัวเองโดยอัตโนมัติ จึงครอบ migration ด้วย transaction ไม่ได้
+// ถ้า migration ล้มเหลวกลางไฟล์ เวอร์ชันนั้นจะไม่ถูกบันทึก และต้องแก้ฐานข้อมูลให้กลับสู่สภาพเดิมก่อนรันใหม่
+func (m *Migrator) Up(ctx context.Context, steps int) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			if steps > 0 && len(done) == steps {
+				break
+			}
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			log.Printf("Applying migration %04d_%s", migration.Version, migration.Name)
+			if err := execScript(ctx, conn, migration.Up); err != nil {
+				return fmt.Errorf("migration %04d_%s up failed: %w", migration.Version, migration.Name, err)
+			}
+			if _, err := conn.ExecContext(ctx, "INSERT INTO schema_migrations (version, name) VALUES (?, ?)", migration.Version, migration.Name); err != nil {
+				return fmt.Errorf("failed to record migration %04d_%s: %w", migration.Version, migration.Name, err)
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down ย้อน migration ที่รันแล้วจากเวอร์ชันล่าสุดลงไป ไม่เกิน steps เวอร์ชัน (steps <= 0 คือทั้งหมด)
+// คืนรายการ migration ที่ย้อนสำเร็จ
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	byVersion := make(map[int64]Migration, len(m.migrations))
+	for _, migration := range m.migrations {
+		byVersion[migration.Version] = migration
+	}
+
+	var done []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+		versions := make([]int64, 0, len(applied))
+		for v := range applied {
+			versions = append(versions, v)
+		}
+		sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+
+		for _, version := range versions {
+			if steps > 0 && len(done) == steps {
+				break
+			}
+			migration, ok := byVersion[version]
+			if !ok {
+				return fmt.Errorf("migration %04d_%s is applied but has no file in this build; cannot roll it back", version, applied[version].Name)
+			}
+			log.Printf("Rolling back migration %04d_%s", migration.Version, migration.Name)
+			if err := execScript(ctx, conn, migration.Down); err != nil {
+				return fmt.Errorf("migration %04d_%s down failed: %w", migration.Version, migration.Name, err)
+			}
+			if _, err := conn.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = ?", migration.Version); err != nil {
+				return fmt.Errorf("failed to unrecord migration %04d_%s: %w", migration.Version, migration.Name, err)
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Seed ใส่ข้อมูลตัวอย่างจาก seed/*.sql (สำหรับเครื่องนักพัฒนาเท่านั้น) ต้องรัน Up ก่อน
+// ไฟล์ seed ใช้ INSERT IGNORE จึงรันซ้ำได้ คืนชื่อไฟล์ที่รันตามลำดับ
+func Seed(ctx context.Context, db *sql.DB) ([]string, error) {
+	names, err := fs.Glob(seedFiles, "seed/*.sql")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database connection: %w", err)
+	}
+	defer conn.Close()
+
+	for _, name := range names {
+		body, err := seedFiles.ReadFile(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read seed %s: %w", name, err)
+		}
+		if err := execScript(ctx, conn, string(body)); err != nil {
+			return nil, fmt.Errorf("seed %s failed: %w", name, err)
+		}
+	}
+	return names, nil
+}
+
+// withLock สร้างตาราง schema_migrations ถ้ายังไม่มี แล้วเรียก fn ขณะถือ named lock
+// ทุกคำสั่งต้องรันบน conn เดียวกัน เพราะ GET_LOCK ผูกกับ session
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get database connection: %w", err)
+	}
+	defer conn.Close()
+
+	var locked sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", lockName, lockTimeoutSeconds).Scan(&locked); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	if !locked.Valid || locked.Int64 != 1 {
+		return fmt.Errorf("timed out after %ds waiting for another migration to finish", lockTimeoutSeconds)
+	}
+	defer func() {
+		// ใช้ context ใหม่เพื่อให้ปล่อย lock ได้แม้ ctx ถูกยกเลิกไปแล้ว
+		if _, err := conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(?)", lockName); err != nil {
+			log.Printf("WARNING: Failed to release migration lock: %v", err)
+		}
+	}()
+
+	if _, err := conn.ExecContext(ctx, createTrackingTable); err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+	return fn(conn)
+}
+
+// appliedMigrations อ่านเวอร์ชันที่รันแล้วจาก schema_migrations
+func appliedMigrations(ctx context.Context, conn *sql.Conn) (map[int64]Status, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, name, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]Status)
+	for rows.Next() {
+		var (
+			s         Status
+			appliedAt time.Time
+		)
+		if err := rows.Scan(&s.Version, &s.Name, &appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan schema_migrations row: %w", err)
+		}
+		s.AppliedAt = &appliedAt
+		applied[s.Version] = s
+	}
+	return applied, rows.Err()
+}
+
+// execScript รันทุกคำสั่งในไฟล์ SQL ตามลำดับ
+func execScript(ctx context.Context, conn *sql.Conn, script string) error {
+	for i, stmt := range SplitStatements(script) {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("statement %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,10 @@
+-- ข้อมูลตัวอย่างสำหรับการพัฒนาในเครื่อง (เดิมอยู่ใน DATABASE_DUMP.sql)
+-- ใช้ INSERT IGNORE จึงรันซ้ำได้โดยไม่เกิด error และไม่ทับแถวที่มีอยู่แล้ว
+
+INSERT IGNORE INTO `branch` VALUES (1,'{\"en\": \"Bangkok Branch 1 (Updated)\", \"th\": \"สาขา กทม 1 (อัปเดตแล้ว)\"}','2025-11-25 08:05:47'),(2,'{\"en\": \"Bangkok Branch 2\", \"th\": \"สาขา กทม 2\"}','2025-11-19 06:47:03'),(3,'{\"en\": \"Chiang Mai Branch\", \"th\": \"สาขา เชียงใหม่\"}','2025-11-19 06:47:03'),(4,'{\"en\": \"Phuket Branch\", \"th\": \"สาขา ภูเก็ต\"}','2025-11-19 06:47:03'),(5,'{\"en\": \"Khon Kaen Branch\", \"th\": \"สาขา ขอนแก่น\"}','2025-11-19 06:47:03'),(6,'{\"en\": \"Nonthaburi Branch\", \"th\": \"สาขา นนทบุรี\"}','2025-11-19 06:47:03'),(7,'{\"en\": \"Nakhon Ratchasima Branch\", \"th\": \"สาขา นครราชสีมา\"}','2025-11-19 06:47:03'),(8,'{\"en\": \"Chonburi Branch\", \"th\": \"สาขา ชลบุรี\"}','2025-11-19 06:47:03'),(9,'{\"en\": \"Surat Thani Branch\", \"th\": \"สาขา สุราษฎร์ธานี\"}','2025-11-19 06:47:03'),(10,'{\"en\": \"Ubon Ratchathani Branch\", \"th\": \"สาขา อุบลราชธานี\"}','2025-11-19 06:47:03'),(13,'{\"en\": \"Bangkok Branch 1 (Updated)\", \"th\": \"สาขา กทม 1 (อัปเดตแล้ว)\"}','2025-11-21 09:26:35');
+INSERT IGNORE INTO `product` VALUES (1,'{\"en\": \"Product A\", \"th\": \"สินค้า A\"}','2025-11-19 06:44:18'),(2,'{\"en\": \"Product B\", \"th\": \"สินค้า B\"}','2025-11-19 06:44:18'),(3,'{\"en\": \"Product C\", \"th\": \"สินค้า C\"}','2025-11-19 06:44:18'),(4,'{\"en\": \"Product D\", \"th\": \"สินค้า D\"}','2025-11-19 06:44:18'),(5,'{\"en\": \"Product E\", \"th\": \"สินค้า E\"}','2025-11-19 06:44:18'),(6,'{\"en\": \"Product F\", \"th\": \"สินค้า F\"}','2025-11-19 06:44:18'),(7,'{\"en\": \"Product G\", \"th\": \"สินค้า G\"}','2025-11-19 06:44:18'),(8,'{\"en\": \"Product H\", \"th\": \"สินค้า H\"}','2025-11-19 06:44:18'),(9,'{\"en\": \"Product I\", \"th\": \"สินค้า I\"}','2025-11-19 06:44:18'),(10,'{\"en\": \"Product J\", \"th\": \"สินค้า J\"}','2025-11-19 06:44:18');
+INSERT IGNORE INTO `interest` VALUES (1,'{\"en\": \"Sports\", \"th\": \"กีฬา\"}'),(2,'{\"en\": \"Technology\", \"th\": \"เทคโนโลยี\"}'),(3,'{\"en\": \"Travel\", \"th\": \"ท่องเที่ยว\"}'),(4,'{\"en\": \"Food\", \"th\": \"อาหาร\"}'),(5,'{\"en\": \"Health\", \"th\": \"สุขภาพ\"}'),(6,'{\"en\": \"Music\", \"th\": \"ดนตรี\"}'),(7,'{\"en\": \"Books\", \"th\": \"หนังสือ\"}'),(8,'{\"en\": \"Games\", \"th\": \"เกม\"}'),(9,'{\"en\": \"Fashion\", \"th\": \"แฟชั่น\"}'),(10,'{\"en\": \"Pets\", \"th\": \"สัตว์เลี้ยง\"}');
+INSERT IGNORE INTO `branch_location` (`id`,`branch_id`,`province_id`,`latitude`,`longitude`) VALUES (1,1,10,13.756331,100.501765),(2,2,20,13.746571,100.539302),(3,3,30,18.788344,98.985300),(4,4,40,7.880448,98.392296),(5,5,50,16.432192,102.823621),(6,6,60,13.862115,100.514374),(7,7,70,14.979900,102.097771),(8,8,80,13.361143,100.984673),(9,9,90,9.138239,99.321748),(10,10,100,15.228697,104.856447);
+INSERT IGNORE INTO `branches_interests` VALUES (1,1),(9,1),(10,1),(1,2),(2,2),(10,2),(1,3),(2,3),(3,3),(2,4),(3,4),(4,4),(3,5),(4,5),(5,5),(4,6),(5,6),(6,6),(5,7),(6,7),(7,7),(6,8),(7,8),(8,8),(7,9),(8,9),(9,9),(8,10),(9,10),(10,10);
+INSERT IGNORE INTO `branches_products` VALUES (2,1),(2,2),(3,2),(3,3),(4,3),(4,4),(5,4),(1,5),(5,5),(6,5),(13,5),(1,6),(6,6),(7,6),(13,6),(1,7),(7,7),(8,7),(13,7),(8,8),(9,8),(13,8),(9,9),(10,9),(10,10);
+INSERT IGNORE INTO `product_option` VALUES (1,100,90,1),(2,150,120,1),(3,200,180,2),(4,250,210,2),(5,300,270,3),(6,350,300,3),(7,400,360,4),(8,450,390,4),(9,500,450,5),(10,550,480,5),(11,600,540,6),(12,650,570,6),(13,700,630,7),(14,750,660,7),(15,800,720,8),(16,850,750,8),(17,900,810,9),(18,950,840,9),(19,1000,900,10),(20,1100,990,10);
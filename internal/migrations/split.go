@@ -0,0 +1,67 @@
+package migrations
+
+import "strings"
+
+// SplitStatements แยกไฟล์ SQL ออกเป็นคำสั่งทีละคำสั่งตาม ';'
+// (driver ของ MySQL ไม่รันหลายคำสั่งใน Exec เดียวถ้าไม่ได้เปิด multiStatements)
+// ';' ที่อยู่ใน string, identifier ที่ครอบด้วย backtick หรือ comment จะไม่ถูกใช้เป็นตัวแบ่ง
+// comment แบบ --, # และ /* */ ถูกตัดทิ้ง ส่วนคำสั่งที่ว่างเปล่าจะไม่ถูกคืน
+func SplitStatements(script string) []string {
+	var (
+		statements []string
+		current    strings.Builder
+	)
+	flush := func() {
+		if stmt := strings.TrimSpace(current.String()); stmt != "" {
+			statements = append(statements, stmt)
+		}
+		current.Reset()
+	}
+
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			// คัดลอกทั้ง string/identifier รวมถึง escape (\') และ quote ซ้อน ('')
+			end := i + 1
+			for end < len(script) {
+				if script[end] == '\\' && c != '`' {
+					end += 2
+					continue
+				}
+				if script[end] == c {
+					if end+1 < len(script) && script[end+1] == c {
+						end += 2
+						continue
+					}
+					break
+				}
+				end++
+			}
+			if end >= len(script) {
+				end = len(script) - 1
+			}
+			current.WriteString(script[i : end+1])
+			i = end
+		case c == '-' && strings.HasPrefix(script[i:], "-- "), c == '-' && strings.HasPrefix(script[i:], "--\n"), c == '#':
+			for i < len(script) && script[i] != '\n' {
+				i++
+			}
+			current.WriteByte('\n')
+		case c == '/' && strings.HasPrefix(script[i:], "/*"):
+			end := strings.Index(script[i+2:], "*/")
+			if end < 0 {
+				i = len(script)
+			} else {
+				i += end + 3
+			}
+			current.WriteByte(' ')
+		case c == ';':
+			flush()
+		default:
+			current.WriteByte(c)
+		}
+	}
+	flush()
+	return statements
+}
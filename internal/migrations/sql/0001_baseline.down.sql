@@ -0,0 +1,10 @@
+-- ลบตารางทั้งหมดของ baseline (ลำดับกลับกับตอนสร้างเพราะ foreign key)
+
+DROP TABLE IF EXISTS `outbox_events`;
+DROP TABLE IF EXISTS `product_option`;
+DROP TABLE IF EXISTS `branches_products`;
+DROP TABLE IF EXISTS `branches_interests`;
+DROP TABLE IF EXISTS `branch_location`;
+DROP TABLE IF EXISTS `interest`;
+DROP TABLE IF EXISTS `product`;
+DROP TABLE IF EXISTS `branch`;
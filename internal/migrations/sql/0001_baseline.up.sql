@@ -0,0 +1,73 @@
+-- Baseline: schema ตรงตาม DATABASE_DUMP.sql ทุกตาราง ห้ามแก้ไฟล์นี้ การเปลี่ยน schema ต้องเป็น migration ใหม่
+-- ใช้ IF NOT EXISTS เพื่อให้ฐานข้อมูลที่สร้างจาก DATABASE_DUMP.sql อยู่แล้วบันทึกเวอร์ชันนี้ได้โดยไม่เปลี่ยนอะไร
+-- แล้วรับการเปลี่ยนแปลงจาก migration ถัดไปเหมือนฐานข้อมูลใหม่
+
+CREATE TABLE IF NOT EXISTS `branch` (
+  `id` int NOT NULL AUTO_INCREMENT,
+  `name` json DEFAULT NULL,
+  `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS `product` (
+  `id` int NOT NULL AUTO_INCREMENT,
+  `name` json DEFAULT NULL,
+  `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS `interest` (
+  `id` int NOT NULL AUTO_INCREMENT,
+  `name` json DEFAULT NULL,
+  PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS `branch_location` (
+  `id` int NOT NULL AUTO_INCREMENT,
+  `branch_id` int NOT NULL,
+  `province_id` int NOT NULL,
+  `name` float DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `fk_branch_location_branch` (`branch_id`),
+  CONSTRAINT `fk_branch_location_branch` FOREIGN KEY (`branch_id`) REFERENCES `branch` (`id`) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS `branches_interests` (
+  `branch_id` int NOT NULL,
+  `interest_id` int NOT NULL,
+  PRIMARY KEY (`branch_id`,`interest_id`),
+  KEY `fk_branch_interest_interest` (`interest_id`),
+  CONSTRAINT `fk_branch_interest_branch` FOREIGN KEY (`branch_id`) REFERENCES `branch` (`id`) ON DELETE CASCADE ON UPDATE CASCADE,
+  CONSTRAINT `fk_branch_interest_interest` FOREIGN KEY (`interest_id`) REFERENCES `interest` (`id`) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS `branches_products` (
+  `branch_id` int NOT NULL,
+  `product_id` int NOT NULL,
+  PRIMARY KEY (`branch_id`,`product_id`),
+  KEY `fk_branch_product_product` (`product_id`),
+  CONSTRAINT `fk_branch_product_branch` FOREIGN KEY (`branch_id`) REFERENCES `branch` (`id`) ON DELETE CASCADE ON UPDATE CASCADE,
+  CONSTRAINT `fk_branch_product_product` FOREIGN KEY (`product_id`) REFERENCES `product` (`id`) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS `product_option` (
+  `id` int NOT NULL AUTO_INCREMENT,
+  `normal_price_thb` float DEFAULT NULL,
+  `tagthai_price_thb` float DEFAULT NULL,
+  `product_id` int NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `fk_product_option_product` (`product_id`),
+  CONSTRAINT `fk_product_option_product` FOREIGN KEY (`product_id`) REFERENCES `product` (`id`) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS `outbox_events` (
+  `id` bigint NOT NULL AUTO_INCREMENT,
+  `aggregate_id` varchar(255) NOT NULL,
+  `aggregate_type` varchar(255) NOT NULL,
+  `event_type` varchar(50) NOT NULL,
+  `payload` json DEFAULT NULL,
+  `status` enum('pending','processed','failed') NOT NULL DEFAULT 'pending',
+  `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `idx_status_created_at` (`status`,`created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
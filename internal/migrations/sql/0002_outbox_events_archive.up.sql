@@ -0,0 +1,17 @@
+-- ตารางเก็บ outbox event ที่ cmd/cleanup ลบออกจาก outbox_events (เมื่อ cleanup.archive เป็น table)
+-- ไม่มี AUTO_INCREMENT เพราะเก็บ id เดิมของ event ไว้
+CREATE TABLE IF NOT EXISTS `outbox_events_archive` (
+  `id` bigint NOT NULL,
+  `aggregate_id` varchar(255) NOT NULL,
+  `aggregate_type` varchar(255) NOT NULL,
+  `event_type` varchar(50) NOT NULL,
+  `payload` json DEFAULT NULL,
+  `status` varchar(20) NOT NULL,
+  `attempts` int NOT NULL DEFAULT '0',
+  `last_error` text,
+  `created_at` timestamp NOT NULL,
+  `archived_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `idx_aggregate` (`aggregate_type`,`aggregate_id`),
+  KEY `idx_archived_at` (`archived_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
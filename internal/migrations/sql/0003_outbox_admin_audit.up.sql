@@ -0,0 +1,11 @@
+-- audit trail ของการกระทำผ่าน /admin/outbox (requeue, purge)
+CREATE TABLE IF NOT EXISTS `outbox_admin_audit` (
+  `id` bigint NOT NULL AUTO_INCREMENT,
+  `actor` varchar(255) NOT NULL,
+  `action` varchar(50) NOT NULL,
+  `event_ids` json NOT NULL,
+  `affected` int NOT NULL,
+  `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `idx_created_at` (`created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
@@ -0,0 +1,9 @@
+-- ชื่อสถานที่แบบ JSON แปลงกลับเป็น float ไม่ได้ จึงล้างค่าก่อน
+UPDATE `branch_location` SET `name` = NULL;
+
+ALTER TABLE `branch_location`
+  DROP KEY `uq_branch_location_branch`,
+  DROP COLUMN `longitude`,
+  DROP COLUMN `latitude`,
+  DROP COLUMN `address`,
+  MODIFY `name` float DEFAULT NULL;
@@ -0,0 +1,14 @@
+-- ที่ตั้งของสาขา: ชื่อสถานที่ (th/en) ที่อยู่ และพิกัด
+-- คอลัมน์ name เดิมเป็น float ซึ่งไม่มีข้อมูลที่ใช้ได้ จึงล้างค่าก่อนเปลี่ยนเป็น JSON
+UPDATE `branch_location` SET `name` = NULL;
+
+-- สาขาหนึ่งมีได้หนึ่งที่ตั้ง: เก็บแถวแรก (id น้อยสุด) ของแต่ละสาขาไว้ก่อนเพิ่ม unique key
+DELETE newer FROM `branch_location` newer
+JOIN `branch_location` older ON older.`branch_id` = newer.`branch_id` AND older.`id` < newer.`id`;
+
+ALTER TABLE `branch_location`
+  MODIFY `name` json DEFAULT NULL,
+  ADD COLUMN `address` varchar(500) DEFAULT NULL AFTER `name`,
+  ADD COLUMN `latitude` decimal(9,6) DEFAULT NULL AFTER `address`,
+  ADD COLUMN `longitude` decimal(9,6) DEFAULT NULL AFTER `latitude`,
+  ADD UNIQUE KEY `uq_branch_location_branch` (`branch_id`);
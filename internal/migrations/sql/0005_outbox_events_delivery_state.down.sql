@@ -0,0 +1,14 @@
+-- สถานะที่ไม่มีใน enum เดิมต้องถูกแปลงก่อน ไม่เช่นนั้น MODIFY จะล้มเหลว
+-- event ที่ค้างอยู่ระหว่างส่งกลับเป็น pending ส่วน dead/unhandled ถือเป็น failed
+UPDATE `outbox_events` SET `status` = 'pending' WHERE `status` = 'processing';
+UPDATE `outbox_events` SET `status` = 'failed' WHERE `status` IN ('dead', 'unhandled');
+
+ALTER TABLE `outbox_events`
+  DROP KEY `idx_status_locked_until`,
+  DROP KEY `idx_status_next_attempt_at`,
+  DROP COLUMN `locked_until`,
+  DROP COLUMN `locked_by`,
+  DROP COLUMN `last_error`,
+  DROP COLUMN `next_attempt_at`,
+  DROP COLUMN `attempts`,
+  MODIFY `status` enum('pending','processed','failed') NOT NULL DEFAULT 'pending';
@@ -0,0 +1,11 @@
+-- สถานะการส่งของ outbox event: การจองด้วย lease (processing, locked_by, locked_until),
+-- การลองใหม่แบบ backoff (attempts, next_attempt_at, last_error) และสถานะ dead/unhandled
+ALTER TABLE `outbox_events`
+  MODIFY `status` enum('pending','processing','processed','failed','dead','unhandled') NOT NULL DEFAULT 'pending',
+  ADD COLUMN `attempts` int NOT NULL DEFAULT '0' AFTER `status`,
+  ADD COLUMN `next_attempt_at` timestamp NULL DEFAULT NULL AFTER `attempts`,
+  ADD COLUMN `last_error` text AFTER `next_attempt_at`,
+  ADD COLUMN `locked_by` varchar(255) DEFAULT NULL AFTER `last_error`,
+  ADD COLUMN `locked_until` timestamp NULL DEFAULT NULL AFTER `locked_by`,
+  ADD KEY `idx_status_next_attempt_at` (`status`,`next_attempt_at`),
+  ADD KEY `idx_status_locked_until` (`status`,`locked_until`);
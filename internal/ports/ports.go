@@ -22,7 +22,16 @@ type BranchRepository interface {
 	UpdateBranch(ctx context.Context, dbtx DBTX, id int64, name domain.BranchNameJSON) error
 	DeleteBranch(ctx context.Context, dbtx DBTX, id int64) error
 	UnlinkAllProductsFromBranch(ctx context.Context, dbtx DBTX, branchID int64) error
+	LinkInterestsToBranch(ctx context.Context, dbtx DBTX, branchID int64, interestIDs []int) error
+	UnlinkAllInterestsFromBranch(ctx context.Context, dbtx DBTX, branchID int64) error
+	LinkInterestToBranch(ctx context.Context, dbtx DBTX, branchID, interestID int64) (bool, error)
+	UnlinkInterestFromBranch(ctx context.Context, dbtx DBTX, branchID, interestID int64) (bool, error)
+	GetBranchLocation(ctx context.Context, dbtx DBTX, branchID int64) (*domain.BranchLocation, error)
+	UpsertBranchLocation(ctx context.Context, dbtx DBTX, branchID int64, location domain.BranchLocation) error
+	DeleteBranchLocation(ctx context.Context, dbtx DBTX, branchID int64) error
 	GetRichBranchData(ctx context.Context, dbtx DBTX, id int64) (*domain.Branch, error)
+	TouchBranches(ctx context.Context, dbtx DBTX, ids []int64) error
+	ListRichBranches(ctx context.Context, dbtx DBTX, query domain.BranchListQuery) ([]*domain.Branch, error)
 }
 
 // OutboxRepository คือ port สำหรับการเขียน event
@@ -30,46 +39,109 @@ type OutboxRepository interface {
 	CreateEvent(ctx context.Context, dbtx DBTX, aggregateID string, aggregateType string, eventType string, payload []byte) error
 }
 
+// OutboxAdminRepository คือ port สำหรับการตรวจสอบและจัดการ outbox event โดยผู้ดูแลระบบ
+type OutboxAdminRepository interface {
+	ListOutboxEvents(ctx context.Context, dbtx DBTX, filter domain.OutboxEventFilter) (*domain.OutboxEventPage, error)
+	GetOutboxEvent(ctx context.Context, dbtx DBTX, id int64) (*domain.OutboxEvent, error)
+	GetOutboxStats(ctx context.Context, dbtx DBTX) (*domain.OutboxStats, error)
+	RequeueOutboxEvents(ctx context.Context, dbtx DBTX, ids []int64) (int64, error)
+	PurgeOutboxEvents(ctx context.Context, dbtx DBTX, ids []int64) (int64, error)
+	CreateOutboxAuditEntry(ctx context.Context, dbtx DBTX, entry domain.OutboxAuditEntry) error
+	ListOutboxAuditEntries(ctx context.Context, dbtx DBTX, limit int) ([]*domain.OutboxAuditEntry, error)
+}
+
+// OutboxNotifier คือ port สำหรับแจ้ง outbox worker ว่ามี event ใหม่ หลังจาก transaction ถูก commit แล้ว
+// การแจ้งเป็นแบบ best-effort: ถ้าล้มเหลว worker จะเจอ event ในการ poll รอบถัดไป
+type OutboxNotifier interface {
+	Notify(ctx context.Context) error
+}
+
+// BranchSearcher คือ port สำหรับค้นหาสาขาจาก search index (Elasticsearch)
+// document ใน index มีรูปแบบเดียวกับ domain.Branch ที่ outbox worker เขียนลงไป
+type BranchSearcher interface {
+	SearchBranches(ctx context.Context, query domain.BranchSearchQuery) (*domain.BranchSearchResult, error)
+}
+
 // BranchService คือ port สำหรับ business logic ของ Branch
+// ใน UpdateBranchWithProducts ถ้า interestIDs เป็น nil จะไม่เปลี่ยนความสนใจที่เชื่อมโยงอยู่
 type BranchService interface {
-	CreateBranchWithProducts(ctx context.Context, name domain.BranchNameJSON, productIDs []int) (*domain.Branch, error)
-	UpdateBranchWithProducts(ctx context.Context, id int64, name domain.BranchNameJSON, productIDs []int) (*domain.Branch, error)
+	CreateBranchWithProducts(ctx context.Context, name domain.BranchNameJSON, productIDs, interestIDs []int) (*domain.Branch, error)
+	UpdateBranchWithProducts(ctx context.Context, id int64, name domain.BranchNameJSON, productIDs, interestIDs []int) (*domain.Branch, error)
 	DeleteBranch(ctx context.Context, id int64) error
 	GetBranch(ctx context.Context, id int64) (*domain.Branch, error)
+	ListBranches(ctx context.Context, query domain.BranchListQuery) (*domain.BranchPage, error)
+	LinkInterest(ctx context.Context, branchID, interestID int64) (*domain.Branch, error)
+	UnlinkInterest(ctx context.Context, branchID, interestID int64) (*domain.Branch, error)
+	GetLocation(ctx context.Context, branchID int64) (*domain.BranchLocation, error)
+	SetLocation(ctx context.Context, branchID int64, location domain.BranchLocation) (*domain.Branch, error)
+	DeleteLocation(ctx context.Context, branchID int64) (*domain.Branch, error)
 }
 
 // InterestRepository คือ port สำหรับ Interest
 type InterestRepository interface {
+	CreateInterest(ctx context.Context, dbtx DBTX, name domain.BranchNameJSON) (int64, error)
+	GetInterest(ctx context.Context, dbtx DBTX, id int64) (*domain.Interest, error)
+	ListInterests(ctx context.Context, dbtx DBTX, page domain.PageQuery) ([]*domain.Interest, error)
 	UpdateInterest(ctx context.Context, dbtx DBTX, id int64, name domain.BranchNameJSON) error
 	DeleteInterest(ctx context.Context, dbtx DBTX, id int64) error
+	GetBranchIDsByInterest(ctx context.Context, dbtx DBTX, interestID int64) ([]int64, error)
 }
 
 // ProductRepository คือ port สำหรับ Product
 type ProductRepository interface {
+	CreateProduct(ctx context.Context, dbtx DBTX, name domain.BranchNameJSON) (int64, error)
+	GetProduct(ctx context.Context, dbtx DBTX, id int64) (*domain.Product, error)
+	ListProducts(ctx context.Context, dbtx DBTX, page domain.PageQuery) ([]*domain.Product, error)
 	UpdateProduct(ctx context.Context, dbtx DBTX, id int64, name domain.BranchNameJSON) error
 	DeleteProduct(ctx context.Context, dbtx DBTX, id int64) error
+	GetBranchIDsByProduct(ctx context.Context, dbtx DBTX, productID int64) ([]int64, error)
 }
 
 // ProductOptionRepository คือ port สำหรับ ProductOption
 type ProductOptionRepository interface {
+	CreateProductOption(ctx context.Context, dbtx DBTX, productID int64, normalPrice, tagthaiPrice float64) (int64, error)
+	GetProductOption(ctx context.Context, dbtx DBTX, id int64) (*domain.ProductOption, error)
+	ListProductOptions(ctx context.Context, dbtx DBTX, productID int64, page domain.PageQuery) ([]*domain.ProductOption, error)
 	UpdateProductOption(ctx context.Context, dbtx DBTX, id int64, normalPrice, tagthaiPrice float64) error
 	DeleteProductOption(ctx context.Context, dbtx DBTX, id int64) error
+	GetBranchIDsByProductOption(ctx context.Context, dbtx DBTX, productOptionID int64) ([]int64, error)
 }
 
 // InterestService คือ port สำหรับ business logic ของ Interest
 type InterestService interface {
+	CreateInterest(ctx context.Context, name domain.BranchNameJSON) (*domain.Interest, error)
+	GetInterest(ctx context.Context, id int64) (*domain.Interest, error)
+	ListInterests(ctx context.Context, page domain.PageQuery) (*domain.InterestPage, error)
 	UpdateInterest(ctx context.Context, id int64, name domain.BranchNameJSON) error
 	DeleteInterest(ctx context.Context, id int64) error
 }
 
 // ProductService คือ port สำหรับ business logic ของ Product
 type ProductService interface {
+	CreateProduct(ctx context.Context, name domain.BranchNameJSON) (*domain.Product, error)
+	GetProduct(ctx context.Context, id int64) (*domain.Product, error)
+	ListProducts(ctx context.Context, page domain.PageQuery) (*domain.ProductPage, error)
 	UpdateProduct(ctx context.Context, id int64, name domain.BranchNameJSON) error
 	DeleteProduct(ctx context.Context, id int64) error
 }
 
 // ProductOptionService คือ port สำหรับ business logic ของ ProductOption
+// ตัวเลือกราคาถูกสร้างภายใต้สินค้า และมีผลกับช่วงราคาของทุกสาขาที่ขายสินค้านั้น
 type ProductOptionService interface {
+	CreateProductOption(ctx context.Context, productID int64, normalPrice, tagthaiPrice float64) (*domain.ProductOption, error)
+	GetProductOption(ctx context.Context, id int64) (*domain.ProductOption, error)
+	ListProductOptions(ctx context.Context, productID int64, page domain.PageQuery) (*domain.ProductOptionPage, error)
 	UpdateProductOption(ctx context.Context, id int64, normalPrice, tagthaiPrice float64) error
 	DeleteProductOption(ctx context.Context, id int64) error
 }
+
+// OutboxAdminService คือ port สำหรับ business logic ของการจัดการ outbox โดยผู้ดูแลระบบ
+// การกระทำที่เปลี่ยนข้อมูล (requeue, purge) ถูกบันทึกใน audit trail ภายใน transaction เดียวกัน
+type OutboxAdminService interface {
+	ListEvents(ctx context.Context, filter domain.OutboxEventFilter) (*domain.OutboxEventPage, error)
+	GetEvent(ctx context.Context, id int64) (*domain.OutboxEvent, error)
+	Stats(ctx context.Context) (*domain.OutboxStats, error)
+	RequeueEvents(ctx context.Context, actor string, ids []int64) (*domain.OutboxAuditEntry, error)
+	PurgeEvents(ctx context.Context, actor string, ids []int64) (*domain.OutboxAuditEntry, error)
+	ListAuditEntries(ctx context.Context, limit int) ([]*domain.OutboxAuditEntry, error)
+}
@@ -0,0 +1,102 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/olivere/elastic/v7"
+)
+
+// --- Index Versions ---
+// r.index คือ alias ที่ API และ worker ใช้อ่านและเขียน document ของสาขา
+// index จริงมีชื่อ <alias>_v<timestamp> การ reindex จะสร้าง index ใหม่ เติมข้อมูลให้ครบ
+// แล้วย้าย alias ไปที่ index ใหม่ในครั้งเดียว ผู้ใช้จึงไม่เห็นข้อมูลที่เติมไม่ครบ
+
+// NewBranchIndexName คืนชื่อ index จริงของสาขาสำหรับเวลาที่กำหนด เช่น branches_v20261018093000
+func NewBranchIndexName(alias string, now time.Time) string {
+	return alias + "_v" + now.UTC().Format("20060102150405")
+}
+
+// CreateBranchIndex สร้าง index จริงชื่อ name โดยใช้ settings และ mappings จาก index template ของสาขา
+func (r *elasticsearchRepository) CreateBranchIndex(ctx context.Context, name string) error {
+	if err := r.PutBranchIndexTemplate(ctx); err != nil {
+		return err
+	}
+	if _, err := r.client.CreateIndex(name).Do(ctx); err != nil {
+		return fmt.Errorf("failed to create index %s: %w", name, err)
+	}
+	return nil
+}
+
+// AliasedBranchIndices คืนชื่อ index จริงที่ alias ของสาขาชี้อยู่ (ว่างถ้ายังไม่มี alias)
+func (r *elasticsearchRepository) AliasedBranchIndices(ctx context.Context) ([]string, error) {
+	res, err := r.client.Aliases().Alias(r.index).Do(ctx)
+	if elastic.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get indices of alias %s: %w", r.index, err)
+	}
+	indices := res.IndicesByAlias(r.index)
+	sort.Strings(indices)
+	return indices, nil
+}
+
+// SwapBranchAlias ย้าย alias ของสาขาไปที่ newIndex ใน request เดียว (atomic)
+// คืนชื่อ index ที่ alias เคยชี้อยู่ เพื่อให้ผู้เรียกเลือกได้ว่าจะลบหรือเก็บไว้ย้อนกลับ
+//
+// ถ้ามี index จริงชื่อเดียวกับ alias (index ที่ถูกสร้างก่อนใช้ alias) จะถูกลบใน request เดียวกัน
+// เพราะ Elasticsearch ไม่ยอมให้ alias มีชื่อซ้ำกับ index
+func (r *elasticsearchRepository) SwapBranchAlias(ctx context.Context, newIndex string) (previous []string, legacyRemoved bool, err error) {
+	previous, err = r.AliasedBranchIndices(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	actions := []elastic.AliasAction{elastic.NewAliasAddAction(r.index).Index(newIndex)}
+	if len(previous) == 0 {
+		exists, err := r.client.IndexExists(r.index).Do(ctx)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to check index %s: %w", r.index, err)
+		}
+		if exists {
+			actions = append(actions, elastic.NewAliasRemoveIndexAction(r.index))
+			legacyRemoved = true
+		}
+	}
+	for _, old := range previous {
+		if old != newIndex {
+			actions = append(actions, elastic.NewAliasRemoveAction(r.index).Index(old))
+		}
+	}
+
+	if _, err := r.client.Alias().Action(actions...).Do(ctx); err != nil {
+		return nil, false, fmt.Errorf("failed to point alias %s to %s: %w", r.index, newIndex, err)
+	}
+	return previous, legacyRemoved, nil
+}
+
+// DeleteBranchIndices ลบ index จริงของสาขา
+func (r *elasticsearchRepository) DeleteBranchIndices(ctx context.Context, names ...string) error {
+	if len(names) == 0 {
+		return nil
+	}
+	if _, err := r.client.DeleteIndex(names...).Do(ctx); err != nil {
+		return fmt.Errorf("failed to delete indices %v: %w", names, err)
+	}
+	return nil
+}
+
+// CountBranchDocuments refresh index แล้วนับจำนวน document ทั้งหมดในนั้น
+func (r *elasticsearchRepository) CountBranchDocuments(ctx context.Context, index string) (int64, error) {
+	if _, err := r.client.Refresh(index).Do(ctx); err != nil {
+		return 0, fmt.Errorf("failed to refresh index %s: %w", index, err)
+	}
+	count, err := r.client.Count(index).Do(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count documents in %s: %w", index, err)
+	}
+	return count, nil
+}
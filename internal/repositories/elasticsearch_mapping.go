@@ -0,0 +1,291 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/olivere/elastic/v7"
+)
+
+// BranchMappingVersion คือเวอร์ชันของ mapping ของ index สาขา
+// ต้องเพิ่มค่านี้ทุกครั้งที่แก้ branchIndexSettings หรือ branchIndexMappings
+const BranchMappingVersion = 2
+
+// BranchIndexTemplateName คือชื่อ index template ที่ใช้กับทุก index ของสาขา
+const BranchIndexTemplateName = "branches_template"
+
+// branchIndexSettings กำหนด analyzer ของ index สาขา
+// ชื่อภาษาไทยไม่มีช่องว่างระหว่างคำ standard analyzer จึงตัดคำได้ไม่ดี
+// จึงใช้ tokenizer "thai" (ตัดคำตามพจนานุกรม) แทน
+var branchIndexSettings = map[string]interface{}{
+	"analysis": map[string]interface{}{
+		"filter": map[string]interface{}{
+			"thai_stop": map[string]interface{}{
+				"type":      "stop",
+				"stopwords": "_thai_",
+			},
+		},
+		"analyzer": map[string]interface{}{
+			"thai_text": map[string]interface{}{
+				"type":      "custom",
+				"tokenizer": "thai",
+				"filter":    []string{"lowercase", "decimal_digit", "thai_stop"},
+			},
+		},
+	},
+}
+
+// branchIndexMappings คือ mapping ของ document สาขา (รูปแบบเดียวกับ domain.Branch)
+// dynamic: false ทำให้ field ที่ไม่รู้จักถูกเก็บใน _source แต่ไม่ถูก index และไม่เปลี่ยน mapping
+var branchIndexMappings = map[string]interface{}{
+	"dynamic": false,
+	"_meta": map[string]interface{}{
+		"mapping_version": BranchMappingVersion,
+	},
+	"properties": map[string]interface{}{
+		"id": map[string]interface{}{"type": "long"},
+		"name": map[string]interface{}{
+			"properties": map[string]interface{}{
+				"th": textWithKeyword("thai_text"),
+				"en": textWithKeyword("english"),
+			},
+		},
+		"location": map[string]interface{}{
+			"properties": map[string]interface{}{
+				"province_id": map[string]interface{}{"type": "integer"},
+				"name": map[string]interface{}{
+					"properties": map[string]interface{}{
+						"th": textWithKeyword("thai_text"),
+						"en": textWithKeyword("english"),
+					},
+				},
+				"address":     map[string]interface{}{"type": "text", "analyzer": "thai_text"},
+				"coordinates": map[string]interface{}{"type": "geo_point"},
+			},
+		},
+		"product_ids":       map[string]interface{}{"type": "integer"},
+		"interest_ids":      map[string]interface{}{"type": "integer"},
+		"min_normal_price":  priceField(),
+		"max_normal_price":  priceField(),
+		"min_tagthai_price": priceField(),
+		"max_tagthai_price": priceField(),
+		"updated_at":        map[string]interface{}{"type": "date"},
+	},
+}
+
+// textWithKeyword คือ field ข้อความที่ค้นหาแบบ full-text ได้ และมี subfield "keyword" สำหรับ sort/aggregation
+func textWithKeyword(analyzer string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":     "text",
+		"analyzer": analyzer,
+		"fields": map[string]interface{}{
+			"keyword": map[string]interface{}{"type": "keyword", "ignore_above": 256},
+		},
+	}
+}
+
+// priceField เก็บราคาเป็น scaled_float ความละเอียด 2 ตำแหน่ง (สตางค์)
+func priceField() map[string]interface{} {
+	return map[string]interface{}{"type": "scaled_float", "scaling_factor": 100}
+}
+
+// BranchIndexTemplate คืน body ของ composable index template สำหรับ index ที่ตรงกับ patterns
+func BranchIndexTemplate(patterns ...string) map[string]interface{} {
+	return map[string]interface{}{
+		"index_patterns": patterns,
+		"priority":       100,
+		"version":        BranchMappingVersion,
+		"template": map[string]interface{}{
+			"settings": branchIndexSettings,
+			"mappings": branchIndexMappings,
+		},
+		"_meta": map[string]interface{}{
+			"description": "Managed by the ES project. Do not edit by hand; change internal/repositories/elasticsearch_mapping.go instead.",
+		},
+	}
+}
+
+// PutBranchIndexTemplate สร้างหรืออัปเดต index template ของสาขา
+// template มีผลกับ index ที่สร้างใหม่หลังจากนี้เท่านั้น index ที่มีอยู่แล้วต้อง reindex
+func (r *elasticsearchRepository) PutBranchIndexTemplate(ctx context.Context) error {
+	_, err := r.client.IndexPutIndexTemplate(BranchIndexTemplateName).
+		BodyJson(BranchIndexTemplate(r.index, r.index+"_*")).
+		Do(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to put index template %s: %w", BranchIndexTemplateName, err)
+	}
+	return nil
+}
+
+// EnsureBranchIndex ติดตั้ง index template และสร้าง index ของสาขาถ้ายังไม่มี
+// ทำให้ document แรกที่ถูกเขียนไม่ไปสร้าง index ด้วย dynamic mapping
+// index ที่สร้างจะมีชื่อแบบมีเวอร์ชัน และมี alias r.index ชี้อยู่ (ดู SwapBranchAlias)
+// คืนชื่อ index ที่ถูกสร้างใหม่ หรือ "" ถ้ามี index หรือ alias อยู่แล้ว
+func (r *elasticsearchRepository) EnsureBranchIndex(ctx context.Context) (string, error) {
+	if err := r.PutBranchIndexTemplate(ctx); err != nil {
+		return "", err
+	}
+
+	// IndexExists เป็นจริงทั้งกรณีที่ r.index เป็น alias และเป็น index จริง
+	exists, err := r.client.IndexExists(r.index).Do(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to check index %s: %w", r.index, err)
+	}
+	if exists {
+		return "", nil
+	}
+
+	name := NewBranchIndexName(r.index, time.Now())
+	_, err = r.client.CreateIndex(name).
+		BodyJson(map[string]interface{}{
+			"aliases": map[string]interface{}{r.index: map[string]interface{}{}},
+		}).
+		Do(ctx)
+	if err != nil {
+		// worker หลายตัวอาจสร้างพร้อมกัน
+		if e, ok := err.(*elastic.Error); ok && e.Details != nil &&
+			(e.Details.Type == "resource_already_exists_exception" || e.Details.Type == "invalid_alias_name_exception") {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to create index %s: %w", name, err)
+	}
+
+	// ถ้า worker อีกตัวสร้าง index คนละชื่อในเวลาไล่เลี่ยกัน alias จะชี้สองที่และเขียนไม่ได้
+	// ให้ index ที่ชื่อเรียงก่อนอยู่ต่อ ส่วนตัวอื่นลบ index ของตัวเองทิ้ง
+	indices, err := r.AliasedBranchIndices(ctx)
+	if err != nil {
+		return "", err
+	}
+	if len(indices) > 1 && indices[0] != name {
+		return "", r.DeleteBranchIndices(ctx, name)
+	}
+	return name, nil
+}
+
+// BranchMappingDrift เปรียบเทียบ mapping ของ index (หรือ alias) ที่ใช้งานอยู่กับ mapping ที่โปรเจกต์กำหนด
+// คืนรายการความแตกต่าง (ว่างถ้าตรงกัน) เช่น field ที่หายไป field ที่ type ไม่ตรง
+// หรือ field ที่เกิดจาก dynamic mapping
+func (r *elasticsearchRepository) BranchMappingDrift(ctx context.Context) ([]string, error) {
+	res, err := r.client.GetMapping().Index(r.index).Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mapping of %s: %w", r.index, err)
+	}
+
+	expected, err := normalizeJSON(branchIndexMappings)
+	if err != nil {
+		return nil, err
+	}
+
+	// ถ้า r.index เป็น alias ผลลัพธ์จะอยู่ใต้ชื่อ index จริง
+	var drift []string
+	for indexName, raw := range res {
+		entry, _ := raw.(map[string]interface{})
+		live, _ := entry["mappings"].(map[string]interface{})
+		for _, d := range DiffMappings(expected, live) {
+			drift = append(drift, indexName+": "+d)
+		}
+	}
+	sort.Strings(drift)
+	return drift, nil
+}
+
+// DiffMappings เปรียบเทียบ mapping สองชุดแบบ field ต่อ field
+// ทั้งสองชุดต้องอยู่ในรูปแบบที่ได้จาก encoding/json (ตัวเลขเป็น float64)
+func DiffMappings(expected, actual map[string]interface{}) []string {
+	var drift []string
+
+	expectedVersion := nestedValue(expected, "_meta", "mapping_version")
+	actualVersion := nestedValue(actual, "_meta", "mapping_version")
+	if !reflect.DeepEqual(expectedVersion, actualVersion) {
+		drift = append(drift, fmt.Sprintf("mapping_version is %v, expected %v", actualVersion, expectedVersion))
+	}
+	if e, a := fmt.Sprint(expected["dynamic"]), fmt.Sprint(actual["dynamic"]); e != a {
+		drift = append(drift, fmt.Sprintf("dynamic is %s, expected %s", a, e))
+	}
+
+	expectedProps, _ := expected["properties"].(map[string]interface{})
+	actualProps, _ := actual["properties"].(map[string]interface{})
+	diffProperties("", expectedProps, actualProps, &drift)
+	return drift
+}
+
+func diffProperties(prefix string, expected, actual map[string]interface{}, drift *[]string) {
+	for _, name := range sortedKeys(expected) {
+		path := prefix + name
+		e, _ := expected[name].(map[string]interface{})
+		a, ok := actual[name].(map[string]interface{})
+		if !ok {
+			*drift = append(*drift, fmt.Sprintf("%s is missing", path))
+			continue
+		}
+		diffField(path, e, a, drift)
+	}
+	for _, name := range sortedKeys(actual) {
+		if _, ok := expected[name]; !ok {
+			*drift = append(*drift, fmt.Sprintf("%s is not in the managed mapping", prefix+name))
+		}
+	}
+}
+
+func diffField(path string, expected, actual map[string]interface{}, drift *[]string) {
+	for _, key := range sortedKeys(expected) {
+		switch key {
+		case "properties":
+			e, _ := expected[key].(map[string]interface{})
+			a, _ := actual[key].(map[string]interface{})
+			diffProperties(path+".", e, a, drift)
+		case "fields":
+			e, _ := expected[key].(map[string]interface{})
+			a, _ := actual[key].(map[string]interface{})
+			diffProperties(path+".fields.", e, a, drift)
+		default:
+			if !reflect.DeepEqual(expected[key], actual[key]) {
+				*drift = append(*drift, fmt.Sprintf("%s.%s is %v, expected %v", path, key, actual[key], expected[key]))
+			}
+		}
+	}
+	// object ที่ไม่มี "type" ใน mapping ที่กำหนด แต่ live มี type อื่น (เช่น dynamic mapping สร้างเป็น text)
+	if _, ok := expected["type"]; !ok {
+		if t, ok := actual["type"]; ok && t != "object" {
+			*drift = append(*drift, fmt.Sprintf("%s.type is %v, expected object", path, t))
+		}
+	}
+}
+
+func nestedValue(m map[string]interface{}, keys ...string) interface{} {
+	var cur interface{} = m
+	for _, k := range keys {
+		obj, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		cur = obj[k]
+	}
+	return cur
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// normalizeJSON แปลง map ให้อยู่ในรูปแบบเดียวกับที่ถอดรหัสจาก JSON เพื่อให้เปรียบเทียบกับ mapping จาก Elasticsearch ได้
+func normalizeJSON(v interface{}) (map[string]interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal mapping: %w", err)
+	}
+	var out map[string]interface{}
+	if err := json.Unmarshal(b, &out); err != nil {
+		return nil, fmt.Errorf("failed to normalize mapping: %w", err)
+	}
+	return out, nil
+}
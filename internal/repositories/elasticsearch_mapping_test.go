@@ -0,0 +1,49 @@
+package repositories
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffMappings_NoDriftForManagedMapping(t *testing.T) {
+	expected, err := normalizeJSON(branchIndexMappings)
+	require.NoError(t, err)
+
+	// Elasticsearch คืนค่า dynamic เป็น string "false"
+	live, err := normalizeJSON(branchIndexMappings)
+	require.NoError(t, err)
+	live["dynamic"] = "false"
+
+	assert.Empty(t, DiffMappings(expected, live))
+}
+
+func TestDiffMappings_ReportsDynamicMappedFields(t *testing.T) {
+	expected, err := normalizeJSON(branchIndexMappings)
+	require.NoError(t, err)
+
+	// mapping ที่ Elasticsearch สร้างเองจาก document แรก
+	live := map[string]interface{}{
+		"properties": map[string]interface{}{
+			"id": map[string]interface{}{"type": "long"},
+			"name": map[string]interface{}{
+				"properties": map[string]interface{}{
+					"th": map[string]interface{}{"type": "text"},
+				},
+			},
+			"min_normal_price": map[string]interface{}{"type": "float"},
+			"extra":            map[string]interface{}{"type": "keyword"},
+		},
+	}
+
+	drift := DiffMappings(expected, live)
+
+	assert.Contains(t, drift, "mapping_version is <nil>, expected 2")
+	assert.Contains(t, drift, "name.th.analyzer is <nil>, expected thai_text")
+	assert.Contains(t, drift, "name.th.fields.keyword is missing")
+	assert.Contains(t, drift, "name.en is missing")
+	assert.Contains(t, drift, "min_normal_price.type is float, expected scaled_float")
+	assert.Contains(t, drift, "product_ids is missing")
+	assert.Contains(t, drift, "extra is not in the managed mapping")
+}
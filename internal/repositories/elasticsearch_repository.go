@@ -0,0 +1,117 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"ES/internal/domain"
+
+	"github.com/olivere/elastic/v7"
+)
+
+// maxSearchWindow คือจำนวนผลลัพธ์สูงสุดที่ Elasticsearch ยอมให้แบ่งหน้าด้วย from/size (index.max_result_window)
+const maxSearchWindow = 10000
+
+// elasticsearchRepository คือ implementation ของ BranchSearcher สำหรับ Elasticsearch
+type elasticsearchRepository struct {
+	client *elastic.Client
+	index  string
+}
+
+// NewElasticsearchRepository คือ factory function สำหรับสร้าง elasticsearchRepository
+// index คือชื่อ index (หรือ alias) ที่ outbox worker เขียน document ของสาขาลงไป
+func NewElasticsearchRepository(client *elastic.Client, index string) *elasticsearchRepository {
+	return &elasticsearchRepository{client: client, index: index}
+}
+
+// SearchBranches ค้นหาสาขาแบบ full-text ในชื่อภาษาไทย/อังกฤษ พร้อมกรองตามสินค้า ความสนใจ จังหวัด
+// และระยะทางจากพิกัดที่ระบุ
+func (r *elasticsearchRepository) SearchBranches(ctx context.Context, q domain.BranchSearchQuery) (*domain.BranchSearchResult, error) {
+	// ตรวจก่อนคูณเพื่อไม่ให้ page ที่ใหญ่มากทำให้ผลคูณ overflow
+	if q.Page < 1 || q.PageSize < 1 || q.Page > maxSearchWindow/q.PageSize {
+		return nil, domain.NewValidationError("page", fmt.Sprintf("page %d with page size %d is beyond the %d result search window", q.Page, q.PageSize, maxSearchWindow))
+	}
+	from := (q.Page - 1) * q.PageSize
+
+	query := elastic.NewBoolQuery()
+
+	// 1. Full-text search (ถ้ามี) มีผลต่อคะแนน ส่วนเงื่อนไขอื่นเป็น filter ที่ไม่มีผลต่อคะแนน
+	if q.Text != "" {
+		query.Must(elastic.NewMultiMatchQuery(q.Text, "name.th", "name.en"))
+	}
+
+	// 2. Filters: สาขาต้องมีอย่างน้อยหนึ่งค่าในแต่ละรายการที่ระบุ
+	if len(q.ProductIDs) > 0 {
+		query.Filter(elastic.NewTermsQuery("product_ids", intsToInterfaces(q.ProductIDs)...))
+	}
+	if len(q.InterestIDs) > 0 {
+		query.Filter(elastic.NewTermsQuery("interest_ids", intsToInterfaces(q.InterestIDs)...))
+	}
+	if q.ProvinceID != nil {
+		query.Filter(elastic.NewTermQuery("location.province_id", *q.ProvinceID))
+	}
+	if q.Near != nil {
+		query.Filter(elastic.NewGeoDistanceQuery("location.coordinates").
+			Lat(q.Near.Lat).
+			Lon(q.Near.Lon).
+			Distance(fmt.Sprintf("%gkm", q.RadiusKm)))
+	}
+
+	search := r.client.Search().
+		Index(r.index).
+		Query(query).
+		From(from).
+		Size(q.PageSize).
+		TrackTotalHits(true)
+
+	// ถ้าระบุพิกัด เรียงจากใกล้ไปไกล (ค่า sort แรกของแต่ละ hit คือระยะทางเป็นกิโลเมตร)
+	// ถ้าไม่มีข้อความค้นหา คะแนนของทุก document เท่ากัน จึงเรียงตาม id เพื่อให้แบ่งหน้าได้คงที่
+	switch {
+	case q.Near != nil:
+		search = search.SortBy(
+			elastic.NewGeoDistanceSort("location.coordinates").
+				Point(q.Near.Lat, q.Near.Lon).
+				Unit("km").
+				Asc(),
+			elastic.NewFieldSort("id").Asc())
+	case q.Text == "":
+		search = search.Sort("id", true)
+	default:
+		search = search.SortBy(elastic.NewScoreSort(), elastic.NewFieldSort("id").Asc())
+	}
+
+	res, err := search.Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search branches: %w", err)
+	}
+
+	result := &domain.BranchSearchResult{Branches: make([]*domain.BranchSearchHit, 0, len(res.Hits.Hits))}
+	if res.Hits.TotalHits != nil {
+		result.Total = res.Hits.TotalHits.Value
+	}
+	for _, hit := range res.Hits.Hits {
+		var branch domain.Branch
+		if err := json.Unmarshal(hit.Source, &branch); err != nil {
+			log.Printf("WARNING: could not unmarshal branch document %s: %v", hit.Id, err)
+			continue // ข้าม document ที่มีปัญหา
+		}
+		searchHit := &domain.BranchSearchHit{Branch: &branch}
+		if q.Near != nil && len(hit.Sort) > 0 {
+			if distance, ok := hit.Sort[0].(float64); ok {
+				searchHit.DistanceKm = &distance
+			}
+		}
+		result.Branches = append(result.Branches, searchHit)
+	}
+	return result, nil
+}
+
+func intsToInterfaces(values []int) []interface{} {
+	out := make([]interface{}, len(values))
+	for i, v := range values {
+		out[i] = v
+	}
+	return out
+}
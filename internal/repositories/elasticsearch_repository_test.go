@@ -0,0 +1,25 @@
+package repositories
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"ES/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchBranches_RejectsPagesBeyondSearchWindow(t *testing.T) {
+	// ไม่มี client เพราะต้องถูกปฏิเสธก่อนส่ง request ไปยัง Elasticsearch
+	repo := NewElasticsearchRepository(nil, "branches")
+
+	for _, q := range []domain.BranchSearchQuery{
+		{Page: 101, PageSize: 100},
+		{Page: math.MaxInt, PageSize: 100},
+		{Page: 0, PageSize: 20},
+	} {
+		_, err := repo.SearchBranches(context.Background(), q)
+		assert.ErrorIs(t, err, domain.ErrValidation, "page %d size %d", q.Page, q.PageSize)
+	}
+}
@@ -0,0 +1,89 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"ES/internal/domain"
+	"ES/internal/ports"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// MySQL error codes ที่แปลงเป็น error ของ domain
+// https://dev.mysql.com/doc/mysql-errors/8.0/en/server-error-reference.html
+const (
+	mysqlErrDuplicateEntry  = 1062 // ER_DUP_ENTRY
+	mysqlErrRowIsReferenced = 1451 // ER_ROW_IS_REFERENCED_2: ลบหรือแก้แถวที่ยังถูกอ้างอิงด้วย foreign key
+	mysqlErrNoReferencedRow = 1452 // ER_NO_REFERENCED_ROW_2: อ้างถึงแถวที่ไม่มีอยู่
+)
+
+// foreignKeyPattern ดึงชื่อคอลัมน์และตารางที่ถูกอ้างอิงจากข้อความ error ของ foreign key เช่น
+// "... CONSTRAINT `fk` FOREIGN KEY (`product_id`) REFERENCES `product` (`id`) ..."
+var foreignKeyPattern = regexp.MustCompile("FOREIGN KEY \\(`([^`]+)`\\) REFERENCES `([^`]+)`")
+
+// childTablePattern ดึงชื่อตารางลูกจากข้อความ error ของ 1451 เช่น "(`TTDB`.`branches_products`, CONSTRAINT ..."
+var childTablePattern = regexp.MustCompile("\\(`[^`]+`\\.`([^`]+)`, CONSTRAINT")
+
+// translateMySQLError แปลง error ของ MySQL ที่เกิดจากข้อมูลของผู้ใช้เป็น error ของ domain
+// resource คือชื่อของสิ่งที่กำลังเขียน (เช่น "branch") error อื่นถูกคืนกลับไปตามเดิม
+func translateMySQLError(err error, resource string) error {
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return err
+	}
+	switch mysqlErr.Number {
+	case mysqlErrNoReferencedRow:
+		field, table := "id", "row"
+		if column, referenced, ok := missingReference(err); ok {
+			field, table = column, referenced
+		}
+		return &domain.ValidationError{Fields: []domain.FieldError{{Field: field, Message: "refers to a " + table + " that does not exist"}}}
+	case mysqlErrDuplicateEntry:
+		return &domain.ConflictError{Resource: resource, Message: "already exists"}
+	case mysqlErrRowIsReferenced:
+		message := "is still referenced by other records"
+		if m := childTablePattern.FindStringSubmatch(mysqlErr.Message); m != nil {
+			message = "is still referenced by " + m[1]
+		}
+		return &domain.ConflictError{Resource: resource, Message: message}
+	}
+	return err
+}
+
+// missingReference คืนชื่อคอลัมน์และตารางที่ถูกอ้างอิง ถ้า err คือ foreign key ที่อ้างถึงแถวที่ไม่มีอยู่ (1452)
+func missingReference(err error) (column, table string, ok bool) {
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) || mysqlErr.Number != mysqlErrNoReferencedRow {
+		return "", "", false
+	}
+	m := foreignKeyPattern.FindStringSubmatch(mysqlErr.Message)
+	if m == nil {
+		return "", "", false
+	}
+	return m[1], m[2], true
+}
+
+// checkAffected คืน NotFoundError ถ้า UPDATE หรือ DELETE ไม่กระทบแถวใดเลยเพราะไม่มีแถวที่มี id นี้
+// UPDATE ที่เขียนค่าเดิมได้ rows affected เป็น 0 เช่นกัน จึงตรวจว่าแถวมีอยู่จริงก่อนสรุปว่าไม่พบ
+func checkAffected(ctx context.Context, dbtx ports.DBTX, res sql.Result, table, resource string, id int64) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if affected > 0 {
+		return nil
+	}
+	var exists int
+	err = dbtx.QueryRowContext(ctx, "SELECT 1 FROM "+table+" WHERE id = ?", id).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.NewNotFoundError(resource, id)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check %s %d exists: %w", resource, id, err)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+
+	"ES/internal/domain"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranslateMySQLError(t *testing.T) {
+	missingProduct := &mysql.MySQLError{Number: 1452, Message: "Cannot add or update a child row: a foreign key constraint fails " +
+		"(`TTDB`.`branches_products`, CONSTRAINT `fk_bp_product` FOREIGN KEY (`product_id`) REFERENCES `product` (`id`))"}
+	err := translateMySQLError(missingProduct, "branch")
+	var validation *domain.ValidationError
+	require.True(t, errors.As(err, &validation))
+	assert.Equal(t, []domain.FieldError{{Field: "product_id", Message: "refers to a product that does not exist"}}, validation.Fields)
+	assert.ErrorIs(t, err, domain.ErrValidation)
+
+	referenced := &mysql.MySQLError{Number: 1451, Message: "Cannot delete or update a parent row: a foreign key constraint fails " +
+		"(`TTDB`.`branches_products`, CONSTRAINT `fk_bp_product` FOREIGN KEY (`product_id`) REFERENCES `product` (`id`))"}
+	err = translateMySQLError(referenced, "product")
+	assert.ErrorIs(t, err, domain.ErrConflict)
+	assert.Contains(t, err.Error(), "is still referenced by branches_products")
+
+	err = translateMySQLError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry '1' for key 'PRIMARY'"}, "interest")
+	assert.ErrorIs(t, err, domain.ErrConflict)
+
+	// error อื่นถูกคืนกลับไปตามเดิม
+	other := errors.New("connection refused")
+	assert.Same(t, other, translateMySQLError(other, "branch"))
+}
+
+func TestDeleteBranch_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM branch WHERE id = ?")).
+		WithArgs(int64(42)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = NewMySQLRepository(db).DeleteBranch(context.Background(), db, 42)
+
+	var notFound *domain.NotFoundError
+	require.True(t, errors.As(err, &notFound))
+	assert.Equal(t, "branch", notFound.Resource)
+	assert.Equal(t, int64(42), notFound.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateInterest_UnchangedRowIsNotNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	// UPDATE ที่เขียนค่าเดิมได้ rows affected เป็น 0 แต่แถวยังมีอยู่
+	mock.ExpectExec("UPDATE interest").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT 1 FROM interest WHERE id = ?")).
+		WithArgs(int64(7)).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectExec("UPDATE interest").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT 1 FROM interest WHERE id = ?")).
+		WithArgs(int64(8)).
+		WillReturnError(sql.ErrNoRows)
+
+	repo := NewMySQLRepository(db)
+	name := domain.BranchNameJSON{EN: "Food", TH: "อาหาร"}
+	assert.NoError(t, repo.UpdateInterest(context.Background(), db, 7, name))
+	assert.ErrorIs(t, repo.UpdateInterest(context.Background(), db, 8, name), domain.ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateProductOption_MissingProductIsNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO product_option (product_id, normal_price_thb, tagthai_price_thb) VALUES (?, ?, ?)")).
+		WithArgs(int64(99), 100.0, 90.0).
+		WillReturnError(&mysql.MySQLError{Number: 1452, Message: "Cannot add or update a child row: a foreign key constraint fails " +
+			"(`TTDB`.`product_option`, CONSTRAINT `fk_product_option_product` FOREIGN KEY (`product_id`) REFERENCES `product` (`id`) ON DELETE CASCADE ON UPDATE CASCADE)"})
+
+	_, err = NewMySQLRepository(db).CreateProductOption(context.Background(), db, 99, 100, 90)
+
+	var notFound *domain.NotFoundError
+	require.True(t, errors.As(err, &notFound))
+	assert.Equal(t, "product", notFound.Resource)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,262 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"ES/internal/domain"
+	"ES/internal/ports"
+)
+
+// --- Outbox Administration ---
+
+// outboxEventColumns คือคอลัมน์ของ outbox_events ที่ไม่รวม payload (ใช้ในรายการซึ่งอาจมีหลายร้อยแถว)
+const outboxEventColumns = "id, aggregate_id, aggregate_type, event_type, status, attempts, next_attempt_at, last_error, locked_by, locked_until, created_at"
+
+// ListOutboxEvents คืน outbox event หนึ่งหน้าตาม filter เรียงจาก ID มากไปน้อย ไม่รวม payload
+// อ่านเกินหนึ่งแถวเพื่อรู้ว่ามีหน้าถัดไปหรือไม่ แทนการเดาจากว่าหน้าเต็ม
+func (r *mySQLRepository) ListOutboxEvents(ctx context.Context, dbtx ports.DBTX, filter domain.OutboxEventFilter) (*domain.OutboxEventPage, error) {
+	var (
+		conditions []string
+		args       []interface{}
+	)
+	if len(filter.Statuses) > 0 {
+		conditions = append(conditions, "status IN ("+inPlaceholders(len(filter.Statuses))+")")
+		for _, s := range filter.Statuses {
+			args = append(args, s)
+		}
+	}
+	if filter.AggregateType != "" {
+		conditions = append(conditions, "aggregate_type = ?")
+		args = append(args, filter.AggregateType)
+	}
+	if filter.AggregateID != "" {
+		conditions = append(conditions, "aggregate_id = ?")
+		args = append(args, filter.AggregateID)
+	}
+	if filter.EventType != "" {
+		conditions = append(conditions, "event_type = ?")
+		args = append(args, filter.EventType)
+	}
+	if filter.CreatedAfter != nil {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, *filter.CreatedBefore)
+	}
+	if filter.BeforeID > 0 {
+		conditions = append(conditions, "id < ?")
+		args = append(args, filter.BeforeID)
+	}
+
+	query := "SELECT " + outboxEventColumns + " FROM outbox_events"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY id DESC LIMIT ?"
+	args = append(args, filter.Limit+1)
+
+	rows, err := dbtx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list outbox events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []*domain.OutboxEvent{}
+	for rows.Next() {
+		event, err := scanOutboxEvent(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list outbox events: %w", err)
+	}
+
+	page := &domain.OutboxEventPage{Events: events}
+	if len(events) > filter.Limit {
+		page.Events = events[:filter.Limit]
+		page.NextBeforeID = page.Events[filter.Limit-1].ID
+	}
+	return page, nil
+}
+
+// GetOutboxEvent คืน outbox event หนึ่งรายการพร้อม payload คืน NotFoundError ถ้าไม่พบ
+func (r *mySQLRepository) GetOutboxEvent(ctx context.Context, dbtx ports.DBTX, id int64) (*domain.OutboxEvent, error) {
+	var payload []byte
+	row := dbtx.QueryRowContext(ctx, "SELECT "+outboxEventColumns+", payload FROM outbox_events WHERE id = ?", id)
+	event, err := scanOutboxEvent(func(dest ...interface{}) error {
+		return row.Scan(append(dest, &payload)...)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.NewNotFoundError("outbox event", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(payload) > 0 {
+		event.Payload = json.RawMessage(payload)
+	}
+	return event, nil
+}
+
+// GetOutboxStats นับ event ของแต่ละสถานะ และหาอายุของ event ที่ pending นานที่สุด
+// อายุคำนวณด้วย NOW() ของ MySQL เพื่อไม่ให้ขึ้นกับ timezone ของ connection
+func (r *mySQLRepository) GetOutboxStats(ctx context.Context, dbtx ports.DBTX) (*domain.OutboxStats, error) {
+	stats := &domain.OutboxStats{Counts: make(map[string]int64, len(domain.OutboxStatuses))}
+	for _, s := range domain.OutboxStatuses {
+		stats.Counts[s] = 0
+	}
+
+	rows, err := dbtx.QueryContext(ctx, "SELECT status, COUNT(*) FROM outbox_events GROUP BY status")
+	if err != nil {
+		return nil, fmt.Errorf("failed to count outbox events: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			status string
+			count  int64
+		)
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan outbox event count: %w", err)
+		}
+		stats.Counts[status] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var (
+		oldest sql.NullTime
+		age    sql.NullInt64
+	)
+	err = dbtx.QueryRowContext(ctx,
+		"SELECT MIN(created_at), TIMESTAMPDIFF(SECOND, MIN(created_at), NOW()) FROM outbox_events WHERE status = 'pending'").
+		Scan(&oldest, &age)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get oldest pending outbox event: %w", err)
+	}
+	if oldest.Valid {
+		stats.OldestPendingCreatedAt = &oldest.Time
+		stats.OldestPendingAgeSeconds = age.Int64
+	}
+	return stats, nil
+}
+
+// RequeueOutboxEvents ย้าย event ที่เลือกซึ่งอยู่ในสถานะที่ requeue ได้กลับไปเป็น pending และเริ่มนับ attempts ใหม่
+// last_error ถูกเก็บไว้เพื่อดูย้อนหลัง คืนจำนวน event ที่ถูกเปลี่ยน
+func (r *mySQLRepository) RequeueOutboxEvents(ctx context.Context, dbtx ports.DBTX, ids []int64) (int64, error) {
+	query := `
+		UPDATE outbox_events
+		SET status = 'pending', attempts = 0, next_attempt_at = NULL, locked_by = NULL, locked_until = NULL
+		WHERE id IN (` + inPlaceholders(len(ids)) + `) AND status IN (` + inPlaceholders(len(domain.RequeueableOutboxStatuses)) + `)`
+	args := int64sToInterfaces(ids)
+	for _, s := range domain.RequeueableOutboxStatuses {
+		args = append(args, s)
+	}
+	res, err := dbtx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to requeue outbox events: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// PurgeOutboxEvents ลบ event ที่เลือก ยกเว้น event ที่ worker กำลังประมวลผลอยู่ คืนจำนวน event ที่ถูกลบ
+func (r *mySQLRepository) PurgeOutboxEvents(ctx context.Context, dbtx ports.DBTX, ids []int64) (int64, error) {
+	query := "DELETE FROM outbox_events WHERE id IN (" + inPlaceholders(len(ids)) + ") AND status <> 'processing'"
+	res, err := dbtx.ExecContext(ctx, query, int64sToInterfaces(ids)...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge outbox events: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// CreateOutboxAuditEntry บันทึกการกระทำของผู้ดูแลลงตาราง outbox_admin_audit
+func (r *mySQLRepository) CreateOutboxAuditEntry(ctx context.Context, dbtx ports.DBTX, entry domain.OutboxAuditEntry) error {
+	eventIDs, err := json.Marshal(entry.EventIDs)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit event ids: %w", err)
+	}
+	query := "INSERT INTO outbox_admin_audit (actor, action, event_ids, affected) VALUES (?, ?, ?, ?)"
+	if _, err := dbtx.ExecContext(ctx, query, entry.Actor, entry.Action, eventIDs, entry.Affected); err != nil {
+		return fmt.Errorf("failed to write outbox audit entry: %w", err)
+	}
+	return nil
+}
+
+// ListOutboxAuditEntries คืนบันทึกล่าสุดของการกระทำของผู้ดูแล เรียงจากใหม่ไปเก่า
+func (r *mySQLRepository) ListOutboxAuditEntries(ctx context.Context, dbtx ports.DBTX, limit int) ([]*domain.OutboxAuditEntry, error) {
+	rows, err := dbtx.QueryContext(ctx,
+		"SELECT id, actor, action, event_ids, affected, created_at FROM outbox_admin_audit ORDER BY id DESC LIMIT ?", limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list outbox audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []*domain.OutboxAuditEntry{}
+	for rows.Next() {
+		var (
+			entry    domain.OutboxAuditEntry
+			eventIDs []byte
+		)
+		if err := rows.Scan(&entry.ID, &entry.Actor, &entry.Action, &eventIDs, &entry.Affected, &entry.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan outbox audit entry: %w", err)
+		}
+		if err := json.Unmarshal(eventIDs, &entry.EventIDs); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal audit event ids: %w", err)
+		}
+		entries = append(entries, &entry)
+	}
+	return entries, rows.Err()
+}
+
+// scanOutboxEvent อ่านคอลัมน์ตาม outboxEventColumns
+func scanOutboxEvent(scan func(dest ...interface{}) error) (*domain.OutboxEvent, error) {
+	var (
+		event                    domain.OutboxEvent
+		nextAttemptAt, lockedTil sql.NullTime
+		lastError, lockedBy      sql.NullString
+	)
+	err := scan(&event.ID, &event.AggregateID, &event.AggregateType, &event.EventType, &event.Status, &event.Attempts,
+		&nextAttemptAt, &lastError, &lockedBy, &lockedTil, &event.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan outbox event: %w", err)
+	}
+	if nextAttemptAt.Valid {
+		event.NextAttemptAt = &nextAttemptAt.Time
+	}
+	if lastError.Valid {
+		event.LastError = &lastError.String
+	}
+	if lockedBy.Valid {
+		event.LockedBy = &lockedBy.String
+	}
+	if lockedTil.Valid {
+		event.LockedUntil = &lockedTil.Time
+	}
+	return &event, nil
+}
+
+// inPlaceholders คืน "?, ?, ..." จำนวน n ตัว
+func inPlaceholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+func int64sToInterfaces(values []int64) []interface{} {
+	out := make([]interface{}, len(values))
+	for i, v := range values {
+		out[i] = v
+	}
+	return out
+}
@@ -0,0 +1,55 @@
+package repositories
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"ES/internal/domain"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListOutboxEvents_ReadsOneExtraRowToDetectNextPage(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewMySQLRepository(db)
+	columns := strings.Split(outboxEventColumns, ", ")
+	createdAt := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+	eventRows := func(ids ...int64) *sqlmock.Rows {
+		rows := sqlmock.NewRows(columns)
+		for _, id := range ids {
+			rows.AddRow(id, "1", "branch", "updated", "failed", 3, nil, "boom", nil, nil, createdAt)
+		}
+		return rows
+	}
+
+	// limit 2: แถวที่สามบอกว่ายังมีหน้าถัดไป และไม่ถูกส่งคืน
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT "+outboxEventColumns+" FROM outbox_events WHERE status IN (?) AND id < ? ORDER BY id DESC LIMIT ?")).
+		WithArgs("failed", int64(100), 3).
+		WillReturnRows(eventRows(10, 8, 7))
+	// หน้าสุดท้ายเต็มพอดี: ไม่มีแถวเกิน จึงไม่มีหน้าถัดไป
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT "+outboxEventColumns+" FROM outbox_events WHERE status IN (?) AND id < ? ORDER BY id DESC LIMIT ?")).
+		WithArgs("failed", int64(8), 3).
+		WillReturnRows(eventRows(7, 5))
+
+	filter := domain.OutboxEventFilter{Statuses: []string{"failed"}, BeforeID: 100, Limit: 2}
+	page, err := repo.ListOutboxEvents(context.Background(), db, filter)
+	require.NoError(t, err)
+	require.Len(t, page.Events, 2)
+	assert.Equal(t, int64(8), page.Events[1].ID)
+	assert.Equal(t, int64(8), page.NextBeforeID)
+
+	filter.BeforeID = page.NextBeforeID
+	page, err = repo.ListOutboxEvents(context.Background(), db, filter)
+	require.NoError(t, err)
+	require.Len(t, page.Events, 2)
+	assert.Zero(t, page.NextBeforeID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
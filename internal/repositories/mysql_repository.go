@@ -4,10 +4,12 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"strconv"
 	"strings"
+	"time"
 
 	"ES/internal/domain"
 	"ES/internal/ports"
@@ -39,7 +41,7 @@ func (r *mySQLRepository) CreateBranch(ctx context.Context, dbtx ports.DBTX, nam
 	query := "INSERT INTO branch (name) VALUES (?)"
 	res, err := dbtx.ExecContext(ctx, query, string(jsonName))
 	if err != nil {
-		return 0, fmt.Errorf("failed to insert branch: %w", err)
+		return 0, fmt.Errorf("failed to insert branch: %w", translateMySQLError(err, "branch"))
 	}
 
 	return res.LastInsertId()
@@ -63,26 +65,49 @@ func (r *mySQLRepository) LinkProductsToBranch(ctx context.Context, dbtx ports.D
 
 	query += strings.Join(placeholders, ", ")
 	_, err := dbtx.ExecContext(ctx, query, args...)
-	return err
+	// สินค้าที่ไม่มีอยู่ทำให้ foreign key ล้มเหลว (1452) ซึ่งแปลงเป็น ValidationError ของ product_id
+	return translateMySQLError(err, "branch")
 }
 
-// UpdateBranch อัปเดตข้อมูลชื่อของสาขา
+// UpdateBranch อัปเดตข้อมูลชื่อของสาขา คืน NotFoundError ถ้าไม่มีสาขานี้
 func (r *mySQLRepository) UpdateBranch(ctx context.Context, dbtx ports.DBTX, id int64, name domain.BranchNameJSON) error {
 	jsonName, err := json.Marshal(name)
 	if err != nil {
 		return fmt.Errorf("failed to marshal branch name to JSON: %w", err)
 	}
-	query := "UPDATE branch SET name = ? WHERE id = ?"
-	_, err = dbtx.ExecContext(ctx, query, string(jsonName), id)
-	return err
+	// ตั้ง updated_at เองเพราะ ON UPDATE CURRENT_TIMESTAMP ไม่ทำงานเมื่อชื่อไม่เปลี่ยน ทั้งที่สินค้าหรือความสนใจอาจเปลี่ยน
+	query := "UPDATE branch SET name = ?, updated_at = NOW() WHERE id = ?"
+	res, err := dbtx.ExecContext(ctx, query, string(jsonName), id)
+	if err != nil {
+		return translateMySQLError(err, "branch")
+	}
+	return checkAffected(ctx, dbtx, res, "branch", "branch", id)
+}
+
+// TouchBranches ตั้ง updated_at ของสาขาเป็นเวลาปัจจุบัน ต้องเรียกในทุก transaction ที่เขียน event ของสาขา
+// เพราะ updated_at เปลี่ยนเองเฉพาะเมื่อแถว branch ถูกแก้ แต่ document ของสาขาเปลี่ยนได้จากตารางอื่น
+// (การเชื่อมโยงสินค้าและความสนใจ ที่ตั้ง ราคา) และ filter updated_after/updated_before อาศัยค่านี้
+func (r *mySQLRepository) TouchBranches(ctx context.Context, dbtx ports.DBTX, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	placeholders := make([]string, len(ids))
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+	query := "UPDATE branch SET updated_at = NOW() WHERE id IN (" + strings.Join(placeholders, ", ") + ")"
+	if _, err := dbtx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to touch branches: %w", err)
+	}
+	return nil
 }
 
-// DeleteBranch ลบข้อมูลสาขา
+// DeleteBranch ลบข้อมูลสาขา คืน NotFoundError ถ้าไม่มีสาขานี้
 // เนื่องจากใน Schema มี ON DELETE CASCADE, ข้อมูลในตารางที่เกี่ยวข้องจะถูกลบไปด้วย
 func (r *mySQLRepository) DeleteBranch(ctx context.Context, dbtx ports.DBTX, id int64) error {
-	query := "DELETE FROM branch WHERE id = ?"
-	_, err := dbtx.ExecContext(ctx, query, id)
-	return err
+	return deleteByID(ctx, dbtx, "branch", "branch", id)
 }
 
 // UnlinkAllProductsFromBranch ลบการเชื่อมโยงสินค้่าทั้งหมดของสาขา
@@ -92,51 +117,454 @@ func (r *mySQLRepository) UnlinkAllProductsFromBranch(ctx context.Context, dbtx
 	return err
 }
 
+// GetBranchLocation ดึงที่ตั้งของสาขา คืน NotFoundError ถ้าสาขายังไม่มีที่ตั้ง
+func (r *mySQLRepository) GetBranchLocation(ctx context.Context, dbtx ports.DBTX, branchID int64) (*domain.BranchLocation, error) {
+	query := "SELECT province_id, name, address, latitude, longitude FROM branch_location WHERE branch_id = ?"
+	var provinceID int
+	var location locationColumns
+	err := dbtx.QueryRowContext(ctx, query, branchID).Scan(&provinceID, &location.name, &location.address, &location.latitude, &location.longitude)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.NewNotFoundError("branch location", branchID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get branch location: %w", err)
+	}
+	return location.toDomain(branchID, provinceID), nil
+}
+
+// UpsertBranchLocation สร้างหรือแทนที่ที่ตั้งของสาขา (branch_id เป็น unique key)
+// คืน NotFoundError ถ้าไม่มีสาขานี้
+func (r *mySQLRepository) UpsertBranchLocation(ctx context.Context, dbtx ports.DBTX, branchID int64, location domain.BranchLocation) error {
+	var name, address, latitude, longitude interface{}
+	if location.Name != nil {
+		jsonName, err := json.Marshal(location.Name)
+		if err != nil {
+			return fmt.Errorf("failed to marshal location name: %w", err)
+		}
+		name = string(jsonName)
+	}
+	if location.Address != "" {
+		address = location.Address
+	}
+	if location.Coordinates != nil {
+		latitude, longitude = location.Coordinates.Lat, location.Coordinates.Lon
+	}
+
+	query := `
+		INSERT INTO branch_location (branch_id, province_id, name, address, latitude, longitude)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			province_id = VALUES(province_id),
+			name = VALUES(name),
+			address = VALUES(address),
+			latitude = VALUES(latitude),
+			longitude = VALUES(longitude)`
+	_, err := dbtx.ExecContext(ctx, query, branchID, location.ProvinceID, name, address, latitude, longitude)
+	if column, _, ok := missingReference(err); ok && column == "branch_id" {
+		return domain.NewNotFoundError("branch", branchID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to upsert branch location: %w", translateMySQLError(err, "branch location"))
+	}
+	return nil
+}
+
+// DeleteBranchLocation ลบที่ตั้งของสาขา คืน NotFoundError ถ้าสาขายังไม่มีที่ตั้ง
+func (r *mySQLRepository) DeleteBranchLocation(ctx context.Context, dbtx ports.DBTX, branchID int64) error {
+	res, err := dbtx.ExecContext(ctx, "DELETE FROM branch_location WHERE branch_id = ?", branchID)
+	if err != nil {
+		return fmt.Errorf("failed to delete branch location: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if affected == 0 {
+		return domain.NewNotFoundError("branch location", branchID)
+	}
+	return nil
+}
+
+// LinkInterestsToBranch เชื่อมโยงสาขากับความสนใจในตาราง `branches_interests`
+func (r *mySQLRepository) LinkInterestsToBranch(ctx context.Context, dbtx ports.DBTX, branchID int64, interestIDs []int) error {
+	if len(interestIDs) == 0 {
+		return nil // ไม่มีความสนใจให้เชื่อมโยง
+	}
+
+	query := "INSERT INTO branches_interests (branch_id, interest_id) VALUES "
+	var args []interface{}
+	placeholders := []string{}
+
+	for _, interestID := range interestIDs {
+		placeholders = append(placeholders, "(?, ?)")
+		args = append(args, branchID, interestID)
+	}
+
+	query += strings.Join(placeholders, ", ")
+	_, err := dbtx.ExecContext(ctx, query, args...)
+	// ความสนใจที่ไม่มีอยู่ทำให้ foreign key ล้มเหลว (1452) ซึ่งแปลงเป็น ValidationError ของ interest_id
+	return translateMySQLError(err, "branch")
+}
+
+// UnlinkAllInterestsFromBranch ลบการเชื่อมโยงความสนใจทั้งหมดของสาขา
+func (r *mySQLRepository) UnlinkAllInterestsFromBranch(ctx context.Context, dbtx ports.DBTX, branchID int64) error {
+	query := "DELETE FROM branches_interests WHERE branch_id = ?"
+	_, err := dbtx.ExecContext(ctx, query, branchID)
+	return err
+}
+
+// LinkInterestToBranch เชื่อมโยงความสนใจหนึ่งรายการกับสาขา คืน true ถ้าเพิ่มการเชื่อมโยงใหม่
+// และ false ถ้าเชื่อมโยงอยู่แล้ว คืน NotFoundError ถ้าไม่มีสาขาหรือความสนใจนี้
+func (r *mySQLRepository) LinkInterestToBranch(ctx context.Context, dbtx ports.DBTX, branchID, interestID int64) (bool, error) {
+	// ON DUPLICATE KEY แทน INSERT IGNORE เพราะ IGNORE จะลด error ของ foreign key เหลือแค่ warning
+	query := "INSERT INTO branches_interests (branch_id, interest_id) VALUES (?, ?) ON DUPLICATE KEY UPDATE interest_id = interest_id"
+	res, err := dbtx.ExecContext(ctx, query, branchID, interestID)
+	if err != nil {
+		if column, _, ok := missingReference(err); ok {
+			if column == "branch_id" {
+				return false, domain.NewNotFoundError("branch", branchID)
+			}
+			return false, domain.NewNotFoundError("interest", interestID)
+		}
+		return false, fmt.Errorf("failed to link interest to branch: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return affected > 0, nil
+}
+
+// UnlinkInterestFromBranch ลบการเชื่อมโยงความสนใจหนึ่งรายการออกจากสาขา คืน true ถ้ามีการเชื่อมโยงถูกลบ
+func (r *mySQLRepository) UnlinkInterestFromBranch(ctx context.Context, dbtx ports.DBTX, branchID, interestID int64) (bool, error) {
+	query := "DELETE FROM branches_interests WHERE branch_id = ? AND interest_id = ?"
+	res, err := dbtx.ExecContext(ctx, query, branchID, interestID)
+	if err != nil {
+		return false, fmt.Errorf("failed to unlink interest from branch: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return affected > 0, nil
+}
+
 // --- Interest ---
+
+// CreateInterest เพิ่มความสนใจใหม่ลงในตาราง `interest`
+func (r *mySQLRepository) CreateInterest(ctx context.Context, dbtx ports.DBTX, name domain.BranchNameJSON) (int64, error) {
+	return insertNamed(ctx, dbtx, "interest", "interest", name)
+}
+
+// GetInterest ดึงความสนใจหนึ่งรายการ คืน NotFoundError ถ้าไม่พบ
+func (r *mySQLRepository) GetInterest(ctx context.Context, dbtx ports.DBTX, id int64) (*domain.Interest, error) {
+	interest := &domain.Interest{ID: id}
+	var nameJSON sql.NullString
+	err := dbtx.QueryRowContext(ctx, "SELECT name FROM interest WHERE id = ?", id).Scan(&nameJSON)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.NewNotFoundError("interest", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get interest: %w", err)
+	}
+	unmarshalName(nameJSON, &interest.Name, "interest", id)
+	return interest, nil
+}
+
+// ListInterests คืนความสนใจหนึ่งหน้าเรียงตาม ID
+func (r *mySQLRepository) ListInterests(ctx context.Context, dbtx ports.DBTX, page domain.PageQuery) ([]*domain.Interest, error) {
+	rows, err := dbtx.QueryContext(ctx, "SELECT id, name FROM interest WHERE id > ? ORDER BY id ASC LIMIT ?", page.AfterID, page.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list interests: %w", err)
+	}
+	defer rows.Close()
+
+	interests := []*domain.Interest{}
+	for rows.Next() {
+		var interest domain.Interest
+		var nameJSON sql.NullString
+		if err := rows.Scan(&interest.ID, &nameJSON); err != nil {
+			return nil, fmt.Errorf("failed to scan interest: %w", err)
+		}
+		unmarshalName(nameJSON, &interest.Name, "interest", interest.ID)
+		interests = append(interests, &interest)
+	}
+	return interests, rows.Err()
+}
+
 func (r *mySQLRepository) UpdateInterest(ctx context.Context, dbtx ports.DBTX, id int64, name domain.BranchNameJSON) error {
 	jsonName, err := json.Marshal(name)
 	if err != nil {
 		return fmt.Errorf("failed to marshal interest name: %w", err)
 	}
 	query := "UPDATE interest SET name = ? WHERE id = ?"
-	_, err = dbtx.ExecContext(ctx, query, string(jsonName), id)
-	return err
+	res, err := dbtx.ExecContext(ctx, query, string(jsonName), id)
+	if err != nil {
+		return translateMySQLError(err, "interest")
+	}
+	return checkAffected(ctx, dbtx, res, "interest", "interest", id)
 }
 
 func (r *mySQLRepository) DeleteInterest(ctx context.Context, dbtx ports.DBTX, id int64) error {
-	query := "DELETE FROM interest WHERE id = ?"
-	_, err := dbtx.ExecContext(ctx, query, id)
-	return err
+	return deleteByID(ctx, dbtx, "interest", "interest", id)
 }
 
 // --- Product ---
+
+// CreateProduct เพิ่มสินค้าใหม่ลงในตาราง `product`
+func (r *mySQLRepository) CreateProduct(ctx context.Context, dbtx ports.DBTX, name domain.BranchNameJSON) (int64, error) {
+	return insertNamed(ctx, dbtx, "product", "product", name)
+}
+
+// GetProduct ดึงสินค้าหนึ่งรายการ คืน NotFoundError ถ้าไม่พบ
+func (r *mySQLRepository) GetProduct(ctx context.Context, dbtx ports.DBTX, id int64) (*domain.Product, error) {
+	product, err := scanProduct(dbtx.QueryRowContext(ctx, "SELECT id, name, updated_at FROM product WHERE id = ?", id).Scan)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.NewNotFoundError("product", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+	return product, nil
+}
+
+// ListProducts คืนสินค้าหนึ่งหน้าเรียงตาม ID
+func (r *mySQLRepository) ListProducts(ctx context.Context, dbtx ports.DBTX, page domain.PageQuery) ([]*domain.Product, error) {
+	rows, err := dbtx.QueryContext(ctx, "SELECT id, name, updated_at FROM product WHERE id > ? ORDER BY id ASC LIMIT ?", page.AfterID, page.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list products: %w", err)
+	}
+	defer rows.Close()
+
+	products := []*domain.Product{}
+	for rows.Next() {
+		product, err := scanProduct(rows.Scan)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan product: %w", err)
+		}
+		products = append(products, product)
+	}
+	return products, rows.Err()
+}
+
+func scanProduct(scan func(dest ...interface{}) error) (*domain.Product, error) {
+	var product domain.Product
+	var nameJSON sql.NullString
+	var updatedAt sql.NullTime
+	if err := scan(&product.ID, &nameJSON, &updatedAt); err != nil {
+		return nil, err
+	}
+	unmarshalName(nameJSON, &product.Name, "product", product.ID)
+	if updatedAt.Valid {
+		product.UpdatedAt = &updatedAt.Time
+	}
+	return &product, nil
+}
+
 func (r *mySQLRepository) UpdateProduct(ctx context.Context, dbtx ports.DBTX, id int64, name domain.BranchNameJSON) error {
 	jsonName, err := json.Marshal(name)
 	if err != nil {
 		return fmt.Errorf("failed to marshal product name: %w", err)
 	}
 	query := "UPDATE product SET name = ? WHERE id = ?"
-	_, err = dbtx.ExecContext(ctx, query, string(jsonName), id)
-	return err
+	res, err := dbtx.ExecContext(ctx, query, string(jsonName), id)
+	if err != nil {
+		return translateMySQLError(err, "product")
+	}
+	return checkAffected(ctx, dbtx, res, "product", "product", id)
 }
 
 func (r *mySQLRepository) DeleteProduct(ctx context.Context, dbtx ports.DBTX, id int64) error {
-	query := "DELETE FROM product WHERE id = ?"
-	_, err := dbtx.ExecContext(ctx, query, id)
-	return err
+	return deleteByID(ctx, dbtx, "product", "product", id)
 }
 
 // --- Product Option ---
+
+// CreateProductOption เพิ่มตัวเลือกราคาของสินค้า คืน NotFoundError ถ้าไม่มีสินค้านี้
+func (r *mySQLRepository) CreateProductOption(ctx context.Context, dbtx ports.DBTX, productID int64, normalPrice, tagthaiPrice float64) (int64, error) {
+	query := "INSERT INTO product_option (product_id, normal_price_thb, tagthai_price_thb) VALUES (?, ?, ?)"
+	res, err := dbtx.ExecContext(ctx, query, productID, normalPrice, tagthaiPrice)
+	if _, _, ok := missingReference(err); ok {
+		return 0, domain.NewNotFoundError("product", productID)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert product option: %w", translateMySQLError(err, "product option"))
+	}
+	return res.LastInsertId()
+}
+
+// GetProductOption ดึงตัวเลือกราคาหนึ่งรายการ คืน NotFoundError ถ้าไม่พบ
+func (r *mySQLRepository) GetProductOption(ctx context.Context, dbtx ports.DBTX, id int64) (*domain.ProductOption, error) {
+	query := "SELECT id, product_id, normal_price_thb, tagthai_price_thb FROM product_option WHERE id = ?"
+	option, err := scanProductOption(dbtx.QueryRowContext(ctx, query, id).Scan)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.NewNotFoundError("product option", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product option: %w", err)
+	}
+	return option, nil
+}
+
+// ListProductOptions คืนตัวเลือกราคาของสินค้าหนึ่งหน้าเรียงตาม ID คืน NotFoundError ถ้าไม่มีสินค้านี้
+// LEFT JOIN จากตาราง product ทำให้รู้ว่าสินค้ามีอยู่จริงใน query เดียวกัน: ไม่มีแถวเลยคือไม่มีสินค้า
+// ส่วนแถวที่ตัวเลือกเป็น NULL คือสินค้าที่ไม่มีตัวเลือก (หรือไม่มีตัวเลือกหลัง AfterID แล้ว)
+func (r *mySQLRepository) ListProductOptions(ctx context.Context, dbtx ports.DBTX, productID int64, page domain.PageQuery) ([]*domain.ProductOption, error) {
+	query := `
+		SELECT product_option.id, product.id, product_option.normal_price_thb, product_option.tagthai_price_thb
+		FROM product
+		LEFT JOIN product_option ON product_option.product_id = product.id AND product_option.id > ?
+		WHERE product.id = ?
+		ORDER BY product_option.id ASC
+		LIMIT ?`
+	rows, err := dbtx.QueryContext(ctx, query, page.AfterID, productID, page.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list product options: %w", err)
+	}
+	defer rows.Close()
+
+	found := false
+	options := []*domain.ProductOption{}
+	for rows.Next() {
+		found = true
+		var optionID sql.NullInt64
+		var option domain.ProductOption
+		var normalPrice, tagthaiPrice sql.NullFloat64
+		if err := rows.Scan(&optionID, &option.ProductID, &normalPrice, &tagthaiPrice); err != nil {
+			return nil, fmt.Errorf("failed to scan product option: %w", err)
+		}
+		if !optionID.Valid {
+			continue
+		}
+		option.ID = optionID.Int64
+		if normalPrice.Valid {
+			option.NormalPrice = &normalPrice.Float64
+		}
+		if tagthaiPrice.Valid {
+			option.TagthaiPrice = &tagthaiPrice.Float64
+		}
+		options = append(options, &option)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list product options: %w", err)
+	}
+	if !found {
+		return nil, domain.NewNotFoundError("product", productID)
+	}
+	return options, nil
+}
+
+func scanProductOption(scan func(dest ...interface{}) error) (*domain.ProductOption, error) {
+	var option domain.ProductOption
+	var normalPrice, tagthaiPrice sql.NullFloat64
+	if err := scan(&option.ID, &option.ProductID, &normalPrice, &tagthaiPrice); err != nil {
+		return nil, err
+	}
+	if normalPrice.Valid {
+		option.NormalPrice = &normalPrice.Float64
+	}
+	if tagthaiPrice.Valid {
+		option.TagthaiPrice = &tagthaiPrice.Float64
+	}
+	return &option, nil
+}
+
 func (r *mySQLRepository) UpdateProductOption(ctx context.Context, dbtx ports.DBTX, id int64, normalPrice, tagthaiPrice float64) error {
 	query := "UPDATE product_option SET normal_price_thb = ?, tagthai_price_thb = ? WHERE id = ?"
-	_, err := dbtx.ExecContext(ctx, query, normalPrice, tagthaiPrice, id)
-	return err
+	res, err := dbtx.ExecContext(ctx, query, normalPrice, tagthaiPrice, id)
+	if err != nil {
+		return translateMySQLError(err, "product option")
+	}
+	return checkAffected(ctx, dbtx, res, "product_option", "product option", id)
 }
 
 func (r *mySQLRepository) DeleteProductOption(ctx context.Context, dbtx ports.DBTX, id int64) error {
-	query := "DELETE FROM product_option WHERE id = ?"
-	_, err := dbtx.ExecContext(ctx, query, id)
-	return err
+	return deleteByID(ctx, dbtx, "product_option", "product option", id)
+}
+
+// insertNamed เพิ่มแถวที่มีเพียงคอลัมน์ name (JSON) ลงใน table แล้วคืน ID ของแถวใหม่
+func insertNamed(ctx context.Context, dbtx ports.DBTX, table, resource string, name domain.BranchNameJSON) (int64, error) {
+	jsonName, err := json.Marshal(name)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal %s name: %w", resource, err)
+	}
+	res, err := dbtx.ExecContext(ctx, "INSERT INTO "+table+" (name) VALUES (?)", string(jsonName))
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert %s: %w", resource, translateMySQLError(err, resource))
+	}
+	return res.LastInsertId()
+}
+
+// unmarshalName แปลงคอลัมน์ name (JSON) เป็น BranchNameJSON ถ้าแปลงไม่ได้จะ log ไว้และปล่อยชื่อว่าง
+func unmarshalName(nameJSON sql.NullString, name *domain.BranchNameJSON, resource string, id int64) {
+	if !nameJSON.Valid {
+		return
+	}
+	if err := json.Unmarshal([]byte(nameJSON.String), name); err != nil {
+		log.Printf("WARNING: could not unmarshal %s name for id %d: %v", resource, id, err)
+	}
+}
+
+// deleteByID ลบแถวที่มี id นี้จาก table คืน NotFoundError ถ้าไม่มีแถวนี้
+// และ ConflictError ถ้าแถวยังถูกอ้างอิงด้วย foreign key ที่ไม่ได้ตั้ง ON DELETE CASCADE
+func deleteByID(ctx context.Context, dbtx ports.DBTX, table, resource string, id int64) error {
+	res, err := dbtx.ExecContext(ctx, "DELETE FROM "+table+" WHERE id = ?", id)
+	if err != nil {
+		return translateMySQLError(err, resource)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if affected == 0 {
+		return domain.NewNotFoundError(resource, id)
+	}
+	return nil
+}
+
+// --- Affected Branches ---
+// ใช้หาสาขาที่ได้รับผลกระทบเมื่อข้อมูลที่ถูก denormalize ลงใน document ของสาขาเปลี่ยนแปลง
+// ต้องเรียกก่อนการลบ เพราะ ON DELETE CASCADE จะลบแถวในตารางเชื่อมโยงไปด้วย
+
+// GetBranchIDsByProduct คืน ID ของสาขาทั้งหมดที่เชื่อมโยงกับสินค้านี้
+func (r *mySQLRepository) GetBranchIDsByProduct(ctx context.Context, dbtx ports.DBTX, productID int64) ([]int64, error) {
+	query := "SELECT DISTINCT branch_id FROM branches_products WHERE product_id = ? ORDER BY branch_id"
+	return queryBranchIDs(ctx, dbtx, query, productID)
+}
+
+// GetBranchIDsByInterest คืน ID ของสาขาทั้งหมดที่เชื่อมโยงกับความสนใจนี้
+func (r *mySQLRepository) GetBranchIDsByInterest(ctx context.Context, dbtx ports.DBTX, interestID int64) ([]int64, error) {
+	query := "SELECT DISTINCT branch_id FROM branches_interests WHERE interest_id = ? ORDER BY branch_id"
+	return queryBranchIDs(ctx, dbtx, query, interestID)
+}
+
+// GetBranchIDsByProductOption คืน ID ของสาขาทั้งหมดที่ขายสินค้าซึ่งเป็นเจ้าของตัวเลือกสินค้านี้
+func (r *mySQLRepository) GetBranchIDsByProductOption(ctx context.Context, dbtx ports.DBTX, productOptionID int64) ([]int64, error) {
+	query := `
+		SELECT DISTINCT bp.branch_id
+		FROM product_option po
+		JOIN branches_products bp ON bp.product_id = po.product_id
+		WHERE po.id = ?
+		ORDER BY bp.branch_id`
+	return queryBranchIDs(ctx, dbtx, query, productOptionID)
+}
+
+func queryBranchIDs(ctx context.Context, dbtx ports.DBTX, query string, args ...interface{}) ([]int64, error) {
+	rows, err := dbtx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query affected branch ids: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan affected branch id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
 }
 
 // --- Outbox ---
@@ -146,42 +574,80 @@ func (r *mySQLRepository) CreateEvent(ctx context.Context, dbtx ports.DBTX, aggr
 	return err
 }
 
-// GetRichBranchData ดึงข้อมูลสาขาที่สมบูรณ์จากหลายตาราง
-func (r *mySQLRepository) GetRichBranchData(ctx context.Context, dbtx ports.DBTX, id int64) (*domain.Branch, error) {
-	// หมายเหตุ: Query นี้ยังขาดข้อมูล product_ids และมีการ join ที่อาจไม่ตรงกับ schema ปัจจุบัน
-	// เราจะปรับปรุงให้ถูกต้อง
-	const query = `
+// GetLatestOutboxEventID คืน ID ล่าสุดของตาราง outbox_events (0 ถ้ายังไม่มี event)
+// worker ใช้ ID ของ event เป็น external version ของ document ใน Elasticsearch
+// งานที่เขียน document โดยตรง (เช่น backfill) จึงใช้ค่านี้เป็น version เพื่อให้ event ที่เกิดขึ้นหลังจากนี้ชนะเสมอ
+func (r *mySQLRepository) GetLatestOutboxEventID(ctx context.Context, dbtx ports.DBTX) (int64, error) {
+	var id int64
+	if err := dbtx.QueryRowContext(ctx, "SELECT COALESCE(MAX(id), 0) FROM outbox_events").Scan(&id); err != nil {
+		return 0, fmt.Errorf("failed to get latest outbox event id: %w", err)
+	}
+	return id, nil
+}
+
+// GetBranchIDsWithEventsAfter คืน ID ของสาขาที่มี outbox event ซึ่ง ID มากกว่า afterEventID
+// ใช้หาสาขาที่เปลี่ยนแปลงหลังจากจุดที่กำหนด (เช่น ระหว่างที่ backfill กำลังทำงาน)
+func (r *mySQLRepository) GetBranchIDsWithEventsAfter(ctx context.Context, dbtx ports.DBTX, afterEventID int64) ([]int64, error) {
+	query := `
+		SELECT DISTINCT CAST(aggregate_id AS UNSIGNED) AS branch_id
+		FROM outbox_events
+		WHERE aggregate_type = 'branch' AND id > ?
+		ORDER BY branch_id`
+	return queryBranchIDs(ctx, dbtx, query, afterEventID)
+}
+
+// richBranchSelect คือส่วน SELECT ... FROM ของ projection สาขาแบบสมบูรณ์ (รูปแบบเดียวกับ document ใน Elasticsearch)
+// ผู้ใช้ต่อท้ายด้วย WHERE ของตัวเอง แล้วตามด้วย richBranchGroupBy
+const richBranchSelect = richBranchFields + richBranchFrom
+
+// listedBranchSelect คือ richBranchSelect ที่มี updated_at เพิ่มเป็นคอลัมน์สุดท้าย ใช้เฉพาะ ListRichBranches
+// updated_at ไม่อยู่ใน projection หลัก เพราะ projection นั้นคือ document ใน index และ payload ของ outbox
+const listedBranchSelect = richBranchFields + `,
+			branch.updated_at` + richBranchFrom
+
+const richBranchFields = `
 		SELECT
 			branch.id,
 			branch.name,
 			ANY_VALUE(branch_location.province_id) AS province_id,
+			ANY_VALUE(branch_location.name) AS location_name,
+			ANY_VALUE(branch_location.address) AS location_address,
+			ANY_VALUE(branch_location.latitude) AS latitude,
+			ANY_VALUE(branch_location.longitude) AS longitude,
 			(SELECT GROUP_CONCAT(DISTINCT p.product_id) FROM branches_products p WHERE p.branch_id = branch.id) AS product_ids,
 			(SELECT GROUP_CONCAT(DISTINCT i.interest_id) FROM branches_interests i WHERE i.branch_id = branch.id) AS interest_ids,
 			(SELECT MIN(po.normal_price_thb) FROM product_option po JOIN branches_products bp ON po.product_id = bp.product_id WHERE bp.branch_id = branch.id) as min_normal_price,
 			(SELECT MAX(po.normal_price_thb) FROM product_option po JOIN branches_products bp ON po.product_id = bp.product_id WHERE bp.branch_id = branch.id) as max_normal_price,
 			(SELECT MIN(po.tagthai_price_thb) FROM product_option po JOIN branches_products bp ON po.product_id = bp.product_id WHERE bp.branch_id = branch.id) as min_tagthai_price,
-			(SELECT MAX(po.tagthai_price_thb) FROM product_option po JOIN branches_products bp ON po.product_id = bp.product_id WHERE bp.branch_id = branch.id) as max_tagthai_price
+			(SELECT MAX(po.tagthai_price_thb) FROM product_option po JOIN branches_products bp ON po.product_id = bp.product_id WHERE bp.branch_id = branch.id) as max_tagthai_price`
+
+const richBranchFrom = `
 		FROM
 			branch
 		LEFT JOIN
-			branch_location ON branch.id = branch_location.branch_id
-		WHERE
-			branch.id = ?
-		GROUP BY
-			branch.id;
-	`
+			branch_location ON branch.id = branch_location.branch_id`
 
-	row := dbtx.QueryRowContext(ctx, query, id)
+const richBranchGroupBy = `
+		GROUP BY
+			branch.id`
 
+// scanRichBranch อ่านหนึ่งแถวของ richBranchSelect แล้วแปลงเป็น domain.Branch
+// scan คือ Scan ของ *sql.Row หรือ *sql.Rows
+func scanRichBranch(scan func(dest ...interface{}) error) (*domain.Branch, error) {
 	var branch domain.Branch
 	var nameJSON, productIDsStr, interestIDsStr sql.NullString
 	var provinceID sql.NullInt64
+	var location locationColumns
 	var minNormalPrice, maxNormalPrice, minTagthaiPrice, maxTagthaiPrice sql.NullFloat64
 
-	err := row.Scan(
+	err := scan(
 		&branch.ID,
 		&nameJSON,
 		&provinceID,
+		&location.name,
+		&location.address,
+		&location.latitude,
+		&location.longitude,
 		&productIDsStr,
 		&interestIDsStr,
 		&minNormalPrice,
@@ -190,40 +656,22 @@ func (r *mySQLRepository) GetRichBranchData(ctx context.Context, dbtx ports.DBTX
 		&maxTagthaiPrice,
 	)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("branch with id %d not found", id)
-		}
-		return nil, fmt.Errorf("failed to scan rich branch data: %w", err)
+		return nil, err
 	}
 
 	// แปลงข้อมูล JSON และ String ที่ได้จาก DB
 	if nameJSON.Valid {
 		if err := json.Unmarshal([]byte(nameJSON.String), &branch.Name); err != nil {
-			log.Printf("WARNING: could not unmarshal branch name for id %d: %v", id, err)
+			log.Printf("WARNING: could not unmarshal branch name for id %d: %v", branch.ID, err)
 		}
 	}
 
 	if provinceID.Valid {
-		branch.Location = &domain.BranchLocation{ProvinceID: int(provinceID.Int64)}
+		branch.Location = location.toDomain(branch.ID, int(provinceID.Int64))
 	}
 
-	if productIDsStr.Valid && productIDsStr.String != "" {
-		ids := strings.Split(productIDsStr.String, ",")
-		branch.ProductIDs = make([]int, 0, len(ids))
-		for _, idStr := range ids {
-			id, _ := strconv.Atoi(idStr)
-			branch.ProductIDs = append(branch.ProductIDs, id)
-		}
-	}
-
-	if interestIDsStr.Valid && interestIDsStr.String != "" {
-		ids := strings.Split(interestIDsStr.String, ",")
-		branch.InterestIDs = make([]int, 0, len(ids))
-		for _, idStr := range ids {
-			id, _ := strconv.Atoi(idStr)
-			branch.InterestIDs = append(branch.InterestIDs, id)
-		}
-	}
+	branch.ProductIDs = splitIntList(productIDsStr)
+	branch.InterestIDs = splitIntList(interestIDsStr)
 
 	if minNormalPrice.Valid {
 		branch.MinNormalPrice = &minNormalPrice.Float64
@@ -241,80 +689,294 @@ func (r *mySQLRepository) GetRichBranchData(ctx context.Context, dbtx ports.DBTX
 	return &branch, nil
 }
 
-// GetAllRichBranchData ดึงข้อมูลสาขาที่สมบูรณ์ทั้งหมดใน query เดียวเพื่อทำ backfill
-func (r *mySQLRepository) GetAllRichBranchData(ctx context.Context, dbtx ports.DBTX) ([]*domain.Branch, error) {
-	const query = `
-		SELECT
-			branch.id,
-			branch.name,
-			ANY_VALUE(branch_location.province_id) AS province_id,
-			(SELECT GROUP_CONCAT(DISTINCT p.product_id) FROM branches_products p WHERE p.branch_id = branch.id) AS product_ids,
-			(SELECT GROUP_CONCAT(DISTINCT i.interest_id) FROM branches_interests i WHERE i.branch_id = branch.id) AS interest_ids,
-			(SELECT MIN(po.normal_price_thb) FROM product_option po JOIN branches_products bp ON po.product_id = bp.product_id WHERE bp.branch_id = branch.id) as min_normal_price,
-			(SELECT MAX(po.normal_price_thb) FROM product_option po JOIN branches_products bp ON po.product_id = bp.product_id WHERE bp.branch_id = branch.id) as max_normal_price,
-			(SELECT MIN(po.tagthai_price_thb) FROM product_option po JOIN branches_products bp ON po.product_id = bp.product_id WHERE bp.branch_id = branch.id) as min_tagthai_price,
-			(SELECT MAX(po.tagthai_price_thb) FROM product_option po JOIN branches_products bp ON po.product_id = bp.product_id WHERE bp.branch_id = branch.id) as max_tagthai_price
-		FROM
-			branch
-		LEFT JOIN
-			branch_location ON branch.id = branch_location.branch_id
-		GROUP BY
-			branch.id
-		ORDER BY
-			branch.id ASC;
-	`
+// scanListedBranch อ่านหนึ่งแถวของ listedBranchSelect (คอลัมน์ของ richBranchSelect ตามด้วย updated_at)
+func scanListedBranch(scan func(dest ...interface{}) error) (*domain.Branch, error) {
+	var updatedAt sql.NullTime
+	branch, err := scanRichBranch(func(dest ...interface{}) error {
+		return scan(append(dest, &updatedAt)...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if updatedAt.Valid {
+		branch.UpdatedAt = &updatedAt.Time
+	}
+	return branch, nil
+}
+
+// locationColumns คือคอลัมน์ของ branch_location ที่อาจเป็น NULL
+type locationColumns struct {
+	name                sql.NullString
+	address             sql.NullString
+	latitude, longitude sql.NullFloat64
+}
+
+// toDomain แปลงคอลัมน์ของ branch_location เป็น domain.BranchLocation
+// พิกัดจะมีค่าเมื่อมีทั้ง latitude และ longitude เท่านั้น
+func (c locationColumns) toDomain(branchID int64, provinceID int) *domain.BranchLocation {
+	location := &domain.BranchLocation{ProvinceID: provinceID, Address: c.address.String}
+	if c.name.Valid {
+		var name domain.BranchNameJSON
+		if err := json.Unmarshal([]byte(c.name.String), &name); err != nil {
+			log.Printf("WARNING: could not unmarshal location name for branch id %d: %v", branchID, err)
+		} else {
+			location.Name = &name
+		}
+	}
+	if c.latitude.Valid && c.longitude.Valid {
+		location.Coordinates = &domain.GeoPoint{Lat: c.latitude.Float64, Lon: c.longitude.Float64}
+	}
+	return location
+}
+
+// splitIntList แปลงผลลัพธ์ของ GROUP_CONCAT เช่น "1,2,3" เป็น []int (nil ถ้าไม่มีค่า)
+func splitIntList(s sql.NullString) []int {
+	if !s.Valid || s.String == "" {
+		return nil
+	}
+	parts := strings.Split(s.String, ",")
+	ids := make([]int, 0, len(parts))
+	for _, p := range parts {
+		id, _ := strconv.Atoi(p)
+		ids = append(ids, id)
+	}
+	return ids
+}
 
-	rows, err := dbtx.QueryContext(ctx, query)
+// queryRichBranches รัน richBranchSelect ตามด้วย where และ order แล้วอ่านทุกแถว
+// แถวที่อ่านไม่ได้ทำให้คืน error แทนการข้ามแถว เพราะผู้เรียกแยกไม่ออกระหว่างแถวที่ถูกข้ามกับสาขาที่ไม่มีอยู่
+// (เช่น StreamRichBranchData จะเข้าใจว่าหน้าว่างคือหมดตารางแล้ว และ verify จะเข้าใจว่าสาขาถูกลบ)
+func queryRichBranches(ctx context.Context, dbtx ports.DBTX, where, order string, args ...interface{}) ([]*domain.Branch, error) {
+	return queryBranches(ctx, dbtx, richBranchSelect+where+richBranchGroupBy+order, scanRichBranch, args...)
+}
+
+// queryBranches รัน query แล้วแปลงทุกแถวด้วย scan
+func queryBranches(ctx context.Context, dbtx ports.DBTX, query string, scan func(func(dest ...interface{}) error) (*domain.Branch, error), args ...interface{}) ([]*domain.Branch, error) {
+	rows, err := dbtx.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query all rich branch data: %w", err)
+		return nil, fmt.Errorf("failed to query rich branch data: %w", err)
 	}
 	defer rows.Close()
 
 	var branches []*domain.Branch
 	for rows.Next() {
-		var branch domain.Branch
-		var nameJSON, productIDsStr, interestIDsStr sql.NullString
-		var provinceID sql.NullInt64
-		var minNormalPrice, maxNormalPrice, minTagthaiPrice, maxTagthaiPrice sql.NullFloat64
-
-		if err := rows.Scan(&branch.ID, &nameJSON, &provinceID, &productIDsStr, &interestIDsStr, &minNormalPrice, &maxNormalPrice, &minTagthaiPrice, &maxTagthaiPrice); err != nil {
-			log.Printf("WARNING: could not scan row for rich branch data: %v", err)
-			continue // ข้ามแถวที่มีปัญหา
+		branch, err := scan(rows.Scan)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan rich branch data: %w", err)
 		}
+		branches = append(branches, branch)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rich branch data: %w", err)
+	}
+	return branches, nil
+}
 
-		if nameJSON.Valid {
-			_ = json.Unmarshal([]byte(nameJSON.String), &branch.Name)
-		}
-		if provinceID.Valid {
-			branch.Location = &domain.BranchLocation{ProvinceID: int(provinceID.Int64)}
+// GetRichBranchData ดึงข้อมูลสาขาที่สมบูรณ์จากหลายตาราง
+func (r *mySQLRepository) GetRichBranchData(ctx context.Context, dbtx ports.DBTX, id int64) (*domain.Branch, error) {
+	query := richBranchSelect + `
+		WHERE
+			branch.id = ?` + richBranchGroupBy
+
+	branch, err := scanRichBranch(dbtx.QueryRowContext(ctx, query, id).Scan)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.NewNotFoundError("branch", id)
 		}
-		if productIDsStr.Valid && productIDsStr.String != "" {
-			ids := strings.Split(productIDsStr.String, ",")
-			branch.ProductIDs = make([]int, len(ids))
-			for i, idStr := range ids {
-				branch.ProductIDs[i], _ = strconv.Atoi(idStr)
-			}
+		return nil, fmt.Errorf("failed to scan rich branch data: %w", err)
+	}
+	return branch, nil
+}
+
+// GetAllRichBranchData ดึงข้อมูลสาขาที่สมบูรณ์ทั้งหมดใน query เดียวเพื่อทำ backfill
+func (r *mySQLRepository) GetAllRichBranchData(ctx context.Context, dbtx ports.DBTX) ([]*domain.Branch, error) {
+	return queryRichBranches(ctx, dbtx, "", `
+		ORDER BY
+			branch.id ASC`)
+}
+
+// StreamRichBranchData อ่านข้อมูลสาขาที่สมบูรณ์ที่ตรงกับ filter ทีละหน้า เรียงตาม ID โดยเริ่มหลัง afterID
+// และเรียก fn กับแต่ละหน้าจนกว่าจะหมด หรือ fn คืน error
+// ใช้ keyset pagination (WHERE branch.id > ?) แทน OFFSET ทำให้ทุกหน้าเร็วเท่ากัน
+// และใช้หน่วยความจำเพียงหนึ่งหน้า ไม่ว่าจะมีสาขากี่สาขา
+// หน้าว่างหมายถึงหมดข้อมูลเท่านั้น แถวที่อ่านไม่ได้ทำให้หยุดพร้อม error แทนการข้ามสาขาที่เหลือไปเงียบๆ
+func (r *mySQLRepository) StreamRichBranchData(ctx context.Context, dbtx ports.DBTX, filter domain.BranchFilter, afterID int64, pageSize int, fn func(page []*domain.Branch) error) error {
+	if pageSize <= 0 {
+		return fmt.Errorf("page size must be positive, got %d", pageSize)
+	}
+	conditions, filterArgs := branchFilterConditions(filter)
+	where := `
+		WHERE
+			branch.id > ?` + conditions
+	for {
+		args := append([]interface{}{afterID}, filterArgs...)
+		args = append(args, pageSize)
+		page, err := queryRichBranches(ctx, dbtx, where, `
+		ORDER BY
+			branch.id ASC
+		LIMIT ?`, args...)
+		if err != nil {
+			return err
 		}
-		if interestIDsStr.Valid && interestIDsStr.String != "" {
-			ids := strings.Split(interestIDsStr.String, ",")
-			branch.InterestIDs = make([]int, len(ids))
-			for i, idStr := range ids {
-				branch.InterestIDs[i], _ = strconv.Atoi(idStr)
-			}
+		if len(page) == 0 {
+			return nil
 		}
-		if minNormalPrice.Valid {
-			branch.MinNormalPrice = &minNormalPrice.Float64
+		if err := fn(page); err != nil {
+			return err
 		}
-		if maxNormalPrice.Valid {
-			branch.MaxNormalPrice = &maxNormalPrice.Float64
+		afterID = page[len(page)-1].ID
+	}
+}
+
+// CountBranches นับสาขาใน MySQL ที่ตรงกับ filter ด้วยเงื่อนไขเดียวกับ StreamRichBranchData
+func (r *mySQLRepository) CountBranches(ctx context.Context, dbtx ports.DBTX, filter domain.BranchFilter) (int64, error) {
+	conditions, args := branchFilterConditions(filter)
+	query := "SELECT COUNT(*)" + richBranchFrom
+	if conditions != "" {
+		query += `
+		WHERE
+			` + strings.TrimPrefix(conditions, " AND ")
+	}
+	var count int64
+	if err := dbtx.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count branches: %w", err)
+	}
+	return count, nil
+}
+
+// branchFilterConditions แปลง filter เป็นเงื่อนไข " AND ..." ต่อท้าย WHERE พร้อม argument
+func branchFilterConditions(filter domain.BranchFilter) (string, []interface{}) {
+	var conditions strings.Builder
+	var args []interface{}
+	if len(filter.IDs) > 0 {
+		placeholders := make([]string, len(filter.IDs))
+		for i, id := range filter.IDs {
+			placeholders[i] = "?"
+			args = append(args, id)
 		}
-		if minTagthaiPrice.Valid {
-			branch.MinTagthaiPrice = &minTagthaiPrice.Float64
+		conditions.WriteString(" AND branch.id IN (" + strings.Join(placeholders, ", ") + ")")
+	}
+	if filter.FromID > 0 {
+		conditions.WriteString(" AND branch.id >= ?")
+		args = append(args, filter.FromID)
+	}
+	if filter.ToID > 0 {
+		conditions.WriteString(" AND branch.id <= ?")
+		args = append(args, filter.ToID)
+	}
+	if filter.UpdatedAfter != nil {
+		conditions.WriteString(" AND branch.updated_at > ?")
+		args = append(args, *filter.UpdatedAfter)
+	}
+	if filter.UpdatedBefore != nil {
+		conditions.WriteString(" AND branch.updated_at < ?")
+		args = append(args, *filter.UpdatedBefore)
+	}
+	if filter.ProvinceID != nil {
+		conditions.WriteString(" AND branch_location.province_id = ?")
+		args = append(args, *filter.ProvinceID)
+	}
+	// EXISTS แทน JOIN เพื่อไม่ให้แถวของสาขาซ้ำ และไม่กระทบ product_ids ที่ projection รวบรวม
+	if len(filter.ProductIDs) > 0 {
+		placeholders, idArgs := intListArgs(filter.ProductIDs)
+		conditions.WriteString(" AND EXISTS (SELECT 1 FROM branches_products fp WHERE fp.branch_id = branch.id AND fp.product_id IN (" + placeholders + "))")
+		args = append(args, idArgs...)
+	}
+	if len(filter.InterestIDs) > 0 {
+		placeholders, idArgs := intListArgs(filter.InterestIDs)
+		conditions.WriteString(" AND EXISTS (SELECT 1 FROM branches_interests fi WHERE fi.branch_id = branch.id AND fi.interest_id IN (" + placeholders + "))")
+		args = append(args, idArgs...)
+	}
+	return conditions.String(), args
+}
+
+// intListArgs คืน placeholder "?, ?, ..." และ argument สำหรับเงื่อนไข IN (...)
+func intListArgs(ids []int) (string, []interface{}) {
+	placeholders := make([]string, len(ids))
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+	return strings.Join(placeholders, ", "), args
+}
+
+// branchSortExprs คือ expression ของคอลัมน์ที่ใช้เรียงตาม BranchListQuery.Sort (นอกจาก ID)
+// ค่า NULL ถูกแทนด้วยค่าคงที่ เพื่อให้เปรียบเทียบกับ cursor ได้ (NULL = ? ไม่เคยเป็นจริง)
+var branchSortExprs = map[string]string{
+	domain.BranchSortName:      "COALESCE(JSON_UNQUOTE(JSON_EXTRACT(branch.name, '$.th')), '')",
+	domain.BranchSortUpdatedAt: "COALESCE(branch.updated_at, TIMESTAMP '1000-01-01 00:00:00')",
+}
+
+// nullUpdatedAt คือค่าที่ branchSortExprs ใช้แทน updated_at ที่เป็น NULL
+var nullUpdatedAt = time.Date(1000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// ListRichBranches ดึงข้อมูลสาขาที่สมบูรณ์หนึ่งหน้าที่ตรงกับ query ใน query เดียว
+// ใช้ keyset pagination: หน้าถัดไปเริ่มหลังค่าที่ใช้เรียงและ ID ของสาขาสุดท้ายใน query.After
+func (r *mySQLRepository) ListRichBranches(ctx context.Context, dbtx ports.DBTX, query domain.BranchListQuery) ([]*domain.Branch, error) {
+	if query.Limit <= 0 {
+		return nil, fmt.Errorf("limit must be positive, got %d", query.Limit)
+	}
+	conditions, args := branchFilterConditions(query.Filter)
+
+	op, direction := ">", "ASC"
+	if query.Desc {
+		op, direction = "<", "DESC"
+	}
+	order := "branch.id " + direction
+	switch query.Sort {
+	case "", domain.BranchSortID:
+		if query.After != nil {
+			conditions += " AND branch.id " + op + " ?"
+			args = append(args, query.After.ID)
 		}
-		if maxTagthaiPrice.Valid {
-			branch.MaxTagthaiPrice = &maxTagthaiPrice.Float64
+	case domain.BranchSortName, domain.BranchSortUpdatedAt:
+		expr := branchSortExprs[query.Sort]
+		order = expr + " " + direction + ", " + order
+		if query.After != nil {
+			var value interface{} = query.After.Name
+			if query.Sort == domain.BranchSortUpdatedAt {
+				value = nullUpdatedAt
+				if query.After.UpdatedAt != nil {
+					value = *query.After.UpdatedAt
+				}
+			}
+			conditions += " AND (" + expr + " " + op + " ? OR (" + expr + " = ? AND branch.id " + op + " ?))"
+			args = append(args, value, value, query.After.ID)
 		}
-		branches = append(branches, &branch)
+	default:
+		return nil, domain.NewValidationError("sort", "must be one of id, name, updated_at")
 	}
-	return branches, nil
+
+	where := ""
+	if conditions != "" {
+		where = `
+		WHERE
+			` + strings.TrimPrefix(conditions, " AND ")
+	}
+	args = append(args, query.Limit)
+	return queryBranches(ctx, dbtx, listedBranchSelect+where+richBranchGroupBy+`
+		ORDER BY
+			`+order+`
+		LIMIT ?`, scanListedBranch, args...)
+}
+
+// GetRichBranchDataByIDs ดึงข้อมูลสาขาที่สมบูรณ์ของสาขาหลายสาขาใน query เดียว
+// สาขาที่ไม่มีอยู่ (เช่น ถูกลบไปแล้ว) จะไม่อยู่ในผลลัพธ์
+func (r *mySQLRepository) GetRichBranchDataByIDs(ctx context.Context, dbtx ports.DBTX, ids []int64) ([]*domain.Branch, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	placeholders := make([]string, len(ids))
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+	where := `
+		WHERE
+			branch.id IN (` + strings.Join(placeholders, ", ") + `)`
+	return queryRichBranches(ctx, dbtx, where, `
+		ORDER BY
+			branch.id ASC`, args...)
 }
@@ -0,0 +1,238 @@
+package repositories
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"ES/internal/domain"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var richBranchColumns = []string{
+	"id", "name", "province_id", "location_name", "location_address", "latitude", "longitude", "product_ids", "interest_ids",
+	"min_normal_price", "max_normal_price", "min_tagthai_price", "max_tagthai_price",
+}
+
+// listedBranchColumns คือคอลัมน์ของ ListRichBranches ซึ่งมี updated_at ต่อท้าย projection หลัก
+var listedBranchColumns = append(append([]string{}, richBranchColumns...), "updated_at")
+
+func TestStreamRichBranchData_PagesWithKeyset(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewMySQLRepository(db)
+
+	// หน้าแรกเริ่มหลัง afterID ที่ส่งเข้ามา หน้าถัดไปเริ่มหลัง ID สุดท้ายของหน้าก่อน
+	mock.ExpectQuery(`WHERE\s+branch.id > \?.*LIMIT \?`).
+		WithArgs(int64(0), 2).
+		WillReturnRows(sqlmock.NewRows(richBranchColumns).
+			AddRow(1, `{"en":"A","th":"ก"}`, 10, `{"en":"Central World","th":"เซ็นทรัลเวิลด์"}`, "999/9 Rama I Rd", 13.746571, 100.539302, "5,6", nil, 100.0, 200.0, nil, nil).
+			AddRow(3, `{"en":"B","th":"ข"}`, nil, nil, nil, nil, nil, nil, "7", nil, nil, nil, nil))
+	mock.ExpectQuery(`WHERE\s+branch.id > \?.*LIMIT \?`).
+		WithArgs(int64(3), 2).
+		WillReturnRows(sqlmock.NewRows(richBranchColumns).
+			AddRow(4, `{"en":"C","th":"ค"}`, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil))
+	mock.ExpectQuery(`WHERE\s+branch.id > \?.*LIMIT \?`).
+		WithArgs(int64(4), 2).
+		WillReturnRows(sqlmock.NewRows(richBranchColumns))
+
+	var pages [][]*domain.Branch
+	err = repo.StreamRichBranchData(context.Background(), db, domain.BranchFilter{}, 0, 2, func(page []*domain.Branch) error {
+		pages = append(pages, page)
+		return nil
+	})
+
+	require.NoError(t, err)
+	require.Len(t, pages, 2)
+	assert.Len(t, pages[0], 2)
+	assert.Equal(t, int64(4), pages[1][0].ID)
+
+	first := pages[0][0]
+	assert.Equal(t, "ก", first.Name.TH)
+	assert.Equal(t, &domain.BranchLocation{
+		ProvinceID:  10,
+		Name:        &domain.BranchNameJSON{EN: "Central World", TH: "เซ็นทรัลเวิลด์"},
+		Address:     "999/9 Rama I Rd",
+		Coordinates: &domain.GeoPoint{Lat: 13.746571, Lon: 100.539302},
+	}, first.Location)
+	assert.Equal(t, []int{5, 6}, first.ProductIDs)
+	assert.Nil(t, first.InterestIDs)
+	require.NotNil(t, first.MaxNormalPrice)
+	assert.Equal(t, 200.0, *first.MaxNormalPrice)
+	assert.Equal(t, []int{7}, pages[0][1].InterestIDs)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStreamRichBranchData_FailsOnUnreadableRow(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewMySQLRepository(db)
+
+	// หน้าที่ทุกแถวอ่านไม่ได้ต้องไม่ถูกตีความว่าหมดตารางแล้ว
+	mock.ExpectQuery(`WHERE\s+branch.id > \?.*LIMIT \?`).
+		WithArgs(int64(0), 2).
+		WillReturnRows(sqlmock.NewRows(richBranchColumns).
+			AddRow(1, `{"en":"A","th":"ก"}`, nil, nil, nil, nil, nil, nil, nil, "not-a-price", nil, nil, nil))
+
+	err = repo.StreamRichBranchData(context.Background(), db, domain.BranchFilter{}, 0, 2, func(page []*domain.Branch) error {
+		t.Fatal("no page expected")
+		return nil
+	})
+
+	assert.ErrorContains(t, err, "failed to scan rich branch data")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStreamRichBranchData_AppliesFilter(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewMySQLRepository(db)
+	updatedAfter := time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC)
+	filter := domain.BranchFilter{IDs: []int64{3, 9}, FromID: 2, ToID: 20, UpdatedAfter: &updatedAfter}
+
+	mock.ExpectQuery(regexp.QuoteMeta("branch.id > ? AND branch.id IN (?, ?) AND branch.id >= ? AND branch.id <= ? AND branch.updated_at > ?")).
+		WithArgs(int64(5), int64(3), int64(9), int64(2), int64(20), updatedAfter, 100).
+		WillReturnRows(sqlmock.NewRows(richBranchColumns))
+
+	err = repo.StreamRichBranchData(context.Background(), db, filter, 5, 100, func(page []*domain.Branch) error {
+		t.Fatal("no page expected")
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListRichBranches_AppliesFilterAndKeysetCursor(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewMySQLRepository(db)
+	provinceID := 10
+	updatedBefore := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	cursorTime := time.Date(2025, 1, 15, 8, 30, 0, 0, time.UTC)
+	query := domain.BranchListQuery{
+		Filter: domain.BranchFilter{
+			ProvinceID:    &provinceID,
+			ProductIDs:    []int{5, 6},
+			InterestIDs:   []int{3},
+			UpdatedBefore: &updatedBefore,
+		},
+		Sort:  domain.BranchSortUpdatedAt,
+		Desc:  true,
+		After: &domain.BranchCursor{Sort: domain.BranchSortUpdatedAt, Desc: true, ID: 7, UpdatedAt: &cursorTime},
+		Limit: 2,
+	}
+
+	updatedAt := time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(regexp.QuoteMeta(`WHERE
+			branch.updated_at < ? AND branch_location.province_id = ?`+
+		` AND EXISTS (SELECT 1 FROM branches_products fp WHERE fp.branch_id = branch.id AND fp.product_id IN (?, ?))`+
+		` AND EXISTS (SELECT 1 FROM branches_interests fi WHERE fi.branch_id = branch.id AND fi.interest_id IN (?))`+
+		` AND (COALESCE(branch.updated_at, TIMESTAMP '1000-01-01 00:00:00') < ? OR (COALESCE(branch.updated_at, TIMESTAMP '1000-01-01 00:00:00') = ? AND branch.id < ?))`)+
+		`.*`+regexp.QuoteMeta(`ORDER BY
+			COALESCE(branch.updated_at, TIMESTAMP '1000-01-01 00:00:00') DESC, branch.id DESC
+		LIMIT ?`)).
+		WithArgs(updatedBefore, provinceID, 5, 6, 3, cursorTime, cursorTime, int64(7), 2).
+		WillReturnRows(sqlmock.NewRows(listedBranchColumns).
+			AddRow(5, `{"en":"A","th":"ก"}`, 10, nil, nil, nil, nil, "5", "3", nil, nil, nil, nil, updatedAt))
+
+	branches, err := repo.ListRichBranches(context.Background(), db, query)
+
+	require.NoError(t, err)
+	require.Len(t, branches, 1)
+	assert.Equal(t, int64(5), branches[0].ID)
+	assert.Equal(t, &updatedAt, branches[0].UpdatedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListRichBranches_SortsByIDWithoutFilter(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewMySQLRepository(db)
+
+	mock.ExpectQuery(`GROUP BY\s+branch.id\s+ORDER BY\s+branch.id ASC\s+LIMIT \?$`).
+		WithArgs(50).
+		WillReturnRows(sqlmock.NewRows(listedBranchColumns))
+
+	branches, err := repo.ListRichBranches(context.Background(), db, domain.BranchListQuery{Limit: 50})
+	require.NoError(t, err)
+	assert.Empty(t, branches)
+
+	_, err = repo.ListRichBranches(context.Background(), db, domain.BranchListQuery{Sort: "province", Limit: 50})
+	assert.ErrorIs(t, err, domain.ErrValidation)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCountBranches_AppliesFilter(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewMySQLRepository(db)
+	provinceID := 10
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\)\s+FROM\s+branch\s+LEFT JOIN\s+branch_location ON branch.id = branch_location.branch_id\s+WHERE\s+branch.id >= \? AND branch_location.province_id = \?$`).
+		WithArgs(int64(5), provinceID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(42))
+
+	count, err := repo.CountBranches(context.Background(), db, domain.BranchFilter{FromID: 5, ProvinceID: &provinceID})
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), count)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListProductOptions_MissingProductIsNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewMySQLRepository(db)
+	columns := []string{"id", "product_id", "normal_price_thb", "tagthai_price_thb"}
+	query := regexp.QuoteMeta("LEFT JOIN product_option ON product_option.product_id = product.id AND product_option.id > ?")
+
+	// มีสินค้าและตัวเลือก
+	mock.ExpectQuery(query).
+		WithArgs(int64(0), int64(5), 3).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(8, 5, 300.0, 270.0).AddRow(9, 5, 350.0, nil))
+	// มีสินค้าแต่ไม่มีตัวเลือก: LEFT JOIN คืนหนึ่งแถวที่ตัวเลือกเป็น NULL
+	mock.ExpectQuery(query).
+		WithArgs(int64(9), int64(5), 3).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(nil, 5, nil, nil))
+	// ไม่มีสินค้า: ไม่มีแถวเลย
+	mock.ExpectQuery(query).
+		WithArgs(int64(0), int64(99), 3).
+		WillReturnRows(sqlmock.NewRows(columns))
+
+	options, err := repo.ListProductOptions(context.Background(), db, 5, domain.PageQuery{Limit: 3})
+	require.NoError(t, err)
+	require.Len(t, options, 2)
+	assert.Equal(t, int64(9), options[1].ID)
+	assert.Nil(t, options[1].TagthaiPrice)
+
+	options, err = repo.ListProductOptions(context.Background(), db, 5, domain.PageQuery{AfterID: 9, Limit: 3})
+	require.NoError(t, err)
+	assert.Empty(t, options)
+	assert.NotNil(t, options)
+
+	_, err = repo.ListProductOptions(context.Background(), db, 99, domain.PageQuery{Limit: 3})
+	var notFound *domain.NotFoundError
+	require.ErrorAs(t, err, &notFound)
+	assert.Equal(t, "product", notFound.Resource)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
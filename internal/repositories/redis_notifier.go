@@ -0,0 +1,31 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// outboxNotification คือข้อความที่ส่งไปยัง channel ของ Outbox worker ไม่ได้อ่านเนื้อหา แค่ใช้เป็นสัญญาณ
+const outboxNotification = "new_event"
+
+// redisOutboxNotifier คือ implementation ของ OutboxNotifier ที่ publish ไปยัง Redis pub/sub channel
+type redisOutboxNotifier struct {
+	client  *redis.Client
+	channel string
+}
+
+// NewRedisOutboxNotifier คือ factory function สำหรับสร้าง redisOutboxNotifier
+// channel ต้องตรงกับ channel ที่ outbox worker subscribe อยู่
+func NewRedisOutboxNotifier(client *redis.Client, channel string) *redisOutboxNotifier {
+	return &redisOutboxNotifier{client: client, channel: channel}
+}
+
+// Notify publish notification ไปยัง channel ของ Outbox
+func (n *redisOutboxNotifier) Notify(ctx context.Context) error {
+	if err := n.client.Publish(ctx, n.channel, outboxNotification).Err(); err != nil {
+		return fmt.Errorf("failed to publish to %s: %w", n.channel, err)
+	}
+	return nil
+}
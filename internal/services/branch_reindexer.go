@@ -0,0 +1,53 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+
+	"ES/internal/ports"
+)
+
+// branchReindexer ใช้ร่วมกันระหว่าง service ของ product, interest และ product_option
+// เพื่อเขียน Event "updated" ของสาขาที่ได้รับผลกระทบลง Outbox
+// เนื่องจาก document ของสาขาใน Elasticsearch เก็บ product_ids, interest_ids และช่วงราคาแบบ denormalize ไว้
+type branchReindexer struct {
+	branchRepo ports.BranchRepository
+	outboxRepo ports.OutboxRepository
+	notifier   ports.OutboxNotifier
+}
+
+// enqueue ดึงข้อมูลฉบับสมบูรณ์ล่าสุดของแต่ละสาขาภายใน transaction เดียวกัน แล้วเขียน Event "updated" ลง Outbox
+func (r *branchReindexer) enqueue(ctx context.Context, tx ports.DBTX, branchIDs []int64) error {
+	if err := r.branchRepo.TouchBranches(ctx, tx, branchIDs); err != nil {
+		return err
+	}
+	for _, branchID := range branchIDs {
+		richBranchData, err := r.branchRepo.GetRichBranchData(ctx, tx, branchID)
+		if err != nil {
+			return fmt.Errorf("failed to get rich branch data for branch %d: %w", branchID, err)
+		}
+		payload, err := json.Marshal(richBranchData)
+		if err != nil {
+			return fmt.Errorf("failed to marshal payload for branch %d: %w", branchID, err)
+		}
+		if err := r.outboxRepo.CreateEvent(ctx, tx, strconv.FormatInt(branchID, 10), "branch", "updated", payload); err != nil {
+			return fmt.Errorf("failed to create outbox event for branch %d: %w", branchID, err)
+		}
+	}
+	return nil
+}
+
+// notify ส่ง Notification ไปให้ worker หลังจาก Commit สำเร็จ (เฉพาะเมื่อมี Event ถูกเขียน)
+func (r *branchReindexer) notify(ctx context.Context, branchIDs []int64) {
+	if len(branchIDs) == 0 {
+		return
+	}
+	log.Printf("Enqueued reindex of %d affected branches. Notifying outbox worker.", len(branchIDs))
+	if err := r.notifier.Notify(ctx); err != nil {
+		// การส่ง notification ล้มเหลวไม่ควรกระทบ logic หลัก แต่ควร log ไว้
+		log.Printf("WARNING: Failed to notify outbox worker: %v", err)
+	}
+}
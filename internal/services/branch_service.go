@@ -10,31 +10,30 @@ import (
 
 	"ES/internal/domain"
 	"ES/internal/ports"
-
-	"github.com/go-redis/redis/v8"
 )
 
 // branchService คือ implementation ของ BranchService
 type branchService struct {
-	db          *sql.DB
-	branchRepo  ports.BranchRepository
-	outboxRepo  ports.OutboxRepository
-	redisClient *redis.Client
+	db         *sql.DB
+	branchRepo ports.BranchRepository
+	outboxRepo ports.OutboxRepository
+	notifier   ports.OutboxNotifier
 }
 
 // NewBranchService คือ factory function สำหรับสร้าง branchService
 // สังเกตว่าเรารับ *sql.DB เข้ามาด้วยเพื่อใช้จัดการ Transaction
-func NewBranchService(db *sql.DB, branchRepo ports.BranchRepository, outboxRepo ports.OutboxRepository, redisClient *redis.Client) ports.BranchService {
+func NewBranchService(db *sql.DB, branchRepo ports.BranchRepository, outboxRepo ports.OutboxRepository, notifier ports.OutboxNotifier) ports.BranchService {
 	return &branchService{
-		db:          db,
-		branchRepo:  branchRepo,
-		outboxRepo:  outboxRepo,
-		redisClient: redisClient,
+		db:         db,
+		branchRepo: branchRepo,
+		outboxRepo: outboxRepo,
+		notifier:   notifier,
 	}
 }
 
 // CreateBranchWithProducts คือเมธอดที่จัดการ business logic ทั้งหมดใน transaction เดียว
-func (s *branchService) CreateBranchWithProducts(ctx context.Context, name domain.BranchNameJSON, productIDs []int) (*domain.Branch, error) {
+// สร้างสาขาพร้อมเชื่อมโยงสินค้าและความสนใจ
+func (s *branchService) CreateBranchWithProducts(ctx context.Context, name domain.BranchNameJSON, productIDs, interestIDs []int) (*domain.Branch, error) {
 	// 1. เริ่มต้น Transaction
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -52,17 +51,39 @@ func (s *branchService) CreateBranchWithProducts(ctx context.Context, name domai
 	if err := s.branchRepo.LinkProductsToBranch(ctx, tx, branchID, productIDs); err != nil {
 		return nil, fmt.Errorf("failed to link products in transaction: %w", err)
 	}
+	if err := s.branchRepo.LinkInterestsToBranch(ctx, tx, branchID, interestIDs); err != nil {
+		return nil, fmt.Errorf("failed to link interests in transaction: %w", err)
+	}
 
-	// 4. ถ้าทุกอย่างสำเร็จ ให้ Commit Transaction
+	// 4. ดึงข้อมูลฉบับสมบูรณ์จาก DB (ภายใน transaction เดียวกัน) แล้วสร้าง Event "created" สำหรับ Outbox
+	richBranchData, err := s.branchRepo.GetRichBranchData(ctx, tx, branchID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rich branch data for outbox: %w", err)
+	}
+	payload, err := json.Marshal(richBranchData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payload for outbox: %w", err)
+	}
+	if err := s.outboxRepo.CreateEvent(ctx, tx, strconv.FormatInt(branchID, 10), "branch", "created", payload); err != nil {
+		return nil, fmt.Errorf("failed to create outbox event: %w", err)
+	}
+
+	// 5. ถ้าทุกอย่างสำเร็จ ให้ Commit Transaction
 	if err := tx.Commit(); err != nil {
 		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	return &domain.Branch{ID: branchID, Name: name, ProductIDs: productIDs}, nil
+	// 6. หลังจาก Commit สำเร็จ ให้ส่ง Notification ไปให้ worker
+	log.Printf("Create transaction committed for branch ID: %d. Notifying outbox worker.", branchID)
+	if err := s.notifier.Notify(ctx); err != nil {
+		log.Printf("WARNING: Failed to notify outbox worker: %v", err)
+	}
+
+	return richBranchData, nil
 }
 
-// UpdateBranchWithProducts อัปเดตข้อมูลสาขาและสินค้าที่เชื่อมโยง
-func (s *branchService) UpdateBranchWithProducts(ctx context.Context, id int64, name domain.BranchNameJSON, productIDs []int) (*domain.Branch, error) {
+// UpdateBranchWithProducts อัปเดตข้อมูลสาขา สินค้าที่เชื่อมโยง และความสนใจที่เชื่อมโยง (ถ้า interestIDs ไม่เป็น nil)
+func (s *branchService) UpdateBranchWithProducts(ctx context.Context, id int64, name domain.BranchNameJSON, productIDs, interestIDs []int) (*domain.Branch, error) {
 	log.Printf("Starting transaction to update branch ID: %d", id)
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -96,6 +117,21 @@ func (s *branchService) UpdateBranchWithProducts(ctx context.Context, id int64,
 		return nil, fmt.Errorf("failed to link new products in transaction: %w", err)
 	}
 
+	// 3.1 แทนที่ความสนใจที่เชื่อมโยงทั้งหมด เฉพาะเมื่อ request ส่ง interest_ids มา
+	if interestIDs != nil {
+		log.Printf("Step 3.1: Replacing interests of branch ID: %d", id)
+		if err := s.branchRepo.UnlinkAllInterestsFromBranch(ctx, tx, id); err != nil {
+			log.Printf("ERROR: Step 3.1 failed. Rolling back transaction. Error: %v", err)
+			tx.Rollback()
+			return nil, fmt.Errorf("failed to unlink old interests in transaction: %w", err)
+		}
+		if err := s.branchRepo.LinkInterestsToBranch(ctx, tx, id, interestIDs); err != nil {
+			log.Printf("ERROR: Step 3.1 failed. Rolling back transaction. Error: %v", err)
+			tx.Rollback()
+			return nil, fmt.Errorf("failed to link new interests in transaction: %w", err)
+		}
+	}
+
 	// 4. สร้าง Event สำหรับ Outbox
 	log.Printf("Step 4: Creating outbox event for branch ID: %d", id)
 	// ดึงข้อมูลฉบับสมบูรณ์ล่าสุดจาก DB เพื่อสร้าง payload
@@ -125,10 +161,10 @@ func (s *branchService) UpdateBranchWithProducts(ctx context.Context, id int64,
 	}
 
 	// หลังจาก Commit สำเร็จ ให้ส่ง Notification
-	log.Println("Transaction committed. Publishing notification to 'outbox_channel'.")
-	if err := s.redisClient.Publish(ctx, "outbox_channel", "new_event").Err(); err != nil {
+	log.Println("Transaction committed. Notifying outbox worker.")
+	if err := s.notifier.Notify(ctx); err != nil {
 		// การส่ง notification ล้มเหลวไม่ควรกระทบ logic หลัก แต่ควร log ไว้
-		log.Printf("WARNING: Failed to publish notification to Redis: %v", err)
+		log.Printf("WARNING: Failed to notify outbox worker: %v", err)
 	}
 
 	return richBranchData, nil
@@ -163,8 +199,8 @@ func (s *branchService) DeleteBranch(ctx context.Context, id int64) error {
 	}
 
 	// 4. ส่ง Notification
-	log.Println("Delete transaction committed. Publishing notification to 'outbox_channel'.")
-	s.redisClient.Publish(ctx, "outbox_channel", "new_event") // ไม่ต้องเช็ค error เพื่อไม่ให้กระทบ flow หลัก
+	log.Println("Delete transaction committed. Notifying outbox worker.")
+	s.notifier.Notify(ctx) // ไม่ต้องเช็ค error เพื่อไม่ให้กระทบ flow หลัก
 
 	return nil
 }
@@ -174,3 +210,108 @@ func (s *branchService) GetBranch(ctx context.Context, id int64) (*domain.Branch
 	// ใช้ DB connection ปกติ ไม่จำเป็นต้องใช้ transaction สำหรับการอ่าน
 	return s.branchRepo.GetRichBranchData(ctx, s.db, id)
 }
+
+// ListBranches ดึงรายการสาขาแบบสมบูรณ์หนึ่งหน้าจาก MySQL ซึ่งเป็นแหล่งข้อมูลจริง (ไม่ใช่ Elasticsearch)
+// อ่านเกินหนึ่งแถวเพื่อรู้ว่ามีหน้าถัดไปหรือไม่ แทนการเดาจากว่าหน้าเต็ม
+func (s *branchService) ListBranches(ctx context.Context, query domain.BranchListQuery) (*domain.BranchPage, error) {
+	limit := query.Limit
+	query.Limit = limit + 1
+	branches, err := s.branchRepo.ListRichBranches(ctx, s.db, query)
+	if err != nil {
+		return nil, err
+	}
+	query.Limit = limit
+
+	page := &domain.BranchPage{Branches: branches}
+	if len(branches) > limit {
+		page.Branches = branches[:limit]
+		page.Next = domain.NewBranchCursor(query, page.Branches[limit-1])
+	}
+	return page, nil
+}
+
+// LinkInterest เชื่อมโยงความสนใจกับสาขาแล้วเขียน Event "updated" ใน transaction เดียวกัน
+// ถ้าเชื่อมโยงอยู่แล้วจะไม่มีการเปลี่ยนแปลงและไม่เขียน event
+func (s *branchService) LinkInterest(ctx context.Context, branchID, interestID int64) (*domain.Branch, error) {
+	return s.changeBranch(ctx, branchID, func(tx *sql.Tx) (bool, error) {
+		return s.branchRepo.LinkInterestToBranch(ctx, tx, branchID, interestID)
+	})
+}
+
+// UnlinkInterest ลบการเชื่อมโยงความสนใจออกจากสาขาแล้วเขียน Event "updated" ใน transaction เดียวกัน
+// ถ้าไม่ได้เชื่อมโยงอยู่จะไม่มีการเปลี่ยนแปลงและไม่เขียน event
+func (s *branchService) UnlinkInterest(ctx context.Context, branchID, interestID int64) (*domain.Branch, error) {
+	return s.changeBranch(ctx, branchID, func(tx *sql.Tx) (bool, error) {
+		return s.branchRepo.UnlinkInterestFromBranch(ctx, tx, branchID, interestID)
+	})
+}
+
+// GetLocation ดึงที่ตั้งของสาขา
+func (s *branchService) GetLocation(ctx context.Context, branchID int64) (*domain.BranchLocation, error) {
+	return s.branchRepo.GetBranchLocation(ctx, s.db, branchID)
+}
+
+// SetLocation สร้างหรือแทนที่ที่ตั้งของสาขาแล้วเขียน Event "updated" ใน transaction เดียวกัน
+func (s *branchService) SetLocation(ctx context.Context, branchID int64, location domain.BranchLocation) (*domain.Branch, error) {
+	if err := location.Validate(); err != nil {
+		return nil, err
+	}
+	return s.changeBranch(ctx, branchID, func(tx *sql.Tx) (bool, error) {
+		return true, s.branchRepo.UpsertBranchLocation(ctx, tx, branchID, location)
+	})
+}
+
+// DeleteLocation ลบที่ตั้งของสาขาแล้วเขียน Event "updated" ใน transaction เดียวกัน
+func (s *branchService) DeleteLocation(ctx context.Context, branchID int64) (*domain.Branch, error) {
+	return s.changeBranch(ctx, branchID, func(tx *sql.Tx) (bool, error) {
+		return true, s.branchRepo.DeleteBranchLocation(ctx, tx, branchID)
+	})
+}
+
+// changeBranch รัน change ใน transaction แล้วคืนข้อมูลสาขาฉบับล่าสุด
+// change คืน true ถ้ามีการเปลี่ยนแปลง ซึ่งจะเขียน Event "updated" และแจ้ง worker หลัง commit
+func (s *branchService) changeBranch(ctx context.Context, branchID int64, change func(tx *sql.Tx) (bool, error)) (*domain.Branch, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	changed, err := change(tx)
+	if err != nil {
+		return nil, err
+	}
+	// การเปลี่ยนแปลงอยู่ในตารางอื่น updated_at ของสาขาจึงไม่เปลี่ยนเอง
+	if changed {
+		if err := s.branchRepo.TouchBranches(ctx, tx, []int64{branchID}); err != nil {
+			return nil, err
+		}
+	}
+
+	// คืน NotFoundError ถ้าไม่มีสาขานี้ (กรณี unlink ที่ไม่มีแถวให้ลบ)
+	richBranchData, err := s.branchRepo.GetRichBranchData(ctx, tx, branchID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rich branch data for outbox: %w", err)
+	}
+	if changed {
+		payload, err := json.Marshal(richBranchData)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal payload for outbox: %w", err)
+		}
+		if err := s.outboxRepo.CreateEvent(ctx, tx, strconv.FormatInt(branchID, 10), "branch", "updated", payload); err != nil {
+			return nil, fmt.Errorf("failed to create outbox event: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	if changed {
+		log.Printf("Branch ID %d changed. Notifying outbox worker.", branchID)
+		if err := s.notifier.Notify(ctx); err != nil {
+			log.Printf("WARNING: Failed to notify outbox worker: %v", err)
+		}
+	}
+	return richBranchData, nil
+}
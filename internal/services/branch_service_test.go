@@ -12,10 +12,17 @@ import (
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/go-redis/redismock/v8"
+	"github.com/go-sql-driver/mysql"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
+// richBranchColumns คือคอลัมน์ที่ query ของ GetRichBranchData คืนกลับมา
+var richBranchColumns = []string{
+	"id", "name", "province_id", "location_name", "location_address", "latitude", "longitude", "product_ids", "interest_ids",
+	"min_normal_price", "max_normal_price", "min_tagthai_price", "max_tagthai_price",
+}
+
 func TestUpdateBranchWithProducts_RollbackOnLinkError(t *testing.T) {
 	// 1. --- Setup ---
 	// สร้าง Mock Database และ Mock Redis
@@ -31,7 +38,7 @@ func TestUpdateBranchWithProducts_RollbackOnLinkError(t *testing.T) {
 	var branchRepo ports.BranchRepository = repo
 	var outboxRepo ports.OutboxRepository = repo
 
-	service := NewBranchService(db, branchRepo, outboxRepo, redisClient)
+	service := NewBranchService(db, branchRepo, outboxRepo, repositories.NewRedisOutboxNotifier(redisClient, "outbox_channel"))
 
 	// กำหนดค่าสำหรับ Test
 	branchID := int64(1)
@@ -44,7 +51,7 @@ func TestUpdateBranchWithProducts_RollbackOnLinkError(t *testing.T) {
 	mock.ExpectBegin() // 1. เริ่ม Transaction
 
 	// 2. อัปเดตข้อมูล Branch (คาดว่าจะสำเร็จ)
-	mock.ExpectExec(regexp.QuoteMeta("UPDATE branch SET name = ? WHERE id = ?")).
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE branch SET name = ?, updated_at = NOW() WHERE id = ?")).
 		WithArgs(`{"en":"Test Branch","th":"สาขาทดสอบ"}`, branchID).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 
@@ -67,7 +74,7 @@ func TestUpdateBranchWithProducts_RollbackOnLinkError(t *testing.T) {
 
 	// 3. --- เรียกใช้ฟังก์ชันที่ต้องการทดสอบ ---
 	ctx := context.Background()
-	_, err = service.UpdateBranchWithProducts(ctx, branchID, branchName, productIDs)
+	_, err = service.UpdateBranchWithProducts(ctx, branchID, branchName, productIDs, nil)
 
 	// 4. --- ตรวจสอบผลลัพธ์ ---
 	// ตรวจสอบว่าฟังก์ชัน return error กลับมาจริง
@@ -80,3 +87,242 @@ func TestUpdateBranchWithProducts_RollbackOnLinkError(t *testing.T) {
 	// นี่คือการยืนยันว่า Begin, Exec, Exec, Exec(Error), และ Rollback เกิดขึ้นจริง
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestCreateBranchWithProducts_WritesCreatedEvent(t *testing.T) {
+	// 1. --- Setup ---
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	redisClient, redisMock := redismock.NewClientMock()
+
+	repo := repositories.NewMySQLRepository(db)
+	service := NewBranchService(db, repo, repo, repositories.NewRedisOutboxNotifier(redisClient, "outbox_channel"))
+
+	branchID := int64(42)
+	branchName := domain.BranchNameJSON{EN: "New Branch", TH: "สาขาใหม่"}
+	productIDs := []int{5, 6}
+
+	// 2. --- กำหนด Expectations ของ Mock ---
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO branch (name) VALUES (?)")).
+		WithArgs(`{"en":"New Branch","th":"สาขาใหม่"}`).
+		WillReturnResult(sqlmock.NewResult(branchID, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO branches_products (branch_id, product_id) VALUES (?, ?), (?, ?)")).
+		WithArgs(branchID, productIDs[0], branchID, productIDs[1]).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	// ข้อมูลฉบับสมบูรณ์ต้องถูกอ่านภายใน transaction เดียวกัน
+	mock.ExpectQuery("SELECT").
+		WithArgs(branchID).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "name", "province_id", "location_name", "location_address", "latitude", "longitude", "product_ids", "interest_ids",
+			"min_normal_price", "max_normal_price", "min_tagthai_price", "max_tagthai_price",
+		}).AddRow(branchID, `{"en":"New Branch","th":"สาขาใหม่"}`, nil, nil, nil, nil, nil, "5,6", nil, 500.0, 650.0, 450.0, 570.0))
+
+	// ต้องมี Event "created" ถูกเขียนลง Outbox ก่อน Commit
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO outbox_events (aggregate_id, aggregate_type, event_type, payload) VALUES (?, ?, ?, ?)")).
+		WithArgs("42", "branch", "created", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	// และต้องส่ง Notification หลัง Commit
+	redisMock.ExpectPublish("outbox_channel", "new_event").SetVal(1)
+
+	// 3. --- เรียกใช้ฟังก์ชันที่ต้องการทดสอบ ---
+	branch, err := service.CreateBranchWithProducts(context.Background(), branchName, productIDs, nil)
+
+	// 4. --- ตรวจสอบผลลัพธ์ ---
+	require.NoError(t, err)
+	assert.Equal(t, branchID, branch.ID)
+	assert.Equal(t, []int{5, 6}, branch.ProductIDs)
+	require.NotNil(t, branch.MinNormalPrice)
+	assert.Equal(t, 500.0, *branch.MinNormalPrice)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.NoError(t, redisMock.ExpectationsWereMet())
+}
+
+func TestUpdateBranchWithProducts_ReplacesInterestsWhenGiven(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	redisClient, redisMock := redismock.NewClientMock()
+	repo := repositories.NewMySQLRepository(db)
+	service := NewBranchService(db, repo, repo, repositories.NewRedisOutboxNotifier(redisClient, "outbox_channel"))
+
+	branchID := int64(7)
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE branch SET name = ?, updated_at = NOW() WHERE id = ?")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM branches_products WHERE branch_id = ?")).
+		WithArgs(branchID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	// interest_ids ถูกแทนที่ใน transaction เดียวกับ product_ids
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM branches_interests WHERE branch_id = ?")).
+		WithArgs(branchID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO branches_interests (branch_id, interest_id) VALUES (?, ?), (?, ?)")).
+		WithArgs(branchID, 3, branchID, 4).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectQuery("SELECT").
+		WithArgs(branchID).
+		WillReturnRows(sqlmock.NewRows(richBranchColumns).
+			AddRow(branchID, `{"en":"B","th":"ข"}`, nil, nil, nil, nil, nil, nil, "3,4", nil, nil, nil, nil))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO outbox_events")).
+		WithArgs("7", "branch", "updated", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	redisMock.ExpectPublish("outbox_channel", "new_event").SetVal(1)
+
+	branch, err := service.UpdateBranchWithProducts(context.Background(), branchID, domain.BranchNameJSON{EN: "B", TH: "ข"}, nil, []int{3, 4})
+
+	require.NoError(t, err)
+	assert.Equal(t, []int{3, 4}, branch.InterestIDs)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLinkInterest_WritesUpdatedEventOnlyWhenChanged(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	redisClient, redisMock := redismock.NewClientMock()
+	repo := repositories.NewMySQLRepository(db)
+	service := NewBranchService(db, repo, repo, repositories.NewRedisOutboxNotifier(redisClient, "outbox_channel"))
+
+	branchRow := func() *sqlmock.Rows {
+		return sqlmock.NewRows(richBranchColumns).AddRow(1, `{"en":"A","th":"ก"}`, nil, nil, nil, nil, nil, nil, "9", nil, nil, nil, nil)
+	}
+
+	// เชื่อมโยงใหม่: ตั้ง updated_at ของสาขา เขียน event "updated" และแจ้ง worker
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO branches_interests (branch_id, interest_id) VALUES (?, ?)")).
+		WithArgs(int64(1), int64(9)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE branch SET updated_at = NOW() WHERE id IN (?)")).
+		WithArgs(int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT").WithArgs(int64(1)).WillReturnRows(branchRow())
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO outbox_events")).
+		WithArgs("1", "branch", "updated", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	redisMock.ExpectPublish("outbox_channel", "new_event").SetVal(1)
+
+	// เชื่อมโยงอยู่แล้ว: ไม่แตะ updated_at และไม่มี event
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO branches_interests (branch_id, interest_id) VALUES (?, ?)")).
+		WithArgs(int64(1), int64(9)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT").WithArgs(int64(1)).WillReturnRows(branchRow())
+	mock.ExpectCommit()
+
+	branch, err := service.LinkInterest(context.Background(), 1, 9)
+	require.NoError(t, err)
+	assert.Equal(t, []int{9}, branch.InterestIDs)
+
+	_, err = service.LinkInterest(context.Background(), 1, 9)
+	require.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.NoError(t, redisMock.ExpectationsWereMet())
+}
+
+func TestLinkInterest_MissingInterestIsNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	redisClient, _ := redismock.NewClientMock()
+	repo := repositories.NewMySQLRepository(db)
+	service := NewBranchService(db, repo, repo, repositories.NewRedisOutboxNotifier(redisClient, "outbox_channel"))
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO branches_interests")).
+		WillReturnError(&mysql.MySQLError{Number: 1452, Message: "Cannot add or update a child row: a foreign key constraint fails " +
+			"(`TTDB`.`branches_interests`, CONSTRAINT `fk_branch_interest_interest` FOREIGN KEY (`interest_id`) REFERENCES `interest` (`id`) ON DELETE CASCADE ON UPDATE CASCADE)"})
+	mock.ExpectRollback()
+
+	_, err = service.LinkInterest(context.Background(), 1, 99)
+
+	var notFound *domain.NotFoundError
+	require.True(t, errors.As(err, &notFound))
+	assert.Equal(t, "interest", notFound.Resource)
+	assert.Equal(t, int64(99), notFound.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSetLocation(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	redisClient, redisMock := redismock.NewClientMock()
+	repo := repositories.NewMySQLRepository(db)
+	service := NewBranchService(db, repo, repo, repositories.NewRedisOutboxNotifier(redisClient, "outbox_channel"))
+
+	// ข้อมูลที่ไม่ถูกต้องถูกปฏิเสธก่อนเริ่ม transaction
+	_, err = service.SetLocation(context.Background(), 1, domain.BranchLocation{ProvinceID: 10, Coordinates: &domain.GeoPoint{Lat: 13.7, Lon: 181}})
+	assert.ErrorIs(t, err, domain.ErrValidation)
+
+	location := domain.BranchLocation{ProvinceID: 10, Address: "999/9 Rama I Rd", Coordinates: &domain.GeoPoint{Lat: 13.746571, Lon: 100.539302}}
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO branch_location (branch_id, province_id, name, address, latitude, longitude)")).
+		WithArgs(int64(1), 10, nil, "999/9 Rama I Rd", 13.746571, 100.539302).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE branch SET updated_at = NOW() WHERE id IN (?)")).
+		WithArgs(int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT").
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows(richBranchColumns).
+			AddRow(1, `{"en":"A","th":"ก"}`, 10, nil, "999/9 Rama I Rd", 13.746571, 100.539302, nil, nil, nil, nil, nil, nil))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO outbox_events")).
+		WithArgs("1", "branch", "updated", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	redisMock.ExpectPublish("outbox_channel", "new_event").SetVal(1)
+
+	branch, err := service.SetLocation(context.Background(), 1, location)
+
+	require.NoError(t, err)
+	assert.Equal(t, &location, branch.Location)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.NoError(t, redisMock.ExpectationsWereMet())
+}
+
+func TestListBranches_ReadsOneExtraRowToDetectNextPage(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := repositories.NewMySQLRepository(db)
+	service := NewBranchService(db, repo, repo, nil)
+	listedColumns := append(append([]string{}, richBranchColumns...), "updated_at")
+	row := func(rows *sqlmock.Rows, id int64, th string) *sqlmock.Rows {
+		return rows.AddRow(id, `{"en":"","th":"`+th+`"}`, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	}
+	query := domain.BranchListQuery{Sort: domain.BranchSortName, Limit: 2}
+
+	// มีแถวที่สามจึงมีหน้าถัดไป และ cursor ชี้ไปยังสาขาสุดท้ายที่คืนให้ ไม่ใช่แถวที่อ่านเกิน
+	mock.ExpectQuery("LIMIT \\?").
+		WithArgs(3).
+		WillReturnRows(row(row(row(sqlmock.NewRows(listedColumns), 1, "ก"), 2, "ข"), 3, "ค"))
+
+	page, err := service.ListBranches(context.Background(), query)
+	require.NoError(t, err)
+	require.Len(t, page.Branches, 2)
+	assert.Equal(t, &domain.BranchCursor{Sort: domain.BranchSortName, ID: 2, Name: "ข"}, page.Next)
+
+	// หน้าที่มีสาขาครบ limit พอดีแต่ไม่มีแถวเกิน คือหน้าสุดท้าย
+	mock.ExpectQuery("LIMIT \\?").
+		WithArgs(3).
+		WillReturnRows(row(row(sqlmock.NewRows(listedColumns), 3, "ค"), 4, "ง"))
+
+	page, err = service.ListBranches(context.Background(), query)
+	require.NoError(t, err)
+	assert.Len(t, page.Branches, 2)
+	assert.Nil(t, page.Next)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
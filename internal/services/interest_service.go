@@ -10,15 +10,74 @@ import (
 )
 
 type interestService struct {
-	db   *sql.DB
-	repo ports.InterestRepository
+	db        *sql.DB
+	repo      ports.InterestRepository
+	reindexer *branchReindexer
 }
 
-func NewInterestService(db *sql.DB, repo ports.InterestRepository) ports.InterestService {
-	return &interestService{db: db, repo: repo}
+func NewInterestService(db *sql.DB, repo ports.InterestRepository, branchRepo ports.BranchRepository, outboxRepo ports.OutboxRepository, notifier ports.OutboxNotifier) ports.InterestService {
+	return &interestService{
+		db:        db,
+		repo:      repo,
+		reindexer: &branchReindexer{branchRepo: branchRepo, outboxRepo: outboxRepo, notifier: notifier},
+	}
 }
 
+// CreateInterest สร้างความสนใจใหม่ ความสนใจใหม่ยังไม่ถูกเชื่อมโยงกับสาขาใด จึงไม่มีสาขาที่ต้อง reindex
+func (s *interestService) CreateInterest(ctx context.Context, name domain.BranchNameJSON) (*domain.Interest, error) {
+	if err := name.Validate("name"); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	id, err := s.repo.CreateInterest(ctx, tx, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create interest in transaction: %w", err)
+	}
+	interest, err := s.repo.GetInterest(ctx, tx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read created interest: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return interest, nil
+}
+
+func (s *interestService) GetInterest(ctx context.Context, id int64) (*domain.Interest, error) {
+	return s.repo.GetInterest(ctx, s.db, id)
+}
+
+// ListInterests คืนความสนใจหนึ่งหน้า อ่านเกินหนึ่งแถวเพื่อรู้ว่ามีหน้าถัดไปหรือไม่ แทนการเดาจากว่าหน้าเต็ม
+func (s *interestService) ListInterests(ctx context.Context, page domain.PageQuery) (*domain.InterestPage, error) {
+	limit := page.Limit
+	page.Limit = limit + 1
+	interests, err := s.repo.ListInterests(ctx, s.db, page)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &domain.InterestPage{Interests: interests}
+	if len(interests) > limit {
+		result.Interests = interests[:limit]
+		result.NextAfterID = result.Interests[limit-1].ID
+	}
+	return result, nil
+}
+
+// UpdateInterest เปลี่ยนชื่อความสนใจ ไม่ต้อง reindex สาขา เพราะ document ของสาขาเก็บเพียง interest_ids
+// ไม่ได้เก็บชื่อความสนใจ (ถ้าวันหนึ่ง document มีชื่อ ต้องกลับมาเขียน event ของสาขาที่เชื่อมโยงอยู่)
 func (s *interestService) UpdateInterest(ctx context.Context, id int64, name domain.BranchNameJSON) error {
+	if err := name.Validate("name"); err != nil {
+		return err
+	}
+
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
@@ -39,9 +98,24 @@ func (s *interestService) DeleteInterest(ctx context.Context, id int64) error {
 	}
 	defer tx.Rollback()
 
+	// ต้องหาสาขาที่ได้รับผลกระทบก่อนลบ เพราะ ON DELETE CASCADE จะลบแถวใน branches_interests ไปด้วย
+	branchIDs, err := s.repo.GetBranchIDsByInterest(ctx, tx, id)
+	if err != nil {
+		return fmt.Errorf("failed to find branches affected by interest %d: %w", id, err)
+	}
+
 	if err := s.repo.DeleteInterest(ctx, tx, id); err != nil {
 		return fmt.Errorf("failed to delete interest in transaction: %w", err)
 	}
 
-	return tx.Commit()
+	// ข้อมูลที่อ่านหลังการลบจะไม่มีความสนใจนี้แล้ว ทำให้ interest_ids ของสาขาถูกต้อง
+	if err := s.reindexer.enqueue(ctx, tx, branchIDs); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	s.reindexer.notify(ctx, branchIDs)
+	return nil
 }
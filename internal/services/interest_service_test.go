@@ -19,7 +19,7 @@ func TestUpdateInterest_RollbackOnError(t *testing.T) {
 	defer db.Close()
 
 	repo := &mockInterestRepository{}
-	service := NewInterestService(db, repo)
+	service := NewInterestService(db, repo, nil, nil, nil)
 
 	ctx := context.Background()
 	testID := int64(1)
@@ -31,6 +31,10 @@ func TestUpdateInterest_RollbackOnError(t *testing.T) {
 		return expectedError
 	}
 
+	// ชื่อว่างถูกปฏิเสธก่อนเริ่ม transaction
+	err = service.UpdateInterest(ctx, testID, domain.BranchNameJSON{})
+	assert.ErrorIs(t, err, domain.ErrValidation)
+
 	// ตั้งค่า mock database transaction
 	mock.ExpectBegin()
 	mock.ExpectRollback() // คาดหวังว่าจะมีการ Rollback
@@ -46,8 +50,29 @@ func TestUpdateInterest_RollbackOnError(t *testing.T) {
 
 // Mock Repository สำหรับ Interest
 type mockInterestRepository struct {
-	UpdateInterestFunc func(ctx context.Context, dbtx ports.DBTX, id int64, name domain.BranchNameJSON) error
-	DeleteInterestFunc func(ctx context.Context, dbtx ports.DBTX, id int64) error
+	CreateInterestFunc         func(ctx context.Context, dbtx ports.DBTX, name domain.BranchNameJSON) (int64, error)
+	GetInterestFunc            func(ctx context.Context, dbtx ports.DBTX, id int64) (*domain.Interest, error)
+	UpdateInterestFunc         func(ctx context.Context, dbtx ports.DBTX, id int64, name domain.BranchNameJSON) error
+	DeleteInterestFunc         func(ctx context.Context, dbtx ports.DBTX, id int64) error
+	GetBranchIDsByInterestFunc func(ctx context.Context, dbtx ports.DBTX, interestID int64) ([]int64, error)
+}
+
+func (m *mockInterestRepository) CreateInterest(ctx context.Context, dbtx ports.DBTX, name domain.BranchNameJSON) (int64, error) {
+	if m.CreateInterestFunc != nil {
+		return m.CreateInterestFunc(ctx, dbtx, name)
+	}
+	return 0, nil
+}
+
+func (m *mockInterestRepository) GetInterest(ctx context.Context, dbtx ports.DBTX, id int64) (*domain.Interest, error) {
+	if m.GetInterestFunc != nil {
+		return m.GetInterestFunc(ctx, dbtx, id)
+	}
+	return &domain.Interest{ID: id}, nil
+}
+
+func (m *mockInterestRepository) ListInterests(ctx context.Context, dbtx ports.DBTX, page domain.PageQuery) ([]*domain.Interest, error) {
+	return nil, nil
 }
 
 func (m *mockInterestRepository) UpdateInterest(ctx context.Context, dbtx ports.DBTX, id int64, name domain.BranchNameJSON) error {
@@ -63,3 +88,10 @@ func (m *mockInterestRepository) DeleteInterest(ctx context.Context, dbtx ports.
 	}
 	return nil
 }
+
+func (m *mockInterestRepository) GetBranchIDsByInterest(ctx context.Context, dbtx ports.DBTX, interestID int64) ([]int64, error) {
+	if m.GetBranchIDsByInterestFunc != nil {
+		return m.GetBranchIDsByInterestFunc(ctx, dbtx, interestID)
+	}
+	return nil, nil
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"sort"
+
+	"ES/internal/domain"
+	"ES/internal/ports"
+)
+
+// outboxAdminService คือ implementation ของ OutboxAdminService
+type outboxAdminService struct {
+	db       *sql.DB
+	repo     ports.OutboxAdminRepository
+	notifier ports.OutboxNotifier
+}
+
+// NewOutboxAdminService คือ factory function สำหรับสร้าง outboxAdminService
+func NewOutboxAdminService(db *sql.DB, repo ports.OutboxAdminRepository, notifier ports.OutboxNotifier) ports.OutboxAdminService {
+	return &outboxAdminService{db: db, repo: repo, notifier: notifier}
+}
+
+func (s *outboxAdminService) ListEvents(ctx context.Context, filter domain.OutboxEventFilter) (*domain.OutboxEventPage, error) {
+	return s.repo.ListOutboxEvents(ctx, s.db, filter)
+}
+
+// GetEvent คืน event พร้อม payload หรือ domain.NotFoundError ถ้าไม่พบ
+func (s *outboxAdminService) GetEvent(ctx context.Context, id int64) (*domain.OutboxEvent, error) {
+	return s.repo.GetOutboxEvent(ctx, s.db, id)
+}
+
+func (s *outboxAdminService) Stats(ctx context.Context) (*domain.OutboxStats, error) {
+	return s.repo.GetOutboxStats(ctx, s.db)
+}
+
+// RequeueEvents ย้าย event ที่เลือกกลับไปเป็น pending (เฉพาะ event ที่ failed, dead หรือ unhandled)
+// แล้วแจ้ง worker ให้ประมวลผลทันที
+func (s *outboxAdminService) RequeueEvents(ctx context.Context, actor string, ids []int64) (*domain.OutboxAuditEntry, error) {
+	entry, err := s.audited(ctx, actor, domain.OutboxAuditRequeue, ids, s.repo.RequeueOutboxEvents)
+	if err != nil {
+		return nil, err
+	}
+	if entry.Affected > 0 {
+		log.Printf("%s requeued %d outbox events. Notifying outbox worker.", actor, entry.Affected)
+		if err := s.notifier.Notify(ctx); err != nil {
+			log.Printf("WARNING: Failed to notify outbox worker: %v", err)
+		}
+	}
+	return entry, nil
+}
+
+// PurgeEvents ลบ event ที่เลือก (ยกเว้น event ที่กำลังถูกประมวลผล)
+func (s *outboxAdminService) PurgeEvents(ctx context.Context, actor string, ids []int64) (*domain.OutboxAuditEntry, error) {
+	entry, err := s.audited(ctx, actor, domain.OutboxAuditPurge, ids, s.repo.PurgeOutboxEvents)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("%s purged %d outbox events.", actor, entry.Affected)
+	return entry, nil
+}
+
+func (s *outboxAdminService) ListAuditEntries(ctx context.Context, limit int) ([]*domain.OutboxAuditEntry, error) {
+	return s.repo.ListOutboxAuditEntries(ctx, s.db, limit)
+}
+
+// audited รัน action กับ event ที่เลือก และบันทึก audit entry ใน transaction เดียวกัน
+// ถ้าบันทึก audit ไม่สำเร็จ การเปลี่ยนแปลงจะถูก rollback ไปด้วย
+func (s *outboxAdminService) audited(ctx context.Context, actor, action string, ids []int64,
+	apply func(ctx context.Context, dbtx ports.DBTX, ids []int64) (int64, error)) (*domain.OutboxAuditEntry, error) {
+	ids = uniqueIDs(ids)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	affected, err := apply(ctx, tx, ids)
+	if err != nil {
+		return nil, err
+	}
+	entry := domain.OutboxAuditEntry{Actor: actor, Action: action, EventIDs: ids, Affected: affected}
+	if err := s.repo.CreateOutboxAuditEntry(ctx, tx, entry); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &entry, nil
+}
+
+// uniqueIDs คืน ID ที่ไม่ซ้ำกันเรียงจากน้อยไปมาก
+func uniqueIDs(ids []int64) []int64 {
+	seen := make(map[int64]bool, len(ids))
+	out := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"ES/internal/domain"
+	"ES/internal/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-redis/redismock/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequeueEvents_AuditsAndNotifies(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	redisClient, redisMock := redismock.NewClientMock()
+	repo := repositories.NewMySQLRepository(db)
+	service := NewOutboxAdminService(db, repo, repositories.NewRedisOutboxNotifier(redisClient, "outbox_channel"))
+
+	// ID ซ้ำถูกตัดออกและเรียงก่อนส่งไปยังฐานข้อมูล
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE outbox_events\s+SET status = 'pending'.*WHERE id IN \(\?, \?\) AND status IN \(\?, \?, \?\)`).
+		WithArgs(int64(3), int64(7), "failed", "dead", "unhandled").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO outbox_admin_audit (actor, action, event_ids, affected) VALUES (?, ?, ?, ?)")).
+		WithArgs("alice", "requeue", []byte("[3,7]"), int64(1)).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	redisMock.ExpectPublish("outbox_channel", "new_event").SetVal(1)
+
+	entry, err := service.RequeueEvents(context.Background(), "alice", []int64{7, 3, 7})
+
+	require.NoError(t, err)
+	assert.Equal(t, []int64{3, 7}, entry.EventIDs)
+	assert.Equal(t, int64(1), entry.Affected)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.NoError(t, redisMock.ExpectationsWereMet())
+}
+
+func TestPurgeEvents_RollsBackWhenAuditFails(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	redisClient, redisMock := redismock.NewClientMock()
+	repo := repositories.NewMySQLRepository(db)
+	service := NewOutboxAdminService(db, repo, repositories.NewRedisOutboxNotifier(redisClient, "outbox_channel"))
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM outbox_events WHERE id IN (?) AND status <> 'processing'")).
+		WithArgs(int64(9)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO outbox_admin_audit").
+		WillReturnError(errors.New("table missing"))
+	// การลบต้องไม่ถูก commit ถ้าบันทึก audit ไม่สำเร็จ
+	mock.ExpectRollback()
+
+	_, err = service.PurgeEvents(context.Background(), "bob", []int64{9})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to write outbox audit entry")
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.NoError(t, redisMock.ExpectationsWereMet())
+}
+
+func TestStats_FillsMissingStatusesAndOldestPending(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	service := NewOutboxAdminService(db, repositories.NewMySQLRepository(db), nil)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT status, COUNT(*) FROM outbox_events GROUP BY status")).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "count"}).AddRow("pending", 4).AddRow("dead", 2))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT MIN(created_at), TIMESTAMPDIFF(SECOND, MIN(created_at), NOW())")).
+		WillReturnRows(sqlmock.NewRows([]string{"min", "age"}).AddRow(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), 120))
+
+	stats, err := service.Stats(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), stats.Counts[domain.OutboxStatusPending])
+	assert.Equal(t, int64(2), stats.Counts[domain.OutboxStatusDead])
+	assert.Equal(t, int64(0), stats.Counts[domain.OutboxStatusFailed])
+	require.NotNil(t, stats.OldestPendingCreatedAt)
+	assert.Equal(t, int64(120), stats.OldestPendingAgeSeconds)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -5,16 +5,77 @@ import (
 	"database/sql"
 	"fmt"
 
+	"ES/internal/domain"
 	"ES/internal/ports"
 )
 
 type productOptionService struct {
-	db   *sql.DB
-	repo ports.ProductOptionRepository
+	db        *sql.DB
+	repo      ports.ProductOptionRepository
+	reindexer *branchReindexer
 }
 
-func NewProductOptionService(db *sql.DB, repo ports.ProductOptionRepository) ports.ProductOptionService {
-	return &productOptionService{db: db, repo: repo}
+func NewProductOptionService(db *sql.DB, repo ports.ProductOptionRepository, branchRepo ports.BranchRepository, outboxRepo ports.OutboxRepository, notifier ports.OutboxNotifier) ports.ProductOptionService {
+	return &productOptionService{
+		db:        db,
+		repo:      repo,
+		reindexer: &branchReindexer{branchRepo: branchRepo, outboxRepo: outboxRepo, notifier: notifier},
+	}
+}
+
+// CreateProductOption สร้างตัวเลือกราคาใหม่ของสินค้า
+// ราคาใหม่มีผลกับ min/max price ของทุกสาขาที่ขายสินค้านี้ จึงเขียน Event "updated" ของสาขาเหล่านั้นใน transaction เดียวกัน
+func (s *productOptionService) CreateProductOption(ctx context.Context, productID int64, normalPrice, tagthaiPrice float64) (*domain.ProductOption, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	id, err := s.repo.CreateProductOption(ctx, tx, productID, normalPrice, tagthaiPrice)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create product option in transaction: %w", err)
+	}
+
+	branchIDs, err := s.repo.GetBranchIDsByProductOption(ctx, tx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find branches affected by product option %d: %w", id, err)
+	}
+	if err := s.reindexer.enqueue(ctx, tx, branchIDs); err != nil {
+		return nil, err
+	}
+	option, err := s.repo.GetProductOption(ctx, tx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read created product option: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	s.reindexer.notify(ctx, branchIDs)
+	return option, nil
+}
+
+func (s *productOptionService) GetProductOption(ctx context.Context, id int64) (*domain.ProductOption, error) {
+	return s.repo.GetProductOption(ctx, s.db, id)
+}
+
+// ListProductOptions คืนตัวเลือกราคาของสินค้าหนึ่งหน้า หรือ NotFoundError ถ้าไม่มีสินค้านี้
+// อ่านเกินหนึ่งแถวเพื่อรู้ว่ามีหน้าถัดไปหรือไม่ แทนการเดาจากว่าหน้าเต็ม
+func (s *productOptionService) ListProductOptions(ctx context.Context, productID int64, page domain.PageQuery) (*domain.ProductOptionPage, error) {
+	limit := page.Limit
+	page.Limit = limit + 1
+	options, err := s.repo.ListProductOptions(ctx, s.db, productID, page)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &domain.ProductOptionPage{Options: options}
+	if len(options) > limit {
+		result.Options = options[:limit]
+		result.NextAfterID = result.Options[limit-1].ID
+	}
+	return result, nil
 }
 
 func (s *productOptionService) UpdateProductOption(ctx context.Context, id int64, normalPrice, tagthaiPrice float64) error {
@@ -28,7 +89,20 @@ func (s *productOptionService) UpdateProductOption(ctx context.Context, id int64
 		return fmt.Errorf("failed to update product option in transaction: %w", err)
 	}
 
-	return tx.Commit()
+	// ราคาใหม่มีผลกับ min/max price ของทุกสาขาที่ขายสินค้าซึ่งเป็นเจ้าของตัวเลือกนี้
+	branchIDs, err := s.repo.GetBranchIDsByProductOption(ctx, tx, id)
+	if err != nil {
+		return fmt.Errorf("failed to find branches affected by product option %d: %w", id, err)
+	}
+	if err := s.reindexer.enqueue(ctx, tx, branchIDs); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	s.reindexer.notify(ctx, branchIDs)
+	return nil
 }
 
 func (s *productOptionService) DeleteProductOption(ctx context.Context, id int64) error {
@@ -38,9 +112,23 @@ func (s *productOptionService) DeleteProductOption(ctx context.Context, id int64
 	}
 	defer tx.Rollback()
 
+	// ต้องหาสาขาที่ได้รับผลกระทบก่อนลบ เพราะหลังลบแล้วจะไม่รู้ว่าตัวเลือกนี้เคยเป็นของสินค้าใด
+	branchIDs, err := s.repo.GetBranchIDsByProductOption(ctx, tx, id)
+	if err != nil {
+		return fmt.Errorf("failed to find branches affected by product option %d: %w", id, err)
+	}
+
 	if err := s.repo.DeleteProductOption(ctx, tx, id); err != nil {
 		return fmt.Errorf("failed to delete product option in transaction: %w", err)
 	}
 
-	return tx.Commit()
+	if err := s.reindexer.enqueue(ctx, tx, branchIDs); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	s.reindexer.notify(ctx, branchIDs)
+	return nil
 }
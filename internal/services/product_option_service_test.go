@@ -4,9 +4,14 @@ import (
 	"ES/internal/ports"
 	"context"
 	"errors"
+	"regexp"
 	"testing"
 
+	"ES/internal/domain"
+	"ES/internal/repositories"
+
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-redis/redismock/v8"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -17,7 +22,7 @@ func TestUpdateProductOption_RollbackOnError(t *testing.T) {
 	defer db.Close()
 
 	repo := &mockProductOptionRepository{}
-	service := NewProductOptionService(db, repo)
+	service := NewProductOptionService(db, repo, nil, nil, nil)
 
 	ctx := context.Background()
 	testID := int64(1)
@@ -37,10 +42,69 @@ func TestUpdateProductOption_RollbackOnError(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestCreateProductOption_EnqueuesBranchesSellingProduct(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	redisClient, redisMock := redismock.NewClientMock()
+	branchRepo := repositories.NewMySQLRepository(db)
+	repo := &mockProductOptionRepository{}
+	service := NewProductOptionService(db, repo, branchRepo, branchRepo, repositories.NewRedisOutboxNotifier(redisClient, "outbox_channel"))
+
+	repo.CreateProductOptionFunc = func(ctx context.Context, dbtx ports.DBTX, productID int64, normalPrice, tagthaiPrice float64) (int64, error) {
+		assert.Equal(t, int64(6), productID)
+		return 21, nil
+	}
+	// สาขาที่ได้รับผลกระทบหาจากตัวเลือกที่เพิ่งสร้าง (ทุกสาขาที่ขายสินค้า 6)
+	repo.GetBranchIDsByProductOptionFunc = func(ctx context.Context, dbtx ports.DBTX, productOptionID int64) ([]int64, error) {
+		assert.Equal(t, int64(21), productOptionID)
+		return []int64{1}, nil
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE branch SET updated_at = NOW() WHERE id IN (?)")).
+		WithArgs(int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT").
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows(richBranchColumns).
+			AddRow(1, `{"en":"Branch","th":"สาขา"}`, nil, nil, nil, nil, nil, "6", nil, 99.0, 350.0, 89.0, 300.0))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO outbox_events")).
+		WithArgs("1", "branch", "updated", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	redisMock.ExpectPublish("outbox_channel", "new_event").SetVal(1)
+
+	option, err := service.CreateProductOption(context.Background(), 6, 99, 89)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(21), option.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.NoError(t, redisMock.ExpectationsWereMet())
+}
+
 // Mock Repository สำหรับ ProductOption
 type mockProductOptionRepository struct {
-	UpdateProductOptionFunc func(ctx context.Context, dbtx ports.DBTX, id int64, normalPrice, tagthaiPrice float64) error
-	DeleteProductOptionFunc func(ctx context.Context, dbtx ports.DBTX, id int64) error
+	CreateProductOptionFunc         func(ctx context.Context, dbtx ports.DBTX, productID int64, normalPrice, tagthaiPrice float64) (int64, error)
+	UpdateProductOptionFunc         func(ctx context.Context, dbtx ports.DBTX, id int64, normalPrice, tagthaiPrice float64) error
+	DeleteProductOptionFunc         func(ctx context.Context, dbtx ports.DBTX, id int64) error
+	GetBranchIDsByProductOptionFunc func(ctx context.Context, dbtx ports.DBTX, productOptionID int64) ([]int64, error)
+}
+
+func (m *mockProductOptionRepository) CreateProductOption(ctx context.Context, dbtx ports.DBTX, productID int64, normalPrice, tagthaiPrice float64) (int64, error) {
+	if m.CreateProductOptionFunc != nil {
+		return m.CreateProductOptionFunc(ctx, dbtx, productID, normalPrice, tagthaiPrice)
+	}
+	return 0, nil
+}
+
+func (m *mockProductOptionRepository) GetProductOption(ctx context.Context, dbtx ports.DBTX, id int64) (*domain.ProductOption, error) {
+	return &domain.ProductOption{ID: id}, nil
+}
+
+func (m *mockProductOptionRepository) ListProductOptions(ctx context.Context, dbtx ports.DBTX, productID int64, page domain.PageQuery) ([]*domain.ProductOption, error) {
+	return nil, nil
 }
 
 func (m *mockProductOptionRepository) UpdateProductOption(ctx context.Context, dbtx ports.DBTX, id int64, normalPrice, tagthaiPrice float64) error {
@@ -56,3 +120,10 @@ func (m *mockProductOptionRepository) DeleteProductOption(ctx context.Context, d
 	}
 	return nil
 }
+
+func (m *mockProductOptionRepository) GetBranchIDsByProductOption(ctx context.Context, dbtx ports.DBTX, productOptionID int64) ([]int64, error) {
+	if m.GetBranchIDsByProductOptionFunc != nil {
+		return m.GetBranchIDsByProductOptionFunc(ctx, dbtx, productOptionID)
+	}
+	return nil, nil
+}
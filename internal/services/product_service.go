@@ -10,15 +10,74 @@ import (
 )
 
 type productService struct {
-	db   *sql.DB
-	repo ports.ProductRepository
+	db        *sql.DB
+	repo      ports.ProductRepository
+	reindexer *branchReindexer
 }
 
-func NewProductService(db *sql.DB, repo ports.ProductRepository) ports.ProductService {
-	return &productService{db: db, repo: repo}
+func NewProductService(db *sql.DB, repo ports.ProductRepository, branchRepo ports.BranchRepository, outboxRepo ports.OutboxRepository, notifier ports.OutboxNotifier) ports.ProductService {
+	return &productService{
+		db:        db,
+		repo:      repo,
+		reindexer: &branchReindexer{branchRepo: branchRepo, outboxRepo: outboxRepo, notifier: notifier},
+	}
 }
 
+// CreateProduct สร้างสินค้าใหม่ สินค้าใหม่ยังไม่ถูกเชื่อมโยงกับสาขาใด จึงไม่มีสาขาที่ต้อง reindex
+func (s *productService) CreateProduct(ctx context.Context, name domain.BranchNameJSON) (*domain.Product, error) {
+	if err := name.Validate("name"); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	id, err := s.repo.CreateProduct(ctx, tx, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create product in transaction: %w", err)
+	}
+	product, err := s.repo.GetProduct(ctx, tx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read created product: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return product, nil
+}
+
+func (s *productService) GetProduct(ctx context.Context, id int64) (*domain.Product, error) {
+	return s.repo.GetProduct(ctx, s.db, id)
+}
+
+// ListProducts คืนสินค้าหนึ่งหน้า อ่านเกินหนึ่งแถวเพื่อรู้ว่ามีหน้าถัดไปหรือไม่ แทนการเดาจากว่าหน้าเต็ม
+func (s *productService) ListProducts(ctx context.Context, page domain.PageQuery) (*domain.ProductPage, error) {
+	limit := page.Limit
+	page.Limit = limit + 1
+	products, err := s.repo.ListProducts(ctx, s.db, page)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &domain.ProductPage{Products: products}
+	if len(products) > limit {
+		result.Products = products[:limit]
+		result.NextAfterID = result.Products[limit-1].ID
+	}
+	return result, nil
+}
+
+// UpdateProduct เปลี่ยนชื่อสินค้า ไม่ต้อง reindex สาขา เพราะ document ของสาขาเก็บเพียง product_ids และช่วงราคา
+// ไม่ได้เก็บชื่อสินค้า (ถ้าวันหนึ่ง document มีชื่อ ต้องกลับมาเขียน event ของสาขาที่เชื่อมโยงอยู่)
 func (s *productService) UpdateProduct(ctx context.Context, id int64, name domain.BranchNameJSON) error {
+	if err := name.Validate("name"); err != nil {
+		return err
+	}
+
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
@@ -39,9 +98,24 @@ func (s *productService) DeleteProduct(ctx context.Context, id int64) error {
 	}
 	defer tx.Rollback()
 
+	// ต้องหาสาขาที่ได้รับผลกระทบก่อนลบ เพราะ ON DELETE CASCADE จะลบแถวใน branches_products ไปด้วย
+	branchIDs, err := s.repo.GetBranchIDsByProduct(ctx, tx, id)
+	if err != nil {
+		return fmt.Errorf("failed to find branches affected by product %d: %w", id, err)
+	}
+
 	if err := s.repo.DeleteProduct(ctx, tx, id); err != nil {
 		return fmt.Errorf("failed to delete product in transaction: %w", err)
 	}
 
-	return tx.Commit()
+	// ข้อมูลที่อ่านหลังการลบจะไม่มีสินค้านี้แล้ว ทำให้ product_ids และช่วงราคาของสาขาถูกต้อง
+	if err := s.reindexer.enqueue(ctx, tx, branchIDs); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	s.reindexer.notify(ctx, branchIDs)
+	return nil
 }
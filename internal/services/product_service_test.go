@@ -2,13 +2,18 @@ package services
 
 import (
 	"context"
+	"database/sql"
 	"errors"
+	"regexp"
+	"strconv"
 	"testing"
 
 	"ES/internal/domain"
 	"ES/internal/ports"
+	"ES/internal/repositories"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-redis/redismock/v8"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -19,7 +24,7 @@ func TestUpdateProduct_RollbackOnError(t *testing.T) {
 	defer db.Close()
 
 	repo := &mockProductRepository{}
-	service := NewProductService(db, repo)
+	service := NewProductService(db, repo, nil, nil, nil)
 
 	ctx := context.Background()
 	testID := int64(1)
@@ -30,6 +35,10 @@ func TestUpdateProduct_RollbackOnError(t *testing.T) {
 		return expectedError
 	}
 
+	// ชื่อว่างถูกปฏิเสธก่อนเริ่ม transaction
+	err = service.UpdateProduct(ctx, testID, domain.BranchNameJSON{})
+	assert.ErrorIs(t, err, domain.ErrValidation)
+
 	mock.ExpectBegin()
 	mock.ExpectRollback()
 
@@ -40,10 +49,166 @@ func TestUpdateProduct_RollbackOnError(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestUpdateProduct_DoesNotReindexBranches(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	// document ของสาขาไม่มีชื่อสินค้า การเปลี่ยนชื่อจึงต้องไม่หาสาขาที่เชื่อมโยงหรือเขียน event
+	repo := &mockProductRepository{}
+	repo.GetBranchIDsByProductFunc = func(ctx context.Context, dbtx ports.DBTX, productID int64) ([]int64, error) {
+		t.Fatal("renaming a product must not look up linked branches")
+		return nil, nil
+	}
+	service := NewProductService(db, repo, nil, nil, nil)
+
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	err = service.UpdateProduct(context.Background(), 5, domain.BranchNameJSON{EN: "Renamed", TH: "ชื่อใหม่"})
+
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteProduct_EnqueuesAffectedBranches(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	redisClient, redisMock := redismock.NewClientMock()
+
+	branchRepo := repositories.NewMySQLRepository(db)
+	repo := &mockProductRepository{}
+	service := NewProductService(db, repo, branchRepo, branchRepo, repositories.NewRedisOutboxNotifier(redisClient, "outbox_channel"))
+
+	ctx := context.Background()
+	testID := int64(5)
+	deleted := false
+
+	// สาขาที่ได้รับผลกระทบต้องถูกหาก่อนการลบ เพราะ ON DELETE CASCADE จะลบแถวเชื่อมโยงไปด้วย
+	repo.GetBranchIDsByProductFunc = func(ctx context.Context, dbtx ports.DBTX, productID int64) ([]int64, error) {
+		assert.False(t, deleted, "affected branches must be looked up before the delete")
+		return []int64{1, 13}, nil
+	}
+	repo.DeleteProductFunc = func(ctx context.Context, dbtx ports.DBTX, id int64) error {
+		deleted = true
+		return nil
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE branch SET updated_at = NOW() WHERE id IN (?, ?)")).
+		WithArgs(int64(1), int64(13)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	for _, branchID := range []int64{1, 13} {
+		mock.ExpectQuery("SELECT").
+			WithArgs(branchID).
+			WillReturnRows(sqlmock.NewRows(richBranchColumns).
+				AddRow(branchID, `{"en":"Branch","th":"สาขา"}`, nil, nil, nil, nil, nil, "6,7", nil, 600.0, 750.0, 540.0, 660.0))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO outbox_events (aggregate_id, aggregate_type, event_type, payload) VALUES (?, ?, ?, ?)")).
+			WithArgs(strconv.FormatInt(branchID, 10), "branch", "updated", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(branchID, 1))
+	}
+	mock.ExpectCommit()
+	redisMock.ExpectPublish("outbox_channel", "new_event").SetVal(1)
+
+	err = service.DeleteProduct(ctx, testID)
+
+	require.NoError(t, err)
+	assert.True(t, deleted)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.NoError(t, redisMock.ExpectationsWereMet())
+}
+
+func TestListProducts_ReadsOneExtraRowToDetectNextPage(t *testing.T) {
+	repo := &mockProductRepository{}
+	service := NewProductService(nil, repo, nil, nil, nil)
+
+	stored := []*domain.Product{{ID: 3}, {ID: 7}, {ID: 9}}
+	repo.ListProductsFunc = func(ctx context.Context, dbtx ports.DBTX, page domain.PageQuery) ([]*domain.Product, error) {
+		if len(stored) > page.Limit {
+			return stored[:page.Limit], nil
+		}
+		return stored, nil
+	}
+
+	// limit 2: แถวที่สามบอกว่ายังมีหน้าถัดไป และไม่ถูกส่งคืน
+	page, err := service.ListProducts(context.Background(), domain.PageQuery{Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, stored[:2], page.Products)
+	assert.Equal(t, int64(7), page.NextAfterID)
+
+	// หน้าเต็มพอดีแต่ไม่มีแถวเกิน จึงไม่มีหน้าถัดไป
+	page, err = service.ListProducts(context.Background(), domain.PageQuery{Limit: 3})
+	require.NoError(t, err)
+	assert.Len(t, page.Products, 3)
+	assert.Zero(t, page.NextAfterID)
+}
+
+func TestCreateProduct_ReturnsCreatedProduct(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := &mockProductRepository{}
+	service := NewProductService(db, repo, nil, nil, nil)
+	name := domain.BranchNameJSON{EN: "Product K", TH: "สินค้า K"}
+
+	var readInTx bool
+	repo.CreateProductFunc = func(ctx context.Context, dbtx ports.DBTX, n domain.BranchNameJSON) (int64, error) {
+		assert.Equal(t, name, n)
+		return 11, nil
+	}
+	repo.GetProductFunc = func(ctx context.Context, dbtx ports.DBTX, id int64) (*domain.Product, error) {
+		// สินค้าที่เพิ่งสร้างต้องถูกอ่านภายใน transaction เดียวกัน
+		_, readInTx = dbtx.(*sql.Tx)
+		return &domain.Product{ID: id, Name: name}, nil
+	}
+
+	// ชื่อว่างถูกปฏิเสธก่อนเริ่ม transaction
+	_, err = service.CreateProduct(context.Background(), domain.BranchNameJSON{})
+	assert.ErrorIs(t, err, domain.ErrValidation)
+
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	product, err := service.CreateProduct(context.Background(), name)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(11), product.ID)
+	assert.True(t, readInTx)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 // Mock Repository สำหรับ Product
 type mockProductRepository struct {
-	UpdateProductFunc func(ctx context.Context, dbtx ports.DBTX, id int64, name domain.BranchNameJSON) error
-	DeleteProductFunc func(ctx context.Context, dbtx ports.DBTX, id int64) error
+	CreateProductFunc         func(ctx context.Context, dbtx ports.DBTX, name domain.BranchNameJSON) (int64, error)
+	GetProductFunc            func(ctx context.Context, dbtx ports.DBTX, id int64) (*domain.Product, error)
+	UpdateProductFunc         func(ctx context.Context, dbtx ports.DBTX, id int64, name domain.BranchNameJSON) error
+	DeleteProductFunc         func(ctx context.Context, dbtx ports.DBTX, id int64) error
+	GetBranchIDsByProductFunc func(ctx context.Context, dbtx ports.DBTX, productID int64) ([]int64, error)
+	ListProductsFunc          func(ctx context.Context, dbtx ports.DBTX, page domain.PageQuery) ([]*domain.Product, error)
+}
+
+func (m *mockProductRepository) CreateProduct(ctx context.Context, dbtx ports.DBTX, name domain.BranchNameJSON) (int64, error) {
+	if m.CreateProductFunc != nil {
+		return m.CreateProductFunc(ctx, dbtx, name)
+	}
+	return 0, nil
+}
+
+func (m *mockProductRepository) GetProduct(ctx context.Context, dbtx ports.DBTX, id int64) (*domain.Product, error) {
+	if m.GetProductFunc != nil {
+		return m.GetProductFunc(ctx, dbtx, id)
+	}
+	return &domain.Product{ID: id}, nil
+}
+
+func (m *mockProductRepository) ListProducts(ctx context.Context, dbtx ports.DBTX, page domain.PageQuery) ([]*domain.Product, error) {
+	if m.ListProductsFunc != nil {
+		return m.ListProductsFunc(ctx, dbtx, page)
+	}
+	return nil, nil
 }
 
 func (m *mockProductRepository) UpdateProduct(ctx context.Context, dbtx ports.DBTX, id int64, name domain.BranchNameJSON) error {
@@ -59,3 +224,10 @@ func (m *mockProductRepository) DeleteProduct(ctx context.Context, dbtx ports.DB
 	}
 	return nil
 }
+
+func (m *mockProductRepository) GetBranchIDsByProduct(ctx context.Context, dbtx ports.DBTX, productID int64) ([]int64, error) {
+	if m.GetBranchIDsByProductFunc != nil {
+		return m.GetBranchIDsByProductFunc(ctx, dbtx, productID)
+	}
+	return nil, nil
+}